@@ -5,18 +5,23 @@ package cmd
 
 import (
 	"bufio"
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/AntoninoAdornetto/issue-summoner/pkg/scm"
 	"github.com/AntoninoAdornetto/issue-summoner/pkg/ui"
+	"github.com/AntoninoAdornetto/issue-summoner/pkg/utils"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 	"github.com/spf13/cobra"
 )
 
-// We only support GitHub, at the moment, but eventually I want to support all that are contained
-// in the `allowedPlatforms` slice.
-var allowedPlatforms = []string{scm.GITHUB, scm.GITLAB, scm.BITBUCKET}
+// We only support GitHub and Gitea, at the moment, but eventually I want to support all that
+// are contained in the `allowedPlatforms` slice.
+var allowedPlatforms = []string{scm.GITHUB, scm.GITLAB, scm.BITBUCKET, scm.GITEA}
 
 // authorizeCmd represents the authorize command
 var authorizeCmd = &cobra.Command{
@@ -51,8 +56,32 @@ var authorizeCmd = &cobra.Command{
 			)
 		}
 
-		accessToken, err := scm.ReadAccessToken(sourceCodeManager)
-		if err != nil && !os.IsNotExist(err) {
+		encrypt, err := cmd.Flags().GetBool(flag_encrypt)
+		if err != nil {
+			ui.LogFatal(err.Error())
+		}
+
+		noBrowser, err := cmd.Flags().GetBool(flag_no_browser)
+		if err != nil {
+			ui.LogFatal(err.Error())
+		}
+
+		tokenRepo, err := cmd.Flags().GetString(flag_token_repo)
+		if err != nil {
+			ui.LogFatal(err.Error())
+		}
+
+		repo := scm.RemoteRepository{}
+		if tokenRepo != "" {
+			owner, name, ok := strings.Cut(tokenRepo, "/")
+			if !ok || owner == "" || name == "" || strings.Contains(name, "/") {
+				ui.LogFatal(fmt.Sprintf("expected --%s to be owner/repo but got %s", flag_token_repo, tokenRepo))
+			}
+			repo = scm.RemoteRepository{Owner: owner, Name: name}
+		}
+
+		accessToken, err := readAccessToken(sourceCodeManager, repo)
+		if err != nil && !errors.Is(err, scm.ErrConfigNotFound) && !errors.Is(err, scm.ErrTokenMissing) {
 			ui.LogFatal(err.Error())
 		}
 
@@ -80,24 +109,76 @@ var authorizeCmd = &cobra.Command{
 			}
 		}
 
-		spinner := &tea.Program{}
+		newPassphrase := ""
+		if encrypt {
+			newPassphrase, err = promptNewPassphrase()
+			if err != nil {
+				ui.LogFatal(err.Error())
+			}
+		}
+
+		// quitting the spinner (q, esc or ctrl+c) stops polling for the access token
+		ctx, cancel := context.WithCancel(cmd.Context())
+		defer cancel()
+
+		spinner := tea.NewProgram(ui.InitialModelNew("Pending Authorization..."))
 		go func() {
-			spinner = tea.NewProgram(ui.InitialModelNew("Pending Authorization..."))
+			defer cancel()
 			if _, err := spinner.Run(); err != nil {
 				ui.LogFatal(err.Error())
 			}
 		}()
 
-		gitManager, err := scm.NewGitManager(sourceCodeManager, "", "")
+		config, err := readConfig()
 		if err != nil {
 			ui.LogFatal(err.Error())
 		}
 
-		err = gitManager.Authorize()
+		gitManager, err := scm.NewGitManager(
+			scm.GitConfig{
+				Scm:            sourceCodeManager,
+				UserName:       repo.Owner,
+				RepositoryName: repo.Name,
+				BaseURL:        config.PlatformURL(sourceCodeManager),
+			},
+		)
+		if err != nil {
+			ui.LogFatal(err.Error())
+		}
+
+		opts := scm.DeviceFlowOptions{
+			ShowUserCode: func(userCode, verificationURI string, opened bool) {
+				// Send, unlike Println, does not block once the spinner has exited
+				spinner.Send(tea.Println(renderUserCode(userCode, verificationURI, opened))())
+			},
+			ReadToken: func(tokenURL string) (string, error) {
+				// the spinner has to release stdin before the token can be read
+				if err := spinner.ReleaseTerminal(); err != nil {
+					return "", err
+				}
+				defer spinner.RestoreTerminal()
+
+				fmt.Println(ui.PrimaryTextStyle.Render(fmt.Sprintf(
+					"Create an access token with the %s scopes at %s",
+					scm.GITEA_TOKEN_SCOPES,
+					tokenURL,
+				)))
+				return readPassword("Paste the access token: ")
+			},
+		}
+
+		if !noBrowser {
+			opts.OpenBrowser = utils.OpenBrowser
+		}
+
+		err = gitManager.Authorize(ctx, opts)
 		if err != nil {
 			if releaseErr := spinner.ReleaseTerminal(); releaseErr != nil {
 				ui.ErrorTextStyle.Render("Error releasing terminal\n%s", releaseErr.Error())
 			}
+			if errors.Is(err, context.Canceled) {
+				ui.LogFatal("Authorization process aborted")
+			}
 			ui.LogFatal(fmt.Errorf("Authorization failed.\n%s", err).Error())
 		}
 
@@ -106,6 +187,12 @@ var authorizeCmd = &cobra.Command{
 			ui.LogFatal(err.Error())
 		}
 
+		if encrypt {
+			if err := scm.EncryptAccessToken(sourceCodeManager, newPassphrase); err != nil {
+				ui.LogFatal(fmt.Errorf("Failed to encrypt access token.\n%s", err).Error())
+			}
+		}
+
 		fmt.Println(
 			ui.SuccessTextStyle.Render(
 				fmt.Sprintf("Authorization for %s succeeded!", sourceCodeManager),
@@ -114,7 +201,25 @@ var authorizeCmd = &cobra.Command{
 	},
 }
 
+// renderUserCode is printed above the spinner, so that it isn't overwritten while
+// we wait for authorization
+func renderUserCode(userCode, verificationURI string, opened bool) string {
+	instructions := fmt.Sprintf("Please visit %s and enter the code below", verificationURI)
+	if opened {
+		instructions = fmt.Sprintf("Enter the code below in your browser (%s)", verificationURI)
+	}
+
+	return fmt.Sprintf(
+		"%s\n\n    %s\n",
+		ui.PrimaryTextStyle.Render(instructions),
+		ui.AccentTextStyle.Padding(0, 2).Border(lipgloss.RoundedBorder()).Render(userCode),
+	)
+}
+
 func init() {
 	rootCmd.AddCommand(authorizeCmd)
 	authorizeCmd.Flags().StringP(flag_scm, shortflag_scm, scm.GITHUB, flag_desc_scm)
+	authorizeCmd.Flags().Bool(flag_encrypt, false, flag_desc_encrypt)
+	authorizeCmd.Flags().Bool(flag_no_browser, false, flag_desc_no_browser)
+	authorizeCmd.Flags().String(flag_token_repo, "", flag_desc_token_repo)
 }
@@ -0,0 +1,153 @@
+/*
+Copyright © 2024 AntoninoAdornetto
+*/
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/AntoninoAdornetto/issue-summoner/pkg/ignore"
+	"github.com/AntoninoAdornetto/issue-summoner/pkg/issue"
+	"github.com/AntoninoAdornetto/issue-summoner/pkg/scm"
+	"github.com/AntoninoAdornetto/issue-summoner/pkg/ui"
+	"github.com/spf13/cobra"
+)
+
+var closeCmd = &cobra.Command{
+	Use:   "close",
+	Short: "Close the issues whose annotations were removed from the source code",
+	Long: `Close will scan your git project for the @ISSUE(<ref>) annotations that report writes back
+once an issue is created. The open issues that were created by issue-summoner, and are no longer
+referenced by any annotation, are closed since their annotation has been removed.
+Issues that were created by hand are never closed. Use --dry-run to see which issues would be closed.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		dryRun, err := cmd.Flags().GetBool(flag_dry_run)
+		if err != nil {
+			ui.LogFatal(err.Error())
+		}
+
+		rateLimit, err := cmd.Flags().GetFloat64(flag_rate_limit)
+		if err != nil {
+			ui.LogFatal(err.Error())
+		}
+
+		config, err := readConfig()
+		if err != nil {
+			ui.LogFatal(err.Error())
+		}
+
+		path := projectPath(cmd)
+		issueManager, err := issue.NewIssueManager(issue.PROCESSED_ISSUE, "")
+		if err != nil {
+			ui.LogFatal(err.Error())
+		}
+
+		scanHidden, allowHidden := hiddenFlags(cmd)
+		_, err = issueManager.Walk(issue.WalkParams{
+			Root:             path,
+			ExcludesFile:     excludesFile(cmd, path),
+			IgnoreCase:       ignoreCase(cmd),
+			NoDefaultIgnores: noDefaultIgnores(cmd),
+			ScanHidden:       scanHidden,
+			AllowHidden:      allowHidden,
+		})
+		// a partial scan could close issues that are still referenced by the files that
+		// were not scanned
+		if err != nil {
+			if err := walkError(path, err); err != nil {
+				ui.LogFatal(err.Error())
+			}
+			ui.LogFatal("no issues were closed, since some files could not be scanned")
+		}
+
+		gitConfig, _, err := resolveGitConfig(cmd, config, path)
+		if err != nil {
+			ui.LogFatal(err.Error())
+		}
+
+		gitConfig.RateLimit.RequestsPerSecond = rateLimit
+		if rateLimit <= 0 {
+			gitConfig.RateLimit.RequestsPerSecond = -1
+		}
+
+		gitManager, err := scm.NewGitManager(gitConfig)
+		if err != nil {
+			ui.LogFatal(err.Error())
+		}
+
+		repository, err := gitManager.VerifyRepository()
+		if err != nil {
+			ui.LogFatal(err.Error())
+		}
+
+		open, err := gitManager.ListIssues(scm.ISSUE_STATE_OPEN)
+		if err != nil {
+			ui.LogFatal(err.Error())
+		}
+
+		referenced := referencedIssues(issueManager.GetIssues(), repository)
+		resolved := scm.ResolvedIssues(open, referenced)
+		if len(resolved) == 0 {
+			fmt.Println(ui.SecondaryTextStyle.Render("No issues to close, every open issue is still referenced"))
+			return
+		}
+
+		closed := 0
+		for _, is := range resolved {
+			if dryRun {
+				fmt.Println(ui.DimTextStyle.Render(fmt.Sprintf("Would close #%d %q", is.Number, is.Title)))
+				continue
+			}
+
+			if err := gitManager.CloseIssue(int64(is.Number)); err != nil {
+				fmt.Println(ui.ErrorTextStyle.Render(err.Error()))
+				continue
+			}
+
+			closed++
+			fmt.Println(ui.DimTextStyle.Render(fmt.Sprintf("Closed #%d %q", is.Number, is.Title)))
+		}
+
+		if dryRun {
+			return
+		}
+
+		fmt.Println(
+			ui.SuccessTextStyle.Render(
+				fmt.Sprintf("Success! Closed %d issue(s) in %s", closed, repository),
+			),
+		)
+	},
+}
+
+// referencedIssues returns the numbers of the issues, of repository, that are referenced
+// by the processed annotations
+func referencedIssues(issues []issue.Issue, repository scm.RemoteRepository) map[int64]bool {
+	referenced := make(map[int64]bool)
+	for _, is := range issues {
+		ref, ok := issue.IssueRef(is.Annotation)
+		if !ok {
+			continue
+		}
+
+		if number, ok := scm.ReferenceNumber(ref, repository.Owner, repository.Name); ok {
+			referenced[number] = true
+		}
+	}
+	return referenced
+}
+
+func init() {
+	rootCmd.AddCommand(closeCmd)
+	closeCmd.Flags().StringP(flag_path, shortflag_path, "", flag_desc_path)
+	closeCmd.Flags().StringP(flag_scm, shortflag_scm, scm.GITHUB, flag_desc_scm)
+	closeCmd.Flags().String(flag_owner, "", flag_desc_owner)
+	closeCmd.Flags().String(flag_repo, "", flag_desc_repo)
+	closeCmd.Flags().Bool(flag_dry_run, false, flag_desc_dry_run)
+	closeCmd.Flags().Bool(flag_no_excludes, false, flag_desc_no_excludes)
+	closeCmd.Flags().Bool(flag_ignore_case, ignore.DEFAULT_IGNORE_CASE, flag_desc_ignore_case)
+	closeCmd.Flags().Bool(flag_no_defaults, false, flag_desc_no_defaults)
+	closeCmd.Flags().Bool(flag_scan_hidden, false, flag_desc_scan_hidden)
+	closeCmd.Flags().StringSlice(flag_allow_hidden, issue.DefaultAllowHidden, flag_desc_allow_hidden)
+	closeCmd.Flags().Float64(flag_rate_limit, scm.DEFAULT_REQUESTS_PER_SECOND, flag_desc_rate_limit)
+}
@@ -1,11 +1,19 @@
 package cmd
 
 import (
+	"errors"
+	"fmt"
+	"io/fs"
 	"os"
+	"path/filepath"
 
+	"github.com/AntoninoAdornetto/issue-summoner/pkg/filter"
+	"github.com/AntoninoAdornetto/issue-summoner/pkg/issue"
 	"github.com/AntoninoAdornetto/issue-summoner/pkg/scm"
 	"github.com/AntoninoAdornetto/issue-summoner/pkg/ui"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"golang.org/x/term"
 )
 
 const (
@@ -13,8 +21,11 @@ const (
 	no_issues            = "No issues were found in your project using the annotation: "
 	found_issues         = "Number of issues found: "
 	select_issues        = "Select the issues you wish to report"
+	select_height        = 10
 	issue_template_path  = "./templates/issue.tmpl"
 	tip_verbose          = "Tip: run issue-summoner scan -v (verbose) for more details about the tag annotations that were found"
+	tip_show_ignores     = "The .gitignore files of subdirectories are applied as the scan enters them"
+	clear_line           = "\r\033[K"
 	flag_path            = "path"
 	flag_mode            = "mode"
 	flag_scm             = "scm"
@@ -32,6 +43,104 @@ const (
 	flag_desc_annotation = "The issue annotation to search for. Example: @TODO:"
 )
 
+// flags that are specific to a single command
+const (
+	flag_no_hooks              = "no-hooks"
+	flag_encrypt               = "encrypt"
+	flag_issueignore_path      = "issueignorePath"
+	flag_no_browser            = "no-browser"
+	flag_preset                = "preset"
+	flag_owner                 = "owner"
+	flag_repo                  = "repo"
+	flag_filter                = "filter"
+	flag_force                 = "force"
+	flag_no_cache              = "no-cache"
+	flag_workspace             = "workspace"
+	flag_workspace_depth       = "workspace-depth"
+	flag_token_repo            = "token-repo"
+	flag_label                 = "label"
+	flag_assignee              = "assignee"
+	flag_status                = "status"
+	flag_no_excludes           = "no-global-excludes"
+	flag_rate_limit            = "rate-limit"
+	flag_ignore_file           = "ignore-file"
+	flag_similarity            = "similarity"
+	flag_ignore_case           = "ignore-case"
+	flag_no_defaults           = "no-default-ignores"
+	flag_show_ignores          = "show-ignores"
+	flag_include               = "include"
+	flag_summary               = "summary"
+	flag_format                = "format"
+	format_text                = "text"
+	format_json                = "json"
+	flag_include_binary        = "include-binary"
+	flag_since                 = "since"
+	flag_max_file_size         = "max-file-size"
+	flag_follow_symlinks       = "follow-symlinks"
+	flag_stdin                 = "stdin"
+	flag_ext                   = "ext"
+	flag_tracked_only          = "tracked-only"
+	flag_scan_hidden           = "scan-hidden"
+	flag_allow_hidden          = "allow-hidden"
+	flag_explain_ignore        = "explain-ignore"
+	flag_max_depth             = "max-depth"
+	flag_dry_run               = "dry-run"
+	flag_annotation_label      = "annotation-label"
+	flag_fresh                 = "fresh"
+	flag_desc_no_hooks         = "skip running the hooks.issue_created command from the config file"
+	flag_desc_encrypt          = "encrypt the access token with a passphrase. ISSUE_SUMMONER_PASSPHRASE can be used instead of prompting"
+	flag_desc_issueignore_path = "path to an ignore file, using gitignore syntax, for files that should not be scanned. defaults to .issueignore and .issuesummonerignore in the root of your project. --ignore-file is an alias"
+	flag_desc_no_browser       = "don't open the verification url in the default browser"
+	flag_desc_preset           = "a named bundle of flag values, such as security. flags that are set explicitly take precedence"
+	flag_desc_owner            = "the owner of the repository that issues are reported to, or read from. overrides the owner of the git remote url"
+	flag_desc_repo             = "the name of the repository that issues are reported to, or read from. overrides the repository of the git remote url"
+	flag_desc_filter           = `only include issues that match the expression. Example: keyword == "@FIXME" && path =~ "^pkg/"`
+	flag_desc_force            = "report issues even when an open issue was already created for the annotation"
+	flag_desc_no_cache         = "scan every file, instead of reusing the results for files that have not changed since the last scan"
+	flag_desc_workspace        = "a directory containing multiple git repositories. each repository beneath it is processed with its own ignore files and remote"
+	flag_desc_workspace_depth  = "how many directories beneath the workspace are searched for git repositories"
+	flag_desc_token_repo       = "store the access token for a single repository, owner/repo, instead of every repository on the platform"
+	flag_desc_label            = "a label to add to every reported issue. can be repeated or comma separated"
+	flag_desc_assignee         = "a user to assign every reported issue to. can be repeated or comma separated"
+	flag_desc_status           = "fetch the issues that were reported for the annotations and show if each one is pending, reported or resolved"
+	flag_desc_no_excludes      = "ignore the global excludes file of git, core.excludesFile, so scans are reproducible across machines"
+	flag_desc_rate_limit       = "the max number of issues that are created per second. 0 creates them as fast as possible"
+	flag_desc_ignore_case      = "match the patterns of ignore files without regard to case. defaults to true on macOS and windows"
+	flag_desc_no_defaults      = "scan dependency, build and editor directories such as node_modules/ and vendor/, which are skipped by default"
+	flag_desc_show_ignores     = "print the ignore files and patterns that apply to the root of the project, from the lowest to the highest precedence, and exit"
+	flag_desc_include          = "only scan the files that match a pattern, using gitignore syntax, such as '**/*.go' or cmd/. can be repeated or comma separated"
+	flag_desc_summary          = "print the number of files that were scanned and skipped and the number of issues of each language and annotation, instead of the issues"
+	flag_desc_format           = "the output format of --summary and --stdin, text or json"
+	flag_desc_include_binary   = "scan files that look binary, which are skipped by default"
+	flag_desc_since            = "only scan the files that changed since a git ref, such as a branch, tag or commit. uncommitted changes are included"
+	flag_desc_max_file_size    = "the size in bytes of the largest file that is scanned, larger files are skipped. 0 scans files of any size"
+	flag_desc_follow_symlinks  = "scan the files and directories that symlinks point to, which are skipped by default. each directory is only scanned once"
+	flag_desc_stdin            = "scan the source code of a single file that is read from stdin, such as an unsaved editor buffer. requires --ext"
+	flag_desc_ext              = "the file extension of the source code read with --stdin, such as .go"
+	flag_desc_tracked_only     = "only scan the files that git tracks, as listed by git ls-files. ignored and untracked files are skipped"
+	flag_desc_scan_hidden      = "scan hidden files and directories, whose name starts with a dot, which are skipped by default. .git is always skipped"
+	flag_desc_allow_hidden     = "the name of a hidden file or directory that is scanned even though hidden entries are skipped. can be repeated or comma separated"
+	flag_desc_explain_ignore   = "print the ignore file and pattern that decide whether a path, relative to the root of the project, is ignored and exit"
+	flag_desc_max_depth        = "how many directories beneath the root of the project are scanned. 0 only scans the files in the root, a negative depth is unlimited"
+	flag_desc_annotation_label = "add a label to the issues of an annotation, such as @BUG=bug. can be repeated or comma separated"
+	flag_desc_dry_run          = "print the issues that would be closed without closing them"
+	flag_desc_fresh            = "discard the issues that an interrupted report run created but couldn't write back to their annotations, instead of resuming the run"
+	flag_desc_similarity       = "how similar, from 0 to 1, the title of an open issue must be to an annotation for it to be skipped as a duplicate. 0 disables title matching"
+)
+
+// flagAliases maps alternative flag names to the name of the flag that they stand for
+var flagAliases = map[string]string{
+	flag_ignore_file: flag_issueignore_path,
+}
+
+// normalizeFlagAliases lets a flag be set with any of its aliases, see flagAliases
+func normalizeFlagAliases(_ *pflag.FlagSet, name string) pflag.NormalizedName {
+	if alias, ok := flagAliases[name]; ok {
+		name = alias
+	}
+	return pflag.NormalizedName(name)
+}
+
 // both the scan and report command will use similar flags
 func handleCommonFlags(cmd *cobra.Command) (annotation string, path string) {
 	var err error
@@ -40,7 +149,23 @@ func handleCommonFlags(cmd *cobra.Command) (annotation string, path string) {
 		ui.LogFatal(err.Error())
 	}
 
-	path, err = cmd.Flags().GetString(flag_path)
+	return annotation, projectPath(cmd)
+}
+
+// readConfig reads the config file. A missing config file is not an error, the empty
+// config is returned instead so that tokens can still be read from the environment.
+func readConfig() (scm.Config, error) {
+	config, err := scm.ReadConfig()
+	if err != nil && !errors.Is(err, scm.ErrConfigNotFound) {
+		return config, err
+	}
+	return config, nil
+}
+
+// projectPath returns the work tree of the git repository that contains the path flag,
+// or the working directory when it's not set
+func projectPath(cmd *cobra.Command) string {
+	path, err := cmd.Flags().GetString(flag_path)
 	if err != nil {
 		ui.LogFatal(err.Error())
 	}
@@ -58,5 +183,337 @@ func handleCommonFlags(cmd *cobra.Command) (annotation string, path string) {
 		ui.LogFatal(err.Error())
 	}
 
-	return annotation, repo.WorkTree
+	return repo.WorkTree
+}
+
+// workspaceFlags returns the directory of the workspace flag, which is empty when the
+// command should only process a single repository, and the max search depth
+func workspaceFlags(cmd *cobra.Command) (dir string, depth int) {
+	dir, err := cmd.Flags().GetString(flag_workspace)
+	if err != nil {
+		ui.LogFatal(err.Error())
+	}
+
+	depth, err = cmd.Flags().GetInt(flag_workspace_depth)
+	if err != nil {
+		ui.LogFatal(err.Error())
+	}
+
+	if dir == "" {
+		return "", depth
+	}
+
+	dir, err = filepath.Abs(dir)
+	if err != nil {
+		ui.LogFatal(err.Error())
+	}
+
+	return dir, depth
+}
+
+// excludesFile returns the global excludes file of git for the repository located at root,
+// or an empty string when the no-global-excludes flag is set
+func excludesFile(cmd *cobra.Command, root string) string {
+	skip, err := cmd.Flags().GetBool(flag_no_excludes)
+	if err != nil {
+		ui.LogFatal(err.Error())
+	}
+
+	if skip {
+		return ""
+	}
+
+	return scm.GlobalExcludesFile(scm.Git, root)
+}
+
+// ignoreCase reports whether the patterns of ignore files should be matched without
+// regard to case
+func ignoreCase(cmd *cobra.Command) bool {
+	fold, err := cmd.Flags().GetBool(flag_ignore_case)
+	if err != nil {
+		ui.LogFatal(err.Error())
+	}
+	return fold
+}
+
+// noDefaultIgnores reports whether the directories in ignore.DefaultIgnores should be scanned
+func noDefaultIgnores(cmd *cobra.Command) bool {
+	scan, err := cmd.Flags().GetBool(flag_no_defaults)
+	if err != nil {
+		ui.LogFatal(err.Error())
+	}
+	return scan
+}
+
+// includeFilter returns the filter of the include flag, or nil when no pattern was provided
+func includeFilter(cmd *cobra.Command) *issue.IncludeFilter {
+	patterns, err := cmd.Flags().GetStringSlice(flag_include)
+	if err != nil {
+		ui.LogFatal(err.Error())
+	}
+
+	if len(patterns) == 0 {
+		return nil
+	}
+
+	filter, err := issue.NewIncludeFilter(patterns)
+	if err != nil {
+		ui.LogFatal(err.Error())
+	}
+	return filter
+}
+
+// printFiltered prints how many files were skipped since they matched no include pattern
+func printFiltered(filter *issue.IncludeFilter) {
+	if filter == nil {
+		return
+	}
+
+	msg := fmt.Sprintf("%d file(s) did not match --include and were not scanned", filter.Filtered)
+	fmt.Println(ui.SecondaryTextStyle.Render(msg))
+}
+
+// includeBinary reports whether files that look binary should be scanned
+func includeBinary(cmd *cobra.Command) bool {
+	include, err := cmd.Flags().GetBool(flag_include_binary)
+	if err != nil {
+		ui.LogFatal(err.Error())
+	}
+	return include
+}
+
+// maxFileSize returns the size in bytes of the largest file that should be scanned
+func maxFileSize(cmd *cobra.Command) int64 {
+	size, err := cmd.Flags().GetInt64(flag_max_file_size)
+	if err != nil {
+		ui.LogFatal(err.Error())
+	}
+
+	if size < 0 {
+		ui.LogFatal(fmt.Sprintf("--%s can't be negative", flag_max_file_size))
+	}
+	return size
+}
+
+// maxDepth returns how many directories beneath the root of the project are scanned, or
+// nil when the depth is unlimited
+func maxDepth(cmd *cobra.Command) *int {
+	depth, err := cmd.Flags().GetInt(flag_max_depth)
+	if err != nil {
+		ui.LogFatal(err.Error())
+	}
+
+	if depth < 0 {
+		return nil
+	}
+	return &depth
+}
+
+// followSymlinks reports whether the files and directories that symlinks point to should
+// be scanned
+func followSymlinks(cmd *cobra.Command) bool {
+	follow, err := cmd.Flags().GetBool(flag_follow_symlinks)
+	if err != nil {
+		ui.LogFatal(err.Error())
+	}
+	return follow
+}
+
+// hiddenFlags returns whether hidden files and directories should be scanned and the names
+// of the hidden entries that are scanned anyway
+func hiddenFlags(cmd *cobra.Command) (bool, []string) {
+	scan, err := cmd.Flags().GetBool(flag_scan_hidden)
+	if err != nil {
+		ui.LogFatal(err.Error())
+	}
+
+	allow, err := cmd.Flags().GetStringSlice(flag_allow_hidden)
+	if err != nil {
+		ui.LogFatal(err.Error())
+	}
+	return scan, allow
+}
+
+// printSkipped prints the files beneath root that were skipped even though they are not
+// ignored, along with the reason they were skipped
+func printSkipped(root string, stats *issue.WalkStats) {
+	for _, file := range stats.SkippedFiles {
+		msg := fmt.Sprintf("skipped %s (%s)", relativeTo(root, file.Path), file.Reason)
+		fmt.Println(ui.DimTextStyle.Render(msg))
+	}
+}
+
+// printOversized warns about the files beneath root that were skipped since they are
+// larger than the max file size, their annotations are neither scanned nor reported
+func printOversized(root string, stats *issue.WalkStats) {
+	for _, file := range stats.SkippedFiles {
+		if file.Reason != issue.SKIP_SIZE {
+			continue
+		}
+
+		msg := fmt.Sprintf(
+			"Warning: skipped %s since it is larger than --%s",
+			relativeTo(root, file.Path),
+			flag_max_file_size,
+		)
+		fmt.Println(ui.NoteTextStyle.Render(msg))
+	}
+}
+
+// walkError prints a warning for each file that could not be scanned when err is made of
+// issue.FileErrors, which don't stop the command, and returns any other error of the walk
+func walkError(root string, err error) error {
+	var fileErrs issue.FileErrors
+	if !errors.As(err, &fileErrs) {
+		return err
+	}
+
+	printFileErrors(root, fileErrs)
+	return nil
+}
+
+// printFileErrors prints a warning, on stderr, for each file beneath root that could not
+// be scanned
+func printFileErrors(root string, errs issue.FileErrors) {
+	for _, fileErr := range errs {
+		reason := fileErr.Err
+		// the path is already part of the message
+		pathErr := &fs.PathError{}
+		if errors.As(reason, &pathErr) {
+			reason = pathErr.Err
+		}
+
+		msg := fmt.Sprintf("Warning: skipped %s, %s", relativeTo(root, fileErr.Path), reason)
+		fmt.Fprintln(os.Stderr, ui.NoteTextStyle.Render(msg))
+	}
+}
+
+// progressCounter returns the OnProgress callback of a walk, which keeps a counter of the
+// files that were scanned on stderr, and a func that clears the counter once the walk is
+// done. The counter is only shown when stderr is a terminal, the callback is nil otherwise
+// so that redirected output is not cluttered.
+func progressCounter() (func(issue.ProgressEvent), func()) {
+	if !term.IsTerminal(int(os.Stderr.Fd())) {
+		return nil, func() {}
+	}
+
+	shown := false
+	onProgress := func(event issue.ProgressEvent) {
+		msg := fmt.Sprintf(
+			"scanning... %d files scanned, %d skipped, %d issues found",
+			event.Scanned,
+			event.Skipped,
+			event.Issues,
+		)
+		fmt.Fprint(os.Stderr, clear_line+ui.DimTextStyle.Render(msg))
+		shown = true
+	}
+
+	return onProgress, func() {
+		if shown {
+			fmt.Fprint(os.Stderr, clear_line)
+		}
+	}
+}
+
+// walkFiles returns the files of the repository located at root that should be scanned,
+// or nil when every file should be. They are the files that changed since the ref of the
+// since flag, which are tracked by git, or the files that git tracks when the tracked-only
+// flag is set.
+func walkFiles(cmd *cobra.Command, root string) *issue.FileSet {
+	ref := ""
+	if cmd.Flags().Lookup(flag_since) != nil {
+		var err error
+		if ref, err = cmd.Flags().GetString(flag_since); err != nil {
+			ui.LogFatal(err.Error())
+		}
+	}
+
+	trackedOnly, err := cmd.Flags().GetBool(flag_tracked_only)
+	if err != nil {
+		ui.LogFatal(err.Error())
+	}
+
+	var files []string
+	switch {
+	case ref != "":
+		files, err = scm.ChangedFiles(scm.Git, root, ref)
+	case trackedOnly:
+		files, err = scm.TrackedFiles(scm.Git, root)
+		if err != nil {
+			err = fmt.Errorf("--%s requires a git repository: %w", flag_tracked_only, err)
+		}
+	default:
+		return nil
+	}
+
+	if err != nil {
+		ui.LogFatal(err.Error())
+	}
+	return issue.NewFileSet(files)
+}
+
+// relativeTo returns path relative to dir, or path itself when it is not beneath dir
+func relativeTo(dir, path string) string {
+	if rel, err := filepath.Rel(dir, path); err == nil {
+		return rel
+	}
+	return path
+}
+
+// issueFilter compiles the expression of the filter flag. Every issue matches when the
+// flag is not set. Paths are relative to root when the expression is evaluated.
+func issueFilter(cmd *cobra.Command, root string) filter.Predicate {
+	expr, err := cmd.Flags().GetString(flag_filter)
+	if err != nil {
+		ui.LogFatal(err.Error())
+	}
+
+	if expr == "" {
+		return func(issue.Issue) bool { return true }
+	}
+
+	pred, err := filter.Compile(expr)
+	if err != nil {
+		ui.LogFatal(err.Error())
+	}
+
+	return func(is issue.Issue) bool {
+		if rel, err := filepath.Rel(root, is.FilePath); err == nil {
+			is.FilePath = filepath.ToSlash(rel)
+		}
+		return pred(is)
+	}
+}
+
+// scanCache loads the scan cache for the project located at root. nil is returned, which
+// disables caching, when the no-cache flag is set.
+func scanCache(cmd *cobra.Command, root, annotation string) *issue.ScanCache {
+	noCache, err := cmd.Flags().GetBool(flag_no_cache)
+	if err != nil {
+		ui.LogFatal(err.Error())
+	}
+
+	if noCache {
+		return nil
+	}
+
+	path, err := scm.ScanCachePath(root)
+	if err != nil {
+		ui.LogFatal(err.Error())
+	}
+
+	return issue.LoadScanCache(path, annotation)
+}
+
+// saveScanCache writes the scan cache. Failing to write the cache doesn't prevent the
+// command from completing, so the error is only printed.
+func saveScanCache(cache *issue.ScanCache) {
+	if cache == nil {
+		return
+	}
+
+	if err := cache.Save(); err != nil {
+		fmt.Println(ui.DimTextStyle.Render(fmt.Sprintf("failed to save the scan cache: %s", err)))
+	}
 }
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/AntoninoAdornetto/issue-summoner/pkg/scm"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+)
+
+// should resolve the access token from the environment when there is no config file
+func TestResolveGitConfigEnvTokenWithoutConfig(t *testing.T) {
+	t.Setenv(scm.CONFIG_DIR_ENV, t.TempDir())
+	t.Setenv("ISSUE_SUMMONER_GITHUB_TOKEN", "")
+	t.Setenv("GITHUB_TOKEN", "env-token")
+
+	config, err := readConfig()
+	require.NoError(t, err)
+
+	cmd := &cobra.Command{}
+	cmd.Flags().String(flag_owner, "", flag_desc_owner)
+	cmd.Flags().String(flag_repo, "", flag_desc_repo)
+	cmd.Flags().String(flag_scm, scm.GITHUB, flag_desc_scm)
+	require.NoError(t, cmd.Flags().Set(flag_owner, "tech"))
+	require.NoError(t, cmd.Flags().Set(flag_repo, "debt"))
+
+	gitConfig, overridden, err := resolveGitConfig(cmd, config, t.TempDir())
+	require.NoError(t, err)
+	require.True(t, overridden)
+	require.Equal(t, "env-token", gitConfig.Token)
+	require.Equal(t, "tech", gitConfig.UserName)
+	require.Equal(t, "debt", gitConfig.RepositoryName)
+}
+
+// should still return the error of a config file that can't be decoded
+func TestReadConfigInvalid(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(scm.CONFIG_DIR_ENV, dir)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config.json"), []byte("{"), 0666))
+
+	_, err := readConfig()
+	require.ErrorContains(t, err, "invalid config file")
+}
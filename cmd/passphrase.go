@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/AntoninoAdornetto/issue-summoner/pkg/scm"
+	"github.com/AntoninoAdornetto/issue-summoner/pkg/ui"
+	"golang.org/x/term"
+)
+
+const max_passphrase_attempts = 3
+
+// passphrase is cached after it's entered so the user is only prompted once per run
+var passphrase string
+
+func init() {
+	scm.PassphraseProvider = promptPassphrase
+}
+
+// promptPassphrase is consulted by the scm package when an encrypted access token is read.
+// The ISSUE_SUMMONER_PASSPHRASE environment variable takes precedence over prompting.
+func promptPassphrase() (string, error) {
+	if env := os.Getenv(scm.PASSPHRASE_ENV); env != "" {
+		return env, nil
+	}
+
+	if passphrase != "" {
+		return passphrase, nil
+	}
+
+	input, err := readPassword("Enter the passphrase for your access token: ")
+	if err != nil {
+		return "", err
+	}
+
+	passphrase = input
+	return passphrase, nil
+}
+
+// promptNewPassphrase asks for a passphrase twice and ensures both entries match
+func promptNewPassphrase() (string, error) {
+	if env := os.Getenv(scm.PASSPHRASE_ENV); env != "" {
+		return env, nil
+	}
+
+	first, err := readPassword("Enter a passphrase to encrypt your access token: ")
+	if err != nil {
+		return "", err
+	}
+
+	second, err := readPassword("Confirm the passphrase: ")
+	if err != nil {
+		return "", err
+	}
+
+	if first != second {
+		return "", errors.New("passphrases do not match")
+	}
+
+	if first == "" {
+		return "", scm.ErrPassphraseRequired
+	}
+
+	return first, nil
+}
+
+func readPassword(prompt string) (string, error) {
+	fmt.Print(ui.PrimaryTextStyle.Render(prompt))
+	input, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Println()
+	return string(input), err
+}
+
+// readAccessToken reads the access token and re-prompts for the passphrase when the
+// passphrase that was entered fails to decrypt the token
+func readAccessToken(sourceCodeManager string, repo scm.RemoteRepository) (string, error) {
+	for attempt := 1; ; attempt++ {
+		token, err := scm.ReadAccessToken(sourceCodeManager, repo)
+		if !retryPassphrase(err, attempt) {
+			return token, err
+		}
+	}
+}
+
+// loadGitConfig loads the git config of the repository located at path and re-prompts
+// for the passphrase when the passphrase that was entered fails to decrypt the token
+func loadGitConfig(path string) (scm.GitConfig, error) {
+	for attempt := 1; ; attempt++ {
+		gitConfig, err := scm.LoadGitConfig(path)
+		if !retryPassphrase(err, attempt) {
+			return gitConfig, err
+		}
+	}
+}
+
+// retryPassphrase reports whether the access token should be read again, after the
+// cached passphrase is cleared, because the passphrase that was entered is wrong
+func retryPassphrase(err error, attempt int) bool {
+	if !errors.Is(err, scm.ErrWrongPassphrase) ||
+		attempt == max_passphrase_attempts ||
+		os.Getenv(scm.PASSPHRASE_ENV) != "" {
+		return false
+	}
+
+	passphrase = ""
+	fmt.Println(ui.ErrorTextStyle.Render(err.Error()))
+	return true
+}
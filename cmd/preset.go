@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/AntoninoAdornetto/issue-summoner/pkg/preset"
+	"github.com/AntoninoAdornetto/issue-summoner/pkg/ui"
+	"github.com/spf13/cobra"
+)
+
+// applyPreset resolves the preset flag, from the built-in presets and the presets in the
+// config file, and sets the flag values of the preset before the command reads its flags
+func applyPreset(cmd *cobra.Command) {
+	name, err := cmd.Flags().GetString(flag_preset)
+	if err != nil {
+		ui.LogFatal(err.Error())
+	}
+
+	if name == "" {
+		return
+	}
+
+	config, err := readConfig()
+	if err != nil {
+		ui.LogFatal(err.Error())
+	}
+
+	p, err := preset.Resolve(name, config.Presets)
+	if err != nil {
+		ui.LogFatal(err.Error())
+	}
+
+	if err := p.Apply(cmd.Flags()); err != nil {
+		ui.LogFatal(fmt.Errorf("Failed to apply preset %s\n%s", name, err).Error())
+	}
+}
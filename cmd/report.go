@@ -4,14 +4,17 @@ Copyright © 2024 AntoninoAdornetto
 package cmd
 
 import (
-	"bytes"
 	"fmt"
 	"os"
-	"os/exec"
+	"strings"
 
+	"github.com/AntoninoAdornetto/issue-summoner/pkg/filter"
+	"github.com/AntoninoAdornetto/issue-summoner/pkg/hook"
+	"github.com/AntoninoAdornetto/issue-summoner/pkg/ignore"
 	"github.com/AntoninoAdornetto/issue-summoner/pkg/issue"
 	"github.com/AntoninoAdornetto/issue-summoner/pkg/scm"
 	"github.com/AntoninoAdornetto/issue-summoner/pkg/ui"
+	"github.com/AntoninoAdornetto/issue-summoner/pkg/workspace"
 	"github.com/AntoninoAdornetto/issue-summoner/templates"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/spf13/cobra"
@@ -27,111 +30,105 @@ Once issue annotations are discovered, you will be presented with a list of all
 that were located and you can select which ones you would like to report to a source code management
 platform.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		annotation, path := handleCommonFlags(cmd)
-
 		sourceCodeManager, err := cmd.Flags().GetString(flag_scm)
 		if err != nil {
 			ui.LogFatal(err.Error())
 		}
 
-		_, err = scm.ReadAccessToken(sourceCodeManager)
-		if err != nil {
-			if os.IsNotExist(err) {
-				ui.LogFatal(
-					"configuration file does not exist. please run <issue-summoner authorize> or see <issue-summoner authorize --help>",
-				)
-			} else {
-				ui.LogFatal(err.Error())
-			}
-		}
-
-		issueManager, err := issue.NewIssueManager(issue.PENDING_ISSUE, annotation)
+		noHooks, err := cmd.Flags().GetBool(flag_no_hooks)
 		if err != nil {
 			ui.LogFatal(err.Error())
 		}
 
-		_, err = issueManager.Walk(path)
+		config, err := readConfig()
 		if err != nil {
 			ui.LogFatal(err.Error())
 		}
 
-		issues := issueManager.GetIssues()
-		if len(issues) == 0 {
-			fmt.Println(ui.ErrorTextStyle.Render(no_issues))
-			return
+		hooks := config.Hooks
+		if noHooks {
+			hooks = scm.HooksConfig{}
 		}
 
-		selections := ui.Selection{
-			Options: make(map[string]bool),
+		labels, err := cmd.Flags().GetStringSlice(flag_label)
+		if err != nil {
+			ui.LogFatal(err.Error())
 		}
 
-		options := make([]ui.Item, len(issues))
-		for i, is := range issues {
-			options[i] = ui.Item{
-				Title: is.Title,
-				Desc:  is.Description,
-				ID:    is.ID,
-			}
+		assignees, err := cmd.Flags().GetStringSlice(flag_assignee)
+		if err != nil {
+			ui.LogFatal(err.Error())
 		}
 
-		var quit bool
-		teaProgram := tea.NewProgram(
-			ui.InitialModelMultiSelect(
-				options,
-				&selections,
-				select_issues,
-				&quit,
-			),
-		)
-
-		if _, err := teaProgram.Run(); err != nil {
+		mappings, err := cmd.Flags().GetStringSlice(flag_annotation_label)
+		if err != nil {
 			ui.LogFatal(err.Error())
 		}
 
-		tmpl, err := templates.LoadIssueTemplate()
+		annotationLabels, err := scm.ParseAnnotationLabels(mappings)
 		if err != nil {
 			ui.LogFatal(err.Error())
 		}
 
-		reportQueue := make([]scm.GitIssue, 0)
-		for i, is := range issues {
-			if selections.Options[is.ID] {
-				md, err := is.ExecuteIssueTemplate(tmpl)
-				if err != nil {
-					ui.LogFatal(err.Error())
-				}
-				reportQueue = append(
-					reportQueue,
-					scm.GitIssue{Title: is.Title, Body: string(md), QueueIndex: i},
-				)
-			}
+		rateLimit, err := cmd.Flags().GetFloat64(flag_rate_limit)
+		if err != nil {
+			ui.LogFatal(err.Error())
 		}
 
-		out := bytes.Buffer{}
-		remoteCmd := exec.Command("git", "remote", "-v")
-		remoteCmd.Stdout = &out
-		if err := remoteCmd.Run(); err != nil {
-			ui.LogFatal(err.Error())
+		opts := reportOptions{
+			scm:              sourceCodeManager,
+			config:           config,
+			hooks:            hooks,
+			labels:           labels,
+			annotationLabels: config.AnnotationLabels.Merge(annotationLabels),
+			assignees:        assignees,
+			rateLimit:        rateLimit,
+		}
+		if dir, depth := workspaceFlags(cmd); dir != "" {
+			reportWorkspace(cmd, dir, depth, opts)
+			return
 		}
 
-		userName, repoName, err := scm.ExtractUserRepoName(out.Bytes())
+		annotation, path := handleCommonFlags(cmd)
+		opts.annotation = annotation
+		issueManager, err := issue.NewIssueManager(issue.PENDING_ISSUE, annotation)
 		if err != nil {
 			ui.LogFatal(err.Error())
 		}
 
-		gitManager, err := scm.NewGitManager(sourceCodeManager, userName, repoName)
-		if err != nil {
+		cache := scanCache(cmd, path, annotation)
+		include := includeFilter(cmd)
+		stats := &issue.WalkStats{}
+		scanHidden, allowHidden := hiddenFlags(cmd)
+		_, err = issueManager.Walk(issue.WalkParams{
+			Root:             path,
+			ExcludesFile:     excludesFile(cmd, path),
+			Cache:            cache,
+			IgnoreCase:       ignoreCase(cmd),
+			NoDefaultIgnores: noDefaultIgnores(cmd),
+			Include:          include,
+			IncludeBinary:    includeBinary(cmd),
+			MaxFileSize:      maxFileSize(cmd),
+			MaxDepth:         maxDepth(cmd),
+			FollowSymlinks:   followSymlinks(cmd),
+			Files:            walkFiles(cmd, path),
+			ScanHidden:       scanHidden,
+			AllowHidden:      allowHidden,
+			Stats:            stats,
+		})
+		if err := walkError(path, err); err != nil {
 			ui.LogFatal(err.Error())
 		}
+		saveScanCache(cache)
+		printFiltered(include)
+		printOversized(path, stats)
 
-		reported := gitManager.Report(reportQueue)
-		for ch := range reported {
-			if err := issueManager.WriteIssueID(ch.ID, ch.QueueIndex); err != nil {
-				ui.LogFatal(err.Error())
-			}
+		n, err := reportIssues(cmd, path, issueManager, opts)
+		if err != nil {
+			ui.LogFatal(err.Error())
 		}
 
-		if len(reportQueue) == 0 {
+		if n == 0 {
 			return
 		}
 
@@ -139,7 +136,7 @@ platform.`,
 			ui.SuccessTextStyle.Render(
 				fmt.Sprintf(
 					"Success! Uploaded %d issue(s) to %s",
-					len(reportQueue),
+					n,
 					sourceCodeManager,
 				),
 			),
@@ -151,9 +148,526 @@ platform.`,
 	},
 }
 
+// reportOptions are the settings that are shared by every repository that is reported.
+// labels and assignees are applied to every issue that is created, annotationLabels adds
+// the labels of the annotation of each issue. rateLimit is the max number of issues that
+// are created per second, pacing is disabled when it's 0 or less.
+type reportOptions struct {
+	annotation       string
+	scm              string
+	config           scm.Config
+	hooks            scm.HooksConfig
+	labels           []string
+	annotationLabels scm.AnnotationLabels
+	assignees        []string
+	rateLimit        float64
+}
+
+// reportIssues lets the user select which of the issues that were found in the repository
+// located at path should be reported and reports them. The number of issues that were
+// created is returned, the issues that failed are printed and not counted.
+func reportIssues(
+	cmd *cobra.Command,
+	path string,
+	issueManager issue.IssueManager,
+	opts reportOptions,
+) (int, error) {
+	// the issues are not filtered in place, since the queue index of a reported issue
+	// is the index of the issue in the issue manager
+	issues := issueManager.GetIssues()
+	matches := issueFilter(cmd, path)
+	matched := filter.Apply(matches, issues)
+	if len(matched) == 0 {
+		fmt.Println(ui.ErrorTextStyle.Render(no_issues))
+		return 0, nil
+	}
+
+	// duplicates are only a warning, the user decides which ones to report
+	for _, group := range issue.FindDuplicates(matched) {
+		fmt.Println(ui.NoteTextStyle.Render(
+			fmt.Sprintf("Warning: found %d annotations titled %q", len(group), group[0].Title),
+		))
+		for _, is := range group {
+			fmt.Println(ui.DimTextStyle.Render(fmt.Sprintf("  %s:%d", is.FilePath, is.LineNumber)))
+		}
+	}
+
+	gitConfig, overridden, err := resolveGitConfig(cmd, opts.config, path)
+	if err != nil {
+		return 0, err
+	}
+
+	gitConfig.RateLimit.RequestsPerSecond = opts.rateLimit
+	if opts.rateLimit <= 0 {
+		gitConfig.RateLimit.RequestsPerSecond = -1
+	}
+
+	gitManager, err := scm.NewGitManager(gitConfig)
+	if err != nil {
+		return 0, err
+	}
+
+	repository, err := gitManager.VerifyRepository()
+	if err != nil {
+		return 0, err
+	}
+
+	requested := scm.RemoteRepository{Owner: gitConfig.UserName, Name: gitConfig.RepositoryName}
+	if !strings.EqualFold(requested.String(), repository.String()) {
+		fmt.Println(ui.NoteTextStyle.Render(
+			fmt.Sprintf(
+				"Warning: %s has been renamed or transferred to %s. please update your remote url via <git remote set-url>",
+				requested,
+				repository,
+			),
+		))
+	}
+
+	gitConfig.UserName, gitConfig.RepositoryName = repository.Owner, repository.Name
+	destination := fmt.Sprintf("Issues will be reported to %s", repository)
+	if overridden {
+		destination += " (overridden by --owner/--repo or the config file)"
+	}
+	fmt.Println(ui.NoteTextStyle.Render(destination))
+
+	selections := ui.Selection{
+		Options: make(map[string]bool),
+	}
+
+	var quit bool
+	teaProgram := tea.NewProgram(
+		ui.InitialModelMultiSelect(
+			issue.SelectItems(issues, path, matches),
+			&selections,
+			select_issues,
+			&quit,
+			select_height,
+		),
+	)
+
+	if _, err := teaProgram.Run(); err != nil {
+		return 0, err
+	}
+
+	selected := issue.Selected(issues, &selections)
+	if quit || len(selected) == 0 {
+		return 0, nil
+	}
+
+	tmpl, err := templates.LoadIssueTemplate()
+	if err != nil {
+		return 0, err
+	}
+
+	runLog, err := openRunLog(cmd, repository)
+	if err != nil {
+		return 0, err
+	}
+
+	// the permalink is left out of the issue body when the branch can't be determined
+	branch, _ := scm.DefaultBranch(scm.Git, path)
+	openIssues, err := loadOpenIssues(cmd, gitManager)
+	if err != nil {
+		return 0, err
+	}
+
+	reportQueue := make([]scm.GitIssue, 0)
+	fingerprints := make(map[int]string)
+	for _, i := range selected {
+		is := issues[i]
+		fingerprint := issue.Fingerprint(is, path)
+		if logged, ok := runLog.Find(fingerprint); ok {
+			if err := resumeIssue(issueManager, gitConfig, runLog, fingerprint, logged, i); err != nil {
+				return 0, err
+			}
+			fmt.Println(ui.NoteTextStyle.Render(
+				fmt.Sprintf("Skipped %q, it was reported as #%d by a previous run. the annotation has been updated", is.Title, loggedNumber(logged)),
+			))
+			continue
+		}
+
+		if existing, ok := openIssues.Find(fingerprint, is.Title); ok {
+			fmt.Println(ui.NoteTextStyle.Render(
+				fmt.Sprintf("Skipped %q, it already exists as #%d %q. use --force to report it anyway", is.Title, existing.Number, existing.Title),
+			))
+			continue
+		}
+
+		md, err := is.ExecuteIssueTemplate(tmpl, issueSource(is, path, gitConfig, branch))
+		if err != nil {
+			return 0, err
+		}
+
+		fingerprints[i] = fingerprint
+		marker := issue.Marker{Fingerprint: fingerprint, Version: Version}
+		reportQueue = append(
+			reportQueue,
+			scm.GitIssue{
+				Title:      is.Title,
+				Body:       issue.AppendMarker(string(md), marker),
+				Labels:     opts.annotationLabels.Labels(is.Annotation, opts.labels),
+				Assignees:  opts.assignees,
+				QueueIndex: i,
+			},
+		)
+	}
+
+	// hooks are executed sequentially and only after the issue has been created
+	hookRunner := hook.Runner{Command: opts.hooks.IssueCreated, Stdout: os.Stdout}
+	reported, created := gitManager.Report(reportQueue), 0
+	for ch := range reported {
+		if ch.Err != nil {
+			fmt.Println(ui.ErrorTextStyle.Render(ch.Err.Error()))
+			continue
+		}
+		created++
+
+		// the issue is logged until its reference is written, so that a run that is
+		// interrupted in between doesn't report it again when it's resumed
+		logged := scm.LoggedIssue{ID: ch.ID, Number: ch.Number, URL: ch.URL}
+		if err := runLog.Record(fingerprints[ch.QueueIndex], logged); err != nil {
+			return 0, err
+		}
+
+		if err := resumeIssue(issueManager, gitConfig, runLog, fingerprints[ch.QueueIndex], logged, ch.QueueIndex); err != nil {
+			return 0, err
+		}
+
+		is := issues[ch.QueueIndex]
+		err := hookRunner.RunIssueCreated(cmd.Context(), hook.IssueCreatedEvent{
+			ID:         ch.ID,
+			Number:     ch.Number,
+			URL:        ch.URL,
+			Title:      is.Title,
+			FilePath:   is.FilePath,
+			LineNumber: is.LineNumber,
+		})
+		if err != nil {
+			fmt.Println(ui.ErrorTextStyle.Render(err.Error()))
+		}
+	}
+
+	return created, nil
+}
+
+// reportWorkspace reports the issues of every repository beneath dir. Each repository is
+// reported to its own remote, a repository that fails is reported and skipped so that
+// the remaining repositories are still processed.
+func reportWorkspace(cmd *cobra.Command, dir string, depth int, opts reportOptions) {
+	owner, err := cmd.Flags().GetString(flag_owner)
+	if err != nil {
+		ui.LogFatal(err.Error())
+	}
+
+	repo, err := cmd.Flags().GetString(flag_repo)
+	if err != nil {
+		ui.LogFatal(err.Error())
+	}
+
+	if owner != "" || repo != "" {
+		ui.LogFatal("--owner and --repo can't be used with --workspace, since every repository is reported to its own remote")
+	}
+
+	opts.annotation, err = cmd.Flags().GetString(flag_annotation)
+	if err != nil {
+		ui.LogFatal(err.Error())
+	}
+
+	// the owner and repo of the config file would send the issues of every repository to
+	// the same place
+	opts.config.Owner, opts.config.Repo = "", ""
+
+	caches := make(map[string]*issue.ScanCache)
+	stats := make(map[string]*issue.WalkStats)
+	include := includeFilter(cmd)
+	results, err := workspace.Scan(dir, workspace.Options{
+		Mode:       issue.PENDING_ISSUE,
+		Annotation: opts.annotation,
+		MaxDepth:   depth,
+		Params: func(root string) issue.WalkParams {
+			cache := scanCache(cmd, root, opts.annotation)
+			scanHidden, allowHidden := hiddenFlags(cmd)
+			caches[root] = cache
+			stats[root] = &issue.WalkStats{}
+			return issue.WalkParams{
+				Root:             root,
+				ExcludesFile:     excludesFile(cmd, root),
+				Cache:            cache,
+				IgnoreCase:       ignoreCase(cmd),
+				NoDefaultIgnores: noDefaultIgnores(cmd),
+				Include:          include,
+				IncludeBinary:    includeBinary(cmd),
+				MaxFileSize:      maxFileSize(cmd),
+				MaxDepth:         maxDepth(cmd),
+				FollowSymlinks:   followSymlinks(cmd),
+				Files:            walkFiles(cmd, root),
+				ScanHidden:       scanHidden,
+				AllowHidden:      allowHidden,
+				Stats:            stats[root],
+			}
+		},
+	})
+	if err != nil {
+		ui.LogFatal(err.Error())
+	}
+
+	if len(results) == 0 {
+		ui.LogFatal(fmt.Sprintf("no git repositories were found in %s", dir))
+	}
+
+	total, failed := 0, 0
+	for _, result := range results {
+		name := relativeTo(dir, result.Repository.WorkTree)
+		fmt.Println(ui.PrimaryTextStyle.Render(fmt.Sprintf("Repository %s", name)))
+		if result.Err != nil {
+			fmt.Println(ui.ErrorTextStyle.Render(fmt.Sprintf("%s: %s", name, result.Err)))
+			failed++
+			continue
+		}
+		printFileErrors(result.Repository.WorkTree, result.FileErrors)
+		saveScanCache(caches[result.Repository.WorkTree])
+		printOversized(result.Repository.WorkTree, stats[result.Repository.WorkTree])
+
+		n, err := reportIssues(cmd, result.Repository.WorkTree, result.Manager, opts)
+		if err != nil {
+			fmt.Println(ui.ErrorTextStyle.Render(fmt.Sprintf("%s: %s", name, err)))
+			failed++
+			continue
+		}
+
+		total += n
+		if n > 0 {
+			fmt.Println(ui.SuccessTextStyle.Render(fmt.Sprintf("Uploaded %d issue(s) from %s", n, name)))
+		}
+	}
+
+	summary := fmt.Sprintf(
+		"Uploaded %d issue(s) to %s across %d repositories",
+		total,
+		opts.scm,
+		len(results),
+	)
+	if failed > 0 {
+		summary += fmt.Sprintf(" (%d failed)", failed)
+	}
+	fmt.Println(ui.SuccessTextStyle.Render(summary))
+	printFiltered(include)
+
+	if total > 0 {
+		fmt.Println(
+			ui.SecondaryTextStyle.Render("make sure to commit and push the annotation updates!"),
+		)
+	}
+}
+
+// openRunLog opens the run log of the repository that issues are reported to. The issues
+// that an interrupted run left in the log are discarded when --fresh is set.
+func openRunLog(cmd *cobra.Command, repository scm.RemoteRepository) (*scm.RunLog, error) {
+	fresh, err := cmd.Flags().GetBool(flag_fresh)
+	if err != nil {
+		return nil, err
+	}
+
+	runLog, err := scm.OpenRunLog(repository)
+	if err != nil {
+		return nil, err
+	}
+
+	if fresh {
+		if err := runLog.Reset(); err != nil {
+			return nil, err
+		}
+	}
+	return runLog, nil
+}
+
+// resumeIssue writes the reference of an issue that was logged for the annotation at
+// index to the annotation and removes the issue from the run log
+func resumeIssue(
+	issueManager issue.IssueManager,
+	gitConfig scm.GitConfig,
+	runLog *scm.RunLog,
+	fingerprint string,
+	logged scm.LoggedIssue,
+	index int,
+) error {
+	ref := scm.ReferenceFormat(gitConfig.Scm, loggedNumber(logged), gitConfig.UserName, gitConfig.RepositoryName)
+	if err := issueManager.WriteIssueRef(ref, index); err != nil {
+		return err
+	}
+	return runLog.Forget(fingerprint)
+}
+
+// loggedNumber is the number that a logged issue is referred to by, see issueNumber
+func loggedNumber(logged scm.LoggedIssue) int64 {
+	return issueNumber(scm.Reporter{ID: logged.ID, Number: logged.Number})
+}
+
+// issueNumber is the number that the reported issue is referred to by. Platforms that
+// don't return the number are referred to by the id of the issue.
+func issueNumber(reported scm.Reporter) int64 {
+	if reported.Number > 0 {
+		return int64(reported.Number)
+	}
+	return reported.ID
+}
+
+// issueSource reads the code surrounding the annotation, links to it on the default
+// branch of the repository and blames the annotation for its author. The snippet is left
+// out when the file can't be read and the author when the line isn't committed.
+func issueSource(is issue.Issue, root string, gitConfig scm.GitConfig, branch string) issue.Source {
+	source := issue.Source{}
+	if src, err := os.ReadFile(is.FilePath); err == nil {
+		source = issue.NewSource(
+			src,
+			is.FilePath,
+			is.LineNumber,
+			is.EndLineNumber,
+			issue.SNIPPET_CONTEXT_LINES,
+		)
+	}
+
+	if author, err := scm.Blame(scm.Git, root, is.FilePath, is.LineNumber); err == nil {
+		source.Author = author.Name
+	}
+
+	webURL := gitConfig.WebURL()
+	if webURL == "" || branch == "" {
+		return source
+	}
+
+	end := max(is.EndLineNumber, is.LineNumber)
+	source.Permalink = issue.Permalink(
+		webURL,
+		gitConfig.UserName,
+		gitConfig.RepositoryName,
+		branch,
+		relativeTo(root, is.FilePath),
+		is.LineNumber,
+		end,
+	)
+
+	return source
+}
+
+// loadOpenIssues lists the open issues of the repository, so that annotations are not
+// reported twice. Issues are matched by the fingerprint in their marker or, for issues
+// without a marker, by the similarity of their title. No issues are listed when --force is set.
+func loadOpenIssues(cmd *cobra.Command, gitManager scm.GitConfigManager) (scm.OpenIssues, error) {
+	force, err := cmd.Flags().GetBool(flag_force)
+	if err != nil || force {
+		return scm.OpenIssues{}, err
+	}
+
+	similarity, err := cmd.Flags().GetFloat64(flag_similarity)
+	if err != nil {
+		return scm.OpenIssues{}, err
+	}
+
+	return scm.LoadOpenIssues(gitManager, similarity)
+}
+
+// resolveGitConfig returns the git config of the repository that issues are reported to.
+// The --owner and --repo flags take precedence over the config file, which takes
+// precedence over the remote url of the local repository. The platform is detected
+// from the remote url unless the --scm flag is set explicitly.
+func resolveGitConfig(
+	cmd *cobra.Command,
+	config scm.Config,
+	path string,
+) (gitConfig scm.GitConfig, overridden bool, err error) {
+	owner, err := cmd.Flags().GetString(flag_owner)
+	if err != nil {
+		return gitConfig, false, err
+	}
+
+	repo, err := cmd.Flags().GetString(flag_repo)
+	if err != nil {
+		return gitConfig, false, err
+	}
+
+	sourceCodeManager, err := cmd.Flags().GetString(flag_scm)
+	if err != nil {
+		return gitConfig, false, err
+	}
+
+	userName, repoName := config.Owner, config.Repo
+	if owner != "" {
+		userName = owner
+	}
+
+	if repo != "" {
+		repoName = repo
+	}
+
+	overridden = userName != "" || repoName != ""
+	if userName == "" || repoName == "" {
+		gitConfig, err = loadGitConfig(path)
+		if err != nil {
+			return gitConfig, false, err
+		}
+	} else {
+		gitConfig.Scm = sourceCodeManager
+		gitConfig.BaseURL = config.PlatformURL(sourceCodeManager)
+	}
+
+	if userName != "" {
+		gitConfig.UserName = userName
+	}
+
+	if repoName != "" {
+		gitConfig.RepositoryName = repoName
+	}
+
+	// the token that was loaded belongs to the repository of the remote url
+	if gitConfig.Token == "" || overridden || cmd.Flags().Changed(flag_scm) {
+		if cmd.Flags().Changed(flag_scm) {
+			gitConfig.Scm = sourceCodeManager
+			gitConfig.BaseURL = config.PlatformURL(sourceCodeManager)
+		}
+
+		repo := scm.RemoteRepository{Owner: gitConfig.UserName, Name: gitConfig.RepositoryName}
+		gitConfig.Token, err = readAccessToken(gitConfig.Scm, repo)
+		if err != nil {
+			return gitConfig, false, fmt.Errorf(
+				"failed to read the access token for %s, please run <issue-summoner authorize>: %w",
+				gitConfig.Scm,
+				err,
+			)
+		}
+	}
+
+	return gitConfig, overridden, nil
+}
+
 func init() {
 	rootCmd.AddCommand(reportCmd)
 	reportCmd.Flags().StringP(flag_path, shortflag_path, "", flag_desc_path)
 	reportCmd.Flags().StringP(flag_annotation, shortflag_annotation, "@TODO", flag_desc_annotation)
 	reportCmd.Flags().StringP(flag_scm, shortflag_scm, scm.GITHUB, flag_desc_scm)
+	reportCmd.Flags().Bool(flag_no_hooks, false, flag_desc_no_hooks)
+	reportCmd.Flags().String(flag_owner, "", flag_desc_owner)
+	reportCmd.Flags().String(flag_repo, "", flag_desc_repo)
+	reportCmd.Flags().String(flag_filter, "", flag_desc_filter)
+	reportCmd.Flags().Bool(flag_force, false, flag_desc_force)
+	reportCmd.Flags().Float64(flag_similarity, issue.DEFAULT_SIMILARITY, flag_desc_similarity)
+	reportCmd.Flags().Bool(flag_no_cache, false, flag_desc_no_cache)
+	reportCmd.Flags().Bool(flag_no_excludes, false, flag_desc_no_excludes)
+	reportCmd.Flags().Bool(flag_ignore_case, ignore.DEFAULT_IGNORE_CASE, flag_desc_ignore_case)
+	reportCmd.Flags().Bool(flag_no_defaults, false, flag_desc_no_defaults)
+	reportCmd.Flags().StringSlice(flag_include, nil, flag_desc_include)
+	reportCmd.Flags().Bool(flag_include_binary, false, flag_desc_include_binary)
+	reportCmd.Flags().Int64(flag_max_file_size, issue.DEFAULT_MAX_FILE_SIZE, flag_desc_max_file_size)
+	reportCmd.Flags().Int(flag_max_depth, -1, flag_desc_max_depth)
+	reportCmd.Flags().Bool(flag_follow_symlinks, false, flag_desc_follow_symlinks)
+	reportCmd.Flags().Bool(flag_tracked_only, false, flag_desc_tracked_only)
+	reportCmd.Flags().Bool(flag_scan_hidden, false, flag_desc_scan_hidden)
+	reportCmd.Flags().StringSlice(flag_allow_hidden, issue.DefaultAllowHidden, flag_desc_allow_hidden)
+	reportCmd.Flags().Float64(flag_rate_limit, scm.DEFAULT_REQUESTS_PER_SECOND, flag_desc_rate_limit)
+	reportCmd.Flags().String(flag_workspace, "", flag_desc_workspace)
+	reportCmd.Flags().Int(flag_workspace_depth, workspace.DEFAULT_MAX_DEPTH, flag_desc_workspace_depth)
+	reportCmd.Flags().StringSlice(flag_label, nil, flag_desc_label)
+	reportCmd.Flags().StringSlice(flag_annotation_label, nil, flag_desc_annotation_label)
+	reportCmd.Flags().StringSlice(flag_assignee, nil, flag_desc_assignee)
+	reportCmd.Flags().Bool(flag_fresh, false, flag_desc_fresh)
 }
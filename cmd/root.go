@@ -7,6 +7,8 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/AntoninoAdornetto/issue-summoner/pkg/lexer"
+	"github.com/AntoninoAdornetto/issue-summoner/pkg/scm"
 	"github.com/AntoninoAdornetto/issue-summoner/pkg/ui"
 	"github.com/spf13/cobra"
 )
@@ -20,6 +22,9 @@ const Logo = `
          \/     \/            \/          \/            \/      \/            \/     \/       
 `
 
+// Version of issue-summoner, it's embedded in the marker of every reported issue
+var Version = "dev"
+
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:   "issue-summoner",
@@ -33,11 +38,40 @@ to quickly create a Cobra application.`,
 	// Uncomment the following line if your bare application
 	// has an action associated with it:
 	// Run: func(cmd *cobra.Command, args []string) { },
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		printLogo(cmd)
+		return loadLanguages()
+	},
+}
+
+// printLogo prints the logo, unless the output of the command is json which should be
+// parseable
+func printLogo(cmd *cobra.Command) {
+	if format := cmd.Flags().Lookup(flag_format); format != nil && format.Value.String() == format_json {
+		return
+	}
+	fmt.Println(ui.AccentTextStyle.Render(Logo))
+}
+
+// loadLanguages registers the comment syntax of the languages that are declared in the
+// languages file of the config dir. The file is optional.
+func loadLanguages() error {
+	path, err := scm.LanguagesPath()
+	if err != nil {
+		return err
+	}
+
+	if err := lexer.LoadLanguagesFile(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
+	rootCmd.Version = Version
 	err := rootCmd.Execute()
 	if err != nil {
 		os.Exit(1)
@@ -54,5 +88,4 @@ func init() {
 	// Cobra also supports local flags, which will only run
 	// when this action is called directly.
 	rootCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
-	fmt.Println(ui.AccentTextStyle.Render(Logo))
 }
@@ -4,10 +4,22 @@ Copyright © 2024 AntoninoAdornetto
 package cmd
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
 
+	"github.com/AntoninoAdornetto/issue-summoner/pkg/filter"
+	"github.com/AntoninoAdornetto/issue-summoner/pkg/ignore"
 	"github.com/AntoninoAdornetto/issue-summoner/pkg/issue"
+	"github.com/AntoninoAdornetto/issue-summoner/pkg/scm"
 	"github.com/AntoninoAdornetto/issue-summoner/pkg/ui"
+	"github.com/AntoninoAdornetto/issue-summoner/pkg/workspace"
+	"github.com/charmbracelet/lipgloss"
 	"github.com/spf13/cobra"
 )
 
@@ -23,7 +35,7 @@ command. Report will actually publish the located comments to your favorite
 source code management platform. Scan is for reviewing the issue annotations
 that reside in your code base.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		annotation, path := handleCommonFlags(cmd)
+		applyPreset(cmd)
 
 		verbose, err := cmd.Flags().GetBool(flag_verbose)
 		if err != nil {
@@ -35,17 +47,107 @@ that reside in your code base.`,
 			ui.LogFatal(err.Error())
 		}
 
+		status, err := cmd.Flags().GetBool(flag_status)
+		if err != nil {
+			ui.LogFatal(err.Error())
+		}
+
+		stdin, err := cmd.Flags().GetBool(flag_stdin)
+		if err != nil {
+			ui.LogFatal(err.Error())
+		}
+
+		if stdin {
+			scanStdin(cmd, mode)
+			return
+		}
+
+		if dir, depth := workspaceFlags(cmd); dir != "" {
+			scanWorkspace(cmd, dir, depth, mode, verbose, status)
+			return
+		}
+
+		annotation, path := handleCommonFlags(cmd)
 		issueManager, err := issue.NewIssueManager(mode, annotation)
 		if err != nil {
 			ui.LogFatal(err.Error())
 		}
 
-		_, err = issueManager.Walk(path)
+		issueIgnorePath, err := cmd.Flags().GetString(flag_issueignore_path)
 		if err != nil {
 			ui.LogFatal(err.Error())
 		}
 
-		issues := issueManager.GetIssues()
+		include := includeFilter(cmd)
+		scanHidden, allowHidden := hiddenFlags(cmd)
+		params := issue.WalkParams{
+			Root:             path,
+			IssueIgnorePath:  issueIgnorePath,
+			ExcludesFile:     excludesFile(cmd, path),
+			IgnoreCase:       ignoreCase(cmd),
+			NoDefaultIgnores: noDefaultIgnores(cmd),
+			Include:          include,
+			IncludeBinary:    includeBinary(cmd),
+			MaxFileSize:      maxFileSize(cmd),
+			MaxDepth:         maxDepth(cmd),
+			FollowSymlinks:   followSymlinks(cmd),
+			Files:            walkFiles(cmd, path),
+			ScanHidden:       scanHidden,
+			AllowHidden:      allowHidden,
+		}
+
+		showIgnores, err := cmd.Flags().GetBool(flag_show_ignores)
+		if err != nil {
+			ui.LogFatal(err.Error())
+		}
+
+		if showIgnores {
+			printIgnores(params)
+			return
+		}
+
+		explain, err := cmd.Flags().GetString(flag_explain_ignore)
+		if err != nil {
+			ui.LogFatal(err.Error())
+		}
+
+		if explain != "" {
+			explainIgnore(params, explain)
+			return
+		}
+
+		summary, format := summaryFlags(cmd)
+		stats := &issue.WalkStats{}
+		cache := scanCache(cmd, path, annotation)
+		params.Cache, params.Stats = cache, stats
+
+		// ctrl+c stops the walk, the issues that were located so far are still shown
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+		onProgress, clearProgress := progressCounter()
+		params.Ctx, params.OnProgress = ctx, onProgress
+		_, err = issueManager.Walk(params)
+		stop()
+		clearProgress()
+		err = walkError(path, err)
+		if errors.Is(err, context.Canceled) {
+			msg := fmt.Sprintf("scan interrupted, showing partial results (%d files scanned)", stats.Scanned)
+			fmt.Fprintln(os.Stderr, ui.NoteTextStyle.Render(msg))
+		} else if err != nil {
+			ui.LogFatal(err.Error())
+		}
+		saveScanCache(cache)
+
+		issues := filter.Apply(issueFilter(cmd, path), issueManager.GetIssues())
+		if summary {
+			printSummary(issue.Summarize(issues, *stats), format)
+			return
+		}
+
+		printFiltered(include)
+		if verbose {
+			printSkipped(path, stats)
+		}
+
 		if len(issues) > 0 {
 			success := fmt.Sprintf("Found %d issue annotations using %s", len(issues), annotation)
 			fmt.Println(ui.SuccessTextStyle.Render(success))
@@ -54,18 +156,436 @@ that reside in your code base.`,
 			return
 		}
 
+		if status {
+			config, err := readConfig()
+			if err != nil {
+				ui.LogFatal(err.Error())
+			}
+
+			gitConfig, _, err := resolveGitConfig(cmd, config, path)
+			if err != nil {
+				ui.LogFatal(err.Error())
+			}
+
+			statuses, err := issueStatuses(gitConfig, path, issues)
+			if err != nil {
+				ui.LogFatal(err.Error())
+			}
+			printStatuses(path, issues, statuses)
+		}
+
 		if verbose {
 			issue.PrintIssueDetails(issues, ui.DimTextStyle, ui.PrimaryTextStyle)
-		} else {
+		} else if !status {
 			fmt.Println(ui.SecondaryTextStyle.Render(tip_verbose))
 		}
 	},
 }
 
+// printIgnores prints the ignore files and patterns that the walk of params starts with,
+// from the lowest to the highest precedence. The .gitignore files of subdirectories are not
+// included since they are added as the walk enters them.
+func printIgnores(params issue.WalkParams) {
+	ignorer, err := issue.NewWalkIgnorer(params)
+	if err != nil {
+		ui.LogFatal(err.Error())
+	}
+
+	for _, group := range ignorer.Precedence() {
+		fmt.Println(ui.PrimaryTextStyle.Render(fmt.Sprintf("%s (%s)", group.Src, group.BasePath)))
+		for _, pattern := range group.Patterns {
+			fmt.Println(ui.DimTextStyle.Render("  " + pattern.Pattern))
+		}
+	}
+
+	fmt.Println(ui.SecondaryTextStyle.Render(tip_show_ignores))
+}
+
+// explainIgnore prints the pattern, and the ignore file that it belongs to, that decides
+// whether target is ignored by the walk of params. target is relative to the root of the
+// walk, the .gitignore files of the directories above it are applied the same way the walk
+// applies them as it enters each directory.
+func explainIgnore(params issue.WalkParams, target string) {
+	ignorer, err := issue.NewWalkIgnorer(params)
+	if err != nil {
+		ui.LogFatal(err.Error())
+	}
+
+	path := filepath.Join(params.Root, target)
+	rel := relativeTo(params.Root, path)
+	if rel == "." || strings.HasPrefix(rel, "..") {
+		ui.LogFatal(fmt.Sprintf("%s is not beneath %s", target, params.Root))
+	}
+
+	dir := filepath.Clean(params.Root)
+	for _, name := range strings.Split(filepath.Dir(rel), string(filepath.Separator)) {
+		if name == "." {
+			break
+		}
+
+		dir = filepath.Join(dir, name)
+		if err := ignorer.EnterDir(dir); err != nil {
+			ui.LogFatal(err.Error())
+		}
+	}
+
+	isDir := strings.HasSuffix(target, "/")
+	if info, err := os.Stat(path); err == nil {
+		isDir = info.IsDir()
+	}
+
+	entry, err := ignorer.Explain(path, isDir)
+	if err != nil {
+		ui.LogFatal(err.Error())
+	}
+
+	if entry == nil {
+		fmt.Println(ui.PrimaryTextStyle.Render(fmt.Sprintf("%s is not ignored, no pattern matches it", rel)))
+		return
+	}
+
+	src := entry.Src
+	if entry.Source != ignore.SOURCE_DEFAULTS {
+		src = relativeTo(params.Root, filepath.Join(entry.BasePath, entry.Src))
+	}
+
+	decision := "ignored by"
+	if entry.Pattern.Negate {
+		decision = "not ignored, it's re-included by"
+	}
+
+	msg := fmt.Sprintf("%s is %s %s:%d:%s", rel, decision, src, entry.Pattern.Line, entry.Pattern.Pattern)
+	fmt.Println(ui.PrimaryTextStyle.Render(msg))
+}
+
+// summarizeWorkspace prints a single summary of the issues of every repository that was
+// scanned. Repositories that could not be scanned are reported on stderr so that the
+// summary can still be parsed when it's printed as json.
+func summarizeWorkspace(
+	cmd *cobra.Command,
+	dir string,
+	results []workspace.Result,
+	caches map[string]*issue.ScanCache,
+	stats issue.WalkStats,
+	format string,
+) {
+	issues := make([]issue.Issue, 0)
+	for _, result := range results {
+		if result.Err != nil {
+			name := relativeTo(dir, result.Repository.WorkTree)
+			fmt.Fprintln(os.Stderr, ui.ErrorTextStyle.Render(fmt.Sprintf("%s: %s", name, result.Err)))
+			continue
+		}
+		printFileErrors(result.Repository.WorkTree, result.FileErrors)
+
+		saveScanCache(caches[result.Repository.WorkTree])
+		expr := issueFilter(cmd, result.Repository.WorkTree)
+		issues = append(issues, filter.Apply(expr, result.Issues)...)
+	}
+
+	printSummary(issue.Summarize(issues, stats), format)
+}
+
+// scanStdin scans the source code that is read from stdin and prints every issue that was
+// found, as text or as json. The extension flag selects the lexer.
+func scanStdin(cmd *cobra.Command, mode string) {
+	annotation, err := cmd.Flags().GetString(flag_annotation)
+	if err != nil {
+		ui.LogFatal(err.Error())
+	}
+
+	ext, err := cmd.Flags().GetString(flag_ext)
+	if err != nil {
+		ui.LogFatal(err.Error())
+	}
+
+	if ext == "" {
+		ui.LogFatal(fmt.Sprintf("--%s requires --%s, such as --%s .go", flag_stdin, flag_ext, flag_ext))
+	}
+
+	if !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+
+	_, format := summaryFlags(cmd)
+	issueManager, err := issue.NewIssueManager(mode, annotation)
+	if err != nil {
+		ui.LogFatal(err.Error())
+	}
+
+	if err := issue.ScanReader(issueManager, os.Stdin, "stdin"+ext); err != nil {
+		ui.LogFatal(err.Error())
+	}
+
+	issues := issueManager.GetIssues()
+	if format == format_json {
+		data, err := json.MarshalIndent(issues, "", "  ")
+		if err != nil {
+			ui.LogFatal(err.Error())
+		}
+		fmt.Println(string(data))
+		return
+	}
+
+	if len(issues) == 0 {
+		fmt.Println(ui.SecondaryTextStyle.Render(fmt.Sprintf("%s %s", no_issues, annotation)))
+		return
+	}
+
+	success := fmt.Sprintf("Found %d issue annotations using %s", len(issues), annotation)
+	fmt.Println(ui.SuccessTextStyle.Render(success))
+	issue.PrintIssueDetails(issues, ui.DimTextStyle, ui.PrimaryTextStyle)
+}
+
+// summaryFlags returns the value of the summary flag and the output format of the summary
+func summaryFlags(cmd *cobra.Command) (bool, string) {
+	summary, err := cmd.Flags().GetBool(flag_summary)
+	if err != nil {
+		ui.LogFatal(err.Error())
+	}
+
+	format, err := cmd.Flags().GetString(flag_format)
+	if err != nil {
+		ui.LogFatal(err.Error())
+	}
+
+	if format != format_text && format != format_json {
+		ui.LogFatal(fmt.Sprintf("unsupported format %q. use %s or %s", format, format_text, format_json))
+	}
+
+	return summary, format
+}
+
+// printSummary prints the number of files that were scanned and skipped, followed by the
+// number of issues of each language and annotation, as a table or as json
+func printSummary(summary issue.Summary, format string) {
+	if format == format_json {
+		data, err := json.MarshalIndent(summary, "", "  ")
+		if err != nil {
+			ui.LogFatal(err.Error())
+		}
+		fmt.Println(string(data))
+		return
+	}
+
+	row := func(style lipgloss.Style, key string, count int) {
+		fmt.Println(style.Render(fmt.Sprintf("%-24s %6d", key, count)))
+	}
+
+	row(ui.PrimaryTextStyle, "files scanned", summary.Scanned)
+	row(ui.PrimaryTextStyle, "files skipped", summary.Skipped)
+	row(ui.PrimaryTextStyle, "hidden entries pruned", summary.Hidden)
+	row(ui.SuccessTextStyle, "issues", summary.Total)
+
+	for _, group := range []struct {
+		title  string
+		counts map[string]int
+	}{
+		{title: "language", counts: summary.Languages},
+		{title: "annotation", counts: summary.Annotations},
+	} {
+		fmt.Println()
+		fmt.Println(ui.SecondaryTextStyle.Render(fmt.Sprintf("%-24s %6s", group.title, "issues")))
+		for _, key := range issue.SortedCounts(group.counts) {
+			row(ui.DimTextStyle, key, group.counts[key])
+		}
+	}
+}
+
+// issueStatuses fetches the issues that were reported to the repository of gitConfig for
+// the annotations of the repository located at root and returns the status of each issue,
+// in the same order as issues
+func issueStatuses(gitConfig scm.GitConfig, root string, issues []issue.Issue) ([]issue.Status, error) {
+	gitManager, err := scm.NewGitManager(gitConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	reported, err := gitManager.ReportedIssues()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]issue.Status, len(issues))
+	for i, is := range issues {
+		if remote, ok := reported[issue.Fingerprint(is, root)]; ok {
+			statuses[i] = issue.Status{Number: remote.Number, State: remote.State, URL: remote.URL}
+		}
+	}
+
+	return statuses, nil
+}
+
+// workspaceStatuses returns the status of the issues of a repository of the workspace,
+// which is always compared with the repository of its own remote url
+func workspaceStatuses(root string, issues []issue.Issue) ([]issue.Status, error) {
+	gitConfig, err := loadGitConfig(root)
+	if err != nil {
+		return nil, err
+	}
+	return issueStatuses(gitConfig, root, issues)
+}
+
+func printStatuses(root string, issues []issue.Issue, statuses []issue.Status) {
+	for i, is := range issues {
+		style := ui.SecondaryTextStyle
+		switch statuses[i].Kind() {
+		case issue.STATUS_REPORTED:
+			style = ui.NoteTextStyle
+		case issue.STATUS_RESOLVED:
+			style = ui.SuccessTextStyle
+		}
+
+		location := fmt.Sprintf("%s:%d", relativeTo(root, is.FilePath), is.LineNumber)
+		fmt.Println(
+			style.Render(fmt.Sprintf("%-24s", statuses[i])),
+			ui.DimTextStyle.Render(location),
+			ui.PrimaryTextStyle.Render(is.Title),
+		)
+	}
+}
+
+// scanWorkspace scans every repository beneath dir and prints the issues grouped by
+// repository, followed by the total number of issues. A repository that can't be
+// scanned is reported and doesn't prevent the others from being scanned.
+func scanWorkspace(
+	cmd *cobra.Command,
+	dir string,
+	depth int,
+	mode string,
+	verbose bool,
+	status bool,
+) {
+	annotation, err := cmd.Flags().GetString(flag_annotation)
+	if err != nil {
+		ui.LogFatal(err.Error())
+	}
+
+	issueIgnorePath, err := cmd.Flags().GetString(flag_issueignore_path)
+	if err != nil {
+		ui.LogFatal(err.Error())
+	}
+
+	caches := make(map[string]*issue.ScanCache)
+	include := includeFilter(cmd)
+	stats := &issue.WalkStats{}
+	results, err := workspace.Scan(dir, workspace.Options{
+		Mode:       mode,
+		Annotation: annotation,
+		MaxDepth:   depth,
+		Params: func(root string) issue.WalkParams {
+			cache := scanCache(cmd, root, annotation)
+			scanHidden, allowHidden := hiddenFlags(cmd)
+			caches[root] = cache
+			return issue.WalkParams{
+				Root:             root,
+				IssueIgnorePath:  issueIgnorePath,
+				ExcludesFile:     excludesFile(cmd, root),
+				Cache:            cache,
+				IgnoreCase:       ignoreCase(cmd),
+				NoDefaultIgnores: noDefaultIgnores(cmd),
+				Include:          include,
+				IncludeBinary:    includeBinary(cmd),
+				MaxFileSize:      maxFileSize(cmd),
+				MaxDepth:         maxDepth(cmd),
+				FollowSymlinks:   followSymlinks(cmd),
+				Files:            walkFiles(cmd, root),
+				ScanHidden:       scanHidden,
+				AllowHidden:      allowHidden,
+				Stats:            stats,
+			}
+		},
+	})
+	if err != nil {
+		ui.LogFatal(err.Error())
+	}
+
+	if len(results) == 0 {
+		ui.LogFatal(fmt.Sprintf("no git repositories were found in %s", dir))
+	}
+
+	if summary, format := summaryFlags(cmd); summary {
+		summarizeWorkspace(cmd, dir, results, caches, *stats, format)
+		return
+	}
+
+	total := 0
+	for _, result := range results {
+		name := relativeTo(dir, result.Repository.WorkTree)
+		if result.Err != nil {
+			fmt.Println(ui.ErrorTextStyle.Render(fmt.Sprintf("%s: %s", name, result.Err)))
+			continue
+		}
+		printFileErrors(result.Repository.WorkTree, result.FileErrors)
+
+		saveScanCache(caches[result.Repository.WorkTree])
+		issues := filter.Apply(issueFilter(cmd, result.Repository.WorkTree), result.Issues)
+		total += len(issues)
+		fmt.Println(ui.PrimaryTextStyle.Render(fmt.Sprintf("%s: %d issue(s)", name, len(issues))))
+		if status && len(issues) > 0 {
+			statuses, err := workspaceStatuses(result.Repository.WorkTree, issues)
+			if err != nil {
+				fmt.Println(ui.ErrorTextStyle.Render(fmt.Sprintf("%s: %s", name, err)))
+			} else {
+				printStatuses(result.Repository.WorkTree, issues, statuses)
+			}
+		}
+
+		if verbose {
+			issue.PrintIssueDetails(issues, ui.DimTextStyle, ui.PrimaryTextStyle)
+		}
+	}
+
+	summary := fmt.Sprintf(
+		"Found %d issue annotations using %s across %d repositories",
+		total,
+		annotation,
+		len(results),
+	)
+	if failed := len(workspace.Failed(results)); failed > 0 {
+		summary += fmt.Sprintf(" (%d could not be scanned)", failed)
+	}
+	fmt.Println(ui.SuccessTextStyle.Render(summary))
+	printFiltered(include)
+
+	if !verbose && total > 0 {
+		fmt.Println(ui.SecondaryTextStyle.Render(tip_verbose))
+	}
+}
+
 func init() {
 	rootCmd.AddCommand(scanCmd)
 	scanCmd.Flags().StringP(flag_path, shortflag_path, "", flag_desc_path)
 	scanCmd.Flags().StringP(flag_mode, shortflag_mode, issue.PENDING_ISSUE, flag_desc_mode)
 	scanCmd.Flags().BoolP(flag_verbose, shortflag_verbose, false, flag_desc_verbose)
 	scanCmd.Flags().StringP(flag_annotation, shortflag_annotation, "@TODO", flag_desc_annotation)
+	scanCmd.Flags().String(flag_issueignore_path, "", flag_desc_issueignore_path)
+	scanCmd.Flags().String(flag_preset, "", flag_desc_preset)
+	scanCmd.Flags().String(flag_filter, "", flag_desc_filter)
+	scanCmd.Flags().Bool(flag_no_cache, false, flag_desc_no_cache)
+	scanCmd.Flags().Bool(flag_no_excludes, false, flag_desc_no_excludes)
+	scanCmd.Flags().Bool(flag_ignore_case, ignore.DEFAULT_IGNORE_CASE, flag_desc_ignore_case)
+	scanCmd.Flags().Bool(flag_no_defaults, false, flag_desc_no_defaults)
+	scanCmd.Flags().StringSlice(flag_include, nil, flag_desc_include)
+	scanCmd.Flags().Bool(flag_include_binary, false, flag_desc_include_binary)
+	scanCmd.Flags().Int64(flag_max_file_size, issue.DEFAULT_MAX_FILE_SIZE, flag_desc_max_file_size)
+	scanCmd.Flags().Int(flag_max_depth, -1, flag_desc_max_depth)
+	scanCmd.Flags().Bool(flag_follow_symlinks, false, flag_desc_follow_symlinks)
+	scanCmd.Flags().Bool(flag_tracked_only, false, flag_desc_tracked_only)
+	scanCmd.Flags().Bool(flag_scan_hidden, false, flag_desc_scan_hidden)
+	scanCmd.Flags().StringSlice(flag_allow_hidden, issue.DefaultAllowHidden, flag_desc_allow_hidden)
+	scanCmd.Flags().String(flag_since, "", flag_desc_since)
+	scanCmd.Flags().Bool(flag_show_ignores, false, flag_desc_show_ignores)
+	scanCmd.Flags().String(flag_explain_ignore, "", flag_desc_explain_ignore)
+	scanCmd.Flags().Bool(flag_summary, false, flag_desc_summary)
+	scanCmd.Flags().Bool(flag_stdin, false, flag_desc_stdin)
+	scanCmd.Flags().String(flag_ext, "", flag_desc_ext)
+	scanCmd.Flags().String(flag_format, format_text, flag_desc_format)
+	scanCmd.Flags().String(flag_workspace, "", flag_desc_workspace)
+	scanCmd.Flags().Int(flag_workspace_depth, workspace.DEFAULT_MAX_DEPTH, flag_desc_workspace_depth)
+	scanCmd.Flags().Bool(flag_status, false, flag_desc_status)
+	scanCmd.Flags().StringP(flag_scm, shortflag_scm, scm.GITHUB, flag_desc_scm)
+	scanCmd.Flags().String(flag_owner, "", flag_desc_owner)
+	scanCmd.Flags().String(flag_repo, "", flag_desc_repo)
+	scanCmd.Flags().SetNormalizeFunc(normalizeFlagAliases)
 }
@@ -5,6 +5,10 @@ package main
 
 import "github.com/AntoninoAdornetto/issue-summoner/cmd"
 
+// version is set by goreleaser
+var version = "dev"
+
 func main() {
+	cmd.Version = version
 	cmd.Execute()
 }
@@ -0,0 +1,63 @@
+/*
+Package clock abstracts reading the wall clock and waiting on timers so that time based
+features, such as polling for an access token, can be tested deterministically. Production
+code uses Real. Tests use a Fake clock that only moves forward when it is advanced.
+*/
+package clock
+
+import "time"
+
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer mirrors the methods of *time.Timer that we depend on. C is a method, rather than
+// a field, so that fake timers can satisfy the interface.
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+// Real is the Clock that is backed by the time package
+var Real Clock = realClock{}
+
+// OrReal returns c, or Real when c is nil. It is used to default optional clocks.
+func OrReal(c Clock) Clock {
+	if c == nil {
+		return Real
+	}
+	return c
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+func (realClock) NewTimer(d time.Duration) Timer {
+	return &realTimer{timer: time.NewTimer(d)}
+}
+
+type realTimer struct {
+	timer *time.Timer
+}
+
+func (t *realTimer) C() <-chan time.Time {
+	return t.timer.C
+}
+
+func (t *realTimer) Stop() bool {
+	return t.timer.Stop()
+}
+
+func (t *realTimer) Reset(d time.Duration) bool {
+	return t.timer.Reset(d)
+}
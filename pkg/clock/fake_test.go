@@ -0,0 +1,68 @@
+package clock_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/AntoninoAdornetto/issue-summoner/pkg/clock"
+	"github.com/stretchr/testify/require"
+)
+
+var epoch = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// should only move time forward when the clock is advanced
+func TestFakeNow(t *testing.T) {
+	clk := clock.NewFake(epoch)
+	require.Equal(t, epoch, clk.Now())
+
+	clk.Advance(time.Minute)
+	require.Equal(t, epoch.Add(time.Minute), clk.Now())
+}
+
+// should fire timers once the clock reaches their deadline
+func TestFakeTimerFires(t *testing.T) {
+	clk := clock.NewFake(epoch)
+	timer := clk.NewTimer(10 * time.Second)
+
+	clk.Advance(9 * time.Second)
+	select {
+	case <-timer.C():
+		t.Fatal("timer fired before its deadline")
+	default:
+	}
+
+	clk.Advance(time.Second)
+	require.Equal(t, epoch.Add(10*time.Second), <-timer.C())
+}
+
+// should not fire a stopped timer and should re-arm a timer when it is reset
+func TestFakeTimerStopReset(t *testing.T) {
+	clk := clock.NewFake(epoch)
+	timer := clk.NewTimer(time.Second)
+	require.True(t, timer.Stop())
+	require.False(t, timer.Stop())
+
+	clk.Advance(time.Second)
+	select {
+	case <-timer.C():
+		t.Fatal("stopped timer fired")
+	default:
+	}
+
+	require.False(t, timer.Reset(5*time.Second))
+	clk.Advance(5 * time.Second)
+	require.Equal(t, epoch.Add(6*time.Second), <-timer.C())
+}
+
+// should wait for a timer to be armed in another goroutine
+func TestFakeBlockUntil(t *testing.T) {
+	clk := clock.NewFake(epoch)
+	done := make(chan time.Time)
+	go func() {
+		done <- <-clk.After(time.Hour)
+	}()
+
+	clk.BlockUntil(1)
+	clk.Advance(time.Hour)
+	require.Equal(t, epoch.Add(time.Hour), <-done)
+}
@@ -0,0 +1,412 @@
+/*
+Package filter compiles a small expression language into a predicate over issues, so
+that findings can be narrowed down with a single --filter flag. For example:
+
+	keyword == "@FIXME" && path =~ "^pkg/" && !(line < 10)
+
+Fields:
+
+	keyword      string  the annotation that was matched, such as @TODO
+	title        string  the title of the issue
+	description  string  the description of the issue
+	path         string  the path of the file, relative to the root of the project
+	file         string  the base name of the file
+	line         number  the line that the comment starts on
+
+Strings are double quoted and support ==, !=, =~ and !~, where the right hand side of
+=~ and !~ is a regular expression. Numbers support ==, !=, <, <=, > and >=. Comparisons
+are combined with && and ||, negated with ! and grouped with parentheses. ! binds looser
+than a comparison, && binds tighter than ||. The expression is type checked before any
+issue is evaluated and errors include the column where the problem was found.
+*/
+package filter
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/AntoninoAdornetto/issue-summoner/pkg/issue"
+)
+
+// Predicate reports whether an issue matches a compiled expression
+type Predicate func(is issue.Issue) bool
+
+// Error describes a syntax or type error. Pos is the byte offset, in the expression,
+// where the error was found.
+type Error struct {
+	Pos int
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("invalid filter at column %d: %s", e.Pos+1, e.Msg)
+}
+
+type valueType int
+
+const (
+	type_bool valueType = iota
+	type_string
+	type_number
+)
+
+func (t valueType) String() string {
+	switch t {
+	case type_string:
+		return "string"
+	case type_number:
+		return "number"
+	default:
+		return "bool"
+	}
+}
+
+// node is a type checked expression. Only the eval func that matches typ is set.
+// constant is set for string literals so that regular expressions can be compiled once.
+type node struct {
+	typ      valueType
+	pos      int
+	boolean  func(is issue.Issue) bool
+	str      func(is issue.Issue) string
+	num      func(is issue.Issue) float64
+	constant *string
+}
+
+var fields = map[string]node{
+	"keyword":     {typ: type_string, str: func(is issue.Issue) string { return is.Annotation }},
+	"title":       {typ: type_string, str: func(is issue.Issue) string { return is.Title }},
+	"description": {typ: type_string, str: func(is issue.Issue) string { return is.Description }},
+	"path":        {typ: type_string, str: func(is issue.Issue) string { return is.FilePath }},
+	"file":        {typ: type_string, str: func(is issue.Issue) string { return is.FileName }},
+	"line": {
+		typ: type_number,
+		num: func(is issue.Issue) float64 { return float64(is.LineNumber) },
+	},
+}
+
+// Compile parses and type checks the expression. The expression must evaluate to a bool.
+func Compile(src string) (Predicate, error) {
+	tokens, err := tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	if p.peek().kind == tok_eof {
+		return nil, &Error{Pos: 0, Msg: "empty expression"}
+	}
+
+	n, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if next := p.peek(); next.kind != tok_eof {
+		return nil, &Error{Pos: next.pos, Msg: fmt.Sprintf("unexpected %s", next)}
+	}
+
+	if n.typ != type_bool {
+		return nil, &Error{
+			Pos: n.pos,
+			Msg: fmt.Sprintf("expression must be a comparison, got %s", n.typ),
+		}
+	}
+
+	return Predicate(n.boolean), nil
+}
+
+// Apply returns the issues that match the predicate
+func Apply(pred Predicate, issues []issue.Issue) []issue.Issue {
+	matched := make([]issue.Issue, 0, len(issues))
+	for _, is := range issues {
+		if pred(is) {
+			matched = append(matched, is)
+		}
+	}
+	return matched
+}
+
+type parser struct {
+	tokens []token
+	i      int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.i]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.i]
+	if tok.kind != tok_eof {
+		p.i++
+	}
+	return tok
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return left, err
+	}
+
+	for p.peek().kind == tok_or {
+		op := p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return right, err
+		}
+
+		if err := requireBool(op, left, right); err != nil {
+			return left, err
+		}
+
+		l, r := left.boolean, right.boolean
+		left = node{
+			typ:     type_bool,
+			pos:     left.pos,
+			boolean: func(is issue.Issue) bool { return l(is) || r(is) },
+		}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return left, err
+	}
+
+	for p.peek().kind == tok_and {
+		op := p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return right, err
+		}
+
+		if err := requireBool(op, left, right); err != nil {
+			return left, err
+		}
+
+		l, r := left.boolean, right.boolean
+		left = node{
+			typ:     type_bool,
+			pos:     left.pos,
+			boolean: func(is issue.Issue) bool { return l(is) && r(is) },
+		}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if p.peek().kind != tok_not {
+		return p.parseComparison()
+	}
+
+	op := p.next()
+	operand, err := p.parseUnary()
+	if err != nil {
+		return operand, err
+	}
+
+	if err := requireBool(op, operand); err != nil {
+		return operand, err
+	}
+
+	fn := operand.boolean
+	return node{
+		typ:     type_bool,
+		pos:     op.pos,
+		boolean: func(is issue.Issue) bool { return !fn(is) },
+	}, nil
+}
+
+func (p *parser) parseComparison() (node, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return left, err
+	}
+
+	op := p.peek()
+	switch op.kind {
+	case tok_eq, tok_neq, tok_match, tok_not_match, tok_lt, tok_lte, tok_gt, tok_gte:
+		p.next()
+	default:
+		return left, nil
+	}
+
+	right, err := p.parsePrimary()
+	if err != nil {
+		return right, err
+	}
+
+	if left.typ != right.typ {
+		return left, &Error{
+			Pos: op.pos,
+			Msg: fmt.Sprintf("cannot compare %s with %s using %s", left.typ, right.typ, op.text),
+		}
+	}
+
+	switch left.typ {
+	case type_string:
+		return compareStrings(op, left, right)
+	case type_number:
+		return compareNumbers(op, left, right)
+	default:
+		return compareBools(op, left, right)
+	}
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tok_lparen:
+		n, err := p.parseOr()
+		if err != nil {
+			return n, err
+		}
+
+		if closing := p.next(); closing.kind != tok_rparen {
+			return n, &Error{
+				Pos: closing.pos,
+				Msg: fmt.Sprintf("expected ) to close the ( at column %d but found %s", tok.pos+1, closing),
+			}
+		}
+
+		n.pos = tok.pos
+		return n, nil
+	case tok_string:
+		text := tok.text
+		return node{
+			typ:      type_string,
+			pos:      tok.pos,
+			str:      func(issue.Issue) string { return text },
+			constant: &text,
+		}, nil
+	case tok_number:
+		value, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return node{}, &Error{Pos: tok.pos, Msg: fmt.Sprintf("invalid number %s", tok.text)}
+		}
+		return node{
+			typ: type_number,
+			pos: tok.pos,
+			num: func(issue.Issue) float64 { return value },
+		}, nil
+	case tok_ident:
+		switch tok.text {
+		case "true", "false":
+			value := tok.text == "true"
+			return node{
+				typ:     type_bool,
+				pos:     tok.pos,
+				boolean: func(issue.Issue) bool { return value },
+			}, nil
+		}
+
+		field, ok := fields[tok.text]
+		if !ok {
+			return node{}, &Error{Pos: tok.pos, Msg: fmt.Sprintf("unknown field %s", tok.text)}
+		}
+
+		field.pos = tok.pos
+		return field, nil
+	default:
+		return node{}, &Error{
+			Pos: tok.pos,
+			Msg: fmt.Sprintf("expected a field, string or number but found %s", tok),
+		}
+	}
+}
+
+func requireBool(op token, operands ...node) error {
+	for _, operand := range operands {
+		if operand.typ != type_bool {
+			return &Error{
+				Pos: operand.pos,
+				Msg: fmt.Sprintf("%s requires a comparison, got %s", op.text, operand.typ),
+			}
+		}
+	}
+	return nil
+}
+
+func undefinedOperator(op token, typ valueType) error {
+	return &Error{
+		Pos: op.pos,
+		Msg: fmt.Sprintf("operator %s is not defined for %s", op.text, typ),
+	}
+}
+
+func compareStrings(op token, left, right node) (node, error) {
+	l, r := left.str, right.str
+	n := node{typ: type_bool, pos: left.pos}
+
+	switch op.kind {
+	case tok_eq:
+		n.boolean = func(is issue.Issue) bool { return l(is) == r(is) }
+	case tok_neq:
+		n.boolean = func(is issue.Issue) bool { return l(is) != r(is) }
+	case tok_match, tok_not_match:
+		if right.constant == nil {
+			return n, &Error{
+				Pos: right.pos,
+				Msg: fmt.Sprintf("the right side of %s must be a string literal", op.text),
+			}
+		}
+
+		re, err := regexp.Compile(*right.constant)
+		if err != nil {
+			return n, &Error{Pos: right.pos, Msg: fmt.Sprintf("invalid regular expression: %s", err)}
+		}
+
+		negate := op.kind == tok_not_match
+		n.boolean = func(is issue.Issue) bool { return re.MatchString(l(is)) != negate }
+	default:
+		return n, undefinedOperator(op, type_string)
+	}
+
+	return n, nil
+}
+
+func compareNumbers(op token, left, right node) (node, error) {
+	l, r := left.num, right.num
+	n := node{typ: type_bool, pos: left.pos}
+
+	switch op.kind {
+	case tok_eq:
+		n.boolean = func(is issue.Issue) bool { return l(is) == r(is) }
+	case tok_neq:
+		n.boolean = func(is issue.Issue) bool { return l(is) != r(is) }
+	case tok_lt:
+		n.boolean = func(is issue.Issue) bool { return l(is) < r(is) }
+	case tok_lte:
+		n.boolean = func(is issue.Issue) bool { return l(is) <= r(is) }
+	case tok_gt:
+		n.boolean = func(is issue.Issue) bool { return l(is) > r(is) }
+	case tok_gte:
+		n.boolean = func(is issue.Issue) bool { return l(is) >= r(is) }
+	default:
+		return n, undefinedOperator(op, type_number)
+	}
+
+	return n, nil
+}
+
+func compareBools(op token, left, right node) (node, error) {
+	l, r := left.boolean, right.boolean
+	n := node{typ: type_bool, pos: left.pos}
+
+	switch op.kind {
+	case tok_eq:
+		n.boolean = func(is issue.Issue) bool { return l(is) == r(is) }
+	case tok_neq:
+		n.boolean = func(is issue.Issue) bool { return l(is) != r(is) }
+	default:
+		return n, undefinedOperator(op, type_bool)
+	}
+
+	return n, nil
+}
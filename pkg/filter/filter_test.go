@@ -0,0 +1,135 @@
+package filter_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/AntoninoAdornetto/issue-summoner/pkg/filter"
+	"github.com/AntoninoAdornetto/issue-summoner/pkg/issue"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	todo = issue.Issue{
+		Annotation:  "@TODO",
+		Title:       "add retries",
+		Description: "requests fail on flaky networks",
+		FilePath:    "pkg/scm/github.go",
+		FileName:    "github.go",
+		LineNumber:  42,
+	}
+	fixme = issue.Issue{
+		Annotation: "@FIXME",
+		Title:      "off by one",
+		FilePath:   "cmd/report.go",
+		FileName:   "report.go",
+		LineNumber: 7,
+	}
+)
+
+// should evaluate comparisons for each field type
+func TestCompileComparisons(t *testing.T) {
+	cases := []struct {
+		expr  string
+		todo  bool
+		fixme bool
+	}{
+		{expr: `keyword == "@FIXME"`, todo: false, fixme: true},
+		{expr: `keyword != "@FIXME"`, todo: true, fixme: false},
+		{expr: `path =~ "^pkg/"`, todo: true, fixme: false},
+		{expr: `path !~ "^pkg/"`, todo: false, fixme: true},
+		{expr: `file == "report.go"`, todo: false, fixme: true},
+		{expr: `title =~ "retr(y|ies)"`, todo: true, fixme: false},
+		{expr: `description == ""`, todo: false, fixme: true},
+		{expr: `line > 10`, todo: true, fixme: false},
+		{expr: `line >= 42`, todo: true, fixme: false},
+		{expr: `line < 7`, todo: false, fixme: false},
+		{expr: `line <= 7`, todo: false, fixme: true},
+		{expr: `line == 7`, todo: false, fixme: true},
+		{expr: `line != 7`, todo: true, fixme: false},
+		{expr: `10 < line`, todo: true, fixme: false},
+		{expr: `true`, todo: true, fixme: true},
+		{expr: `"a\"b" == "a\"b"`, todo: true, fixme: true},
+	}
+
+	for _, tc := range cases {
+		pred, err := filter.Compile(tc.expr)
+		require.NoError(t, err, tc.expr)
+		require.Equal(t, tc.todo, pred(todo), "%s with @TODO", tc.expr)
+		require.Equal(t, tc.fixme, pred(fixme), "%s with @FIXME", tc.expr)
+	}
+}
+
+// should give && precedence over || and ! the lowest precedence of the unary operators
+func TestCompilePrecedence(t *testing.T) {
+	cases := []struct {
+		expr  string
+		todo  bool
+		fixme bool
+	}{
+		{expr: `keyword == "@FIXME" || line > 10 && file == "nope.go"`, todo: false, fixme: true},
+		{expr: `(keyword == "@FIXME" || line > 10) && file == "nope.go"`, todo: false, fixme: false},
+		{expr: `!keyword == "@FIXME"`, todo: true, fixme: false},
+		{expr: `!(line > 10) && !(path =~ "^pkg/")`, todo: false, fixme: true},
+		{expr: `!!(line > 10)`, todo: true, fixme: false},
+		{expr: `line > 1 && line < 100 || false`, todo: true, fixme: true},
+		{expr: `false || keyword == "@TODO" && true`, todo: true, fixme: false},
+	}
+
+	for _, tc := range cases {
+		pred, err := filter.Compile(tc.expr)
+		require.NoError(t, err, tc.expr)
+		require.Equal(t, tc.todo, pred(todo), "%s with @TODO", tc.expr)
+		require.Equal(t, tc.fixme, pred(fixme), "%s with @FIXME", tc.expr)
+	}
+}
+
+// should report syntax and type errors with the column where they were found
+func TestCompileErrors(t *testing.T) {
+	cases := []struct {
+		expr   string
+		column int
+		msg    string
+	}{
+		{expr: ``, column: 1, msg: "empty expression"},
+		{expr: `owner == "me"`, column: 1, msg: "unknown field owner"},
+		{expr: `keyword == "@TODO`, column: 12, msg: "unterminated string"},
+		{expr: `keyword == 1`, column: 9, msg: "cannot compare string with number using =="},
+		{expr: `line == "7"`, column: 6, msg: "cannot compare number with string using =="},
+		{expr: `title < "b"`, column: 7, msg: "operator < is not defined for string"},
+		{expr: `line =~ 7`, column: 6, msg: "operator =~ is not defined for number"},
+		{expr: `keyword`, column: 1, msg: "expression must be a comparison, got string"},
+		{expr: `!keyword`, column: 2, msg: "! requires a comparison, got string"},
+		{expr: `line > 1 && title`, column: 13, msg: "&& requires a comparison, got string"},
+		{expr: `line || true`, column: 1, msg: "|| requires a comparison, got number"},
+		{expr: `path =~ "["`, column: 9, msg: "invalid regular expression"},
+		{expr: `path =~ title`, column: 9, msg: "the right side of =~ must be a string literal"},
+		{expr: `(line > 1`, column: 10, msg: "expected ) to close the ( at column 1"},
+		{expr: `line > 1)`, column: 9, msg: "unexpected )"},
+		{expr: `line > 1 == true`, column: 10, msg: "unexpected =="},
+		{expr: `line >`, column: 7, msg: "expected a field, string or number but found end of expression"},
+		{expr: `line > 1 & true`, column: 10, msg: "unexpected character '&'"},
+		{expr: `line > 1.2.3`, column: 8, msg: "invalid number 1.2.3"},
+	}
+
+	for _, tc := range cases {
+		_, err := filter.Compile(tc.expr)
+		require.Error(t, err, tc.expr)
+
+		filterErr := &filter.Error{}
+		require.True(t, errors.As(err, &filterErr), tc.expr)
+		require.Equal(t, tc.column, filterErr.Pos+1, "%s: %s", tc.expr, err)
+		require.ErrorContains(t, err, tc.msg, tc.expr)
+	}
+}
+
+// should return the issues that match the predicate, in order
+func TestApply(t *testing.T) {
+	pred, err := filter.Compile(`line < 100`)
+	require.NoError(t, err)
+	require.Equal(t, []issue.Issue{todo, fixme}, filter.Apply(pred, []issue.Issue{todo, fixme}))
+
+	pred, err = filter.Compile(`keyword == "@FIXME"`)
+	require.NoError(t, err)
+	require.Equal(t, []issue.Issue{fixme}, filter.Apply(pred, []issue.Issue{todo, fixme}))
+}
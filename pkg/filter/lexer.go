@@ -0,0 +1,148 @@
+package filter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tok_eof tokenKind = iota
+	tok_ident
+	tok_string
+	tok_number
+	tok_eq
+	tok_neq
+	tok_match
+	tok_not_match
+	tok_lt
+	tok_lte
+	tok_gt
+	tok_gte
+	tok_and
+	tok_or
+	tok_not
+	tok_lparen
+	tok_rparen
+)
+
+// operators are ordered so that two character operators are tried before their one
+// character prefix
+var operators = []struct {
+	text string
+	kind tokenKind
+}{
+	{"==", tok_eq},
+	{"!=", tok_neq},
+	{"=~", tok_match},
+	{"!~", tok_not_match},
+	{"<=", tok_lte},
+	{">=", tok_gte},
+	{"&&", tok_and},
+	{"||", tok_or},
+	{"<", tok_lt},
+	{">", tok_gt},
+	{"!", tok_not},
+	{"(", tok_lparen},
+	{")", tok_rparen},
+}
+
+type token struct {
+	kind tokenKind
+	text string // source text of the token, string literals are unquoted
+	pos  int    // byte offset of the token in the expression
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tok_eof:
+		return "end of expression"
+	case tok_string:
+		return strconv.Quote(t.text)
+	default:
+		return t.text
+	}
+}
+
+func tokenize(src string) ([]token, error) {
+	tokens := make([]token, 0)
+	i := 0
+
+	for i < len(src) {
+		ch := src[i]
+		switch {
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
+			i++
+		case ch == '"':
+			end, text, err := scanString(src, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tok_string, text: text, pos: i})
+			i = end
+		case isDigit(ch):
+			start := i
+			for i < len(src) && (isDigit(src[i]) || src[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tok_number, text: src[start:i], pos: start})
+		case isIdentStart(ch):
+			start := i
+			for i < len(src) && (isIdentStart(src[i]) || isDigit(src[i])) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tok_ident, text: src[start:i], pos: start})
+		default:
+			op, ok := scanOperator(src, i)
+			if !ok {
+				return nil, &Error{Pos: i, Msg: fmt.Sprintf("unexpected character %q", ch)}
+			}
+			tokens = append(tokens, op)
+			i += len(op.text)
+		}
+	}
+
+	return append(tokens, token{kind: tok_eof, pos: len(src)}), nil
+}
+
+// scanString reads the double quoted string that starts at index start. Escape
+// sequences follow the rules of Go string literals.
+func scanString(src string, start int) (int, string, error) {
+	i := start + 1
+	for i < len(src) && src[i] != '"' {
+		if src[i] == '\\' {
+			i++
+		}
+		i++
+	}
+
+	if i >= len(src) {
+		return 0, "", &Error{Pos: start, Msg: "unterminated string"}
+	}
+
+	text, err := strconv.Unquote(src[start : i+1])
+	if err != nil {
+		return 0, "", &Error{Pos: start, Msg: "invalid escape sequence in string"}
+	}
+
+	return i + 1, text, nil
+}
+
+func scanOperator(src string, i int) (token, bool) {
+	for _, op := range operators {
+		if strings.HasPrefix(src[i:], op.text) {
+			return token{kind: op.kind, text: op.text, pos: i}, true
+		}
+	}
+	return token{}, false
+}
+
+func isDigit(ch byte) bool {
+	return ch >= '0' && ch <= '9'
+}
+
+func isIdentStart(ch byte) bool {
+	return ch == '_' || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z')
+}
@@ -0,0 +1,114 @@
+/*
+Hooks allow users to run their own commands after issue-summoner completes an action.
+The only event that is supported, at the moment, is issue creation. Each hook command is
+executed by the system shell and the details of the event are exposed to the command as
+environment variables. This keeps the integration surface small and avoids having to
+interpolate untrusted values, such as issue titles, into the command itself.
+
+Example config.json entry:
+
+	"hooks": {"issue_created": "git branch issue-$ISSUE_SUMMONER_ISSUE_NUMBER"}
+*/
+package hook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+const (
+	DEFAULT_TIMEOUT = 30 * time.Second
+	ENV_ISSUE_ID    = "ISSUE_SUMMONER_ISSUE_ID"
+	ENV_ISSUE_NUM   = "ISSUE_SUMMONER_ISSUE_NUMBER"
+	ENV_ISSUE_URL   = "ISSUE_SUMMONER_ISSUE_URL"
+	ENV_ISSUE_TITLE = "ISSUE_SUMMONER_ISSUE_TITLE"
+	ENV_ISSUE_FILE  = "ISSUE_SUMMONER_ISSUE_FILE"
+	ENV_ISSUE_LINE  = "ISSUE_SUMMONER_ISSUE_LINE"
+)
+
+// IssueCreatedEvent contains the details of an issue that was successfully
+// created on a source code management platform
+type IssueCreatedEvent struct {
+	ID         int64
+	Number     int
+	URL        string
+	Title      string
+	FilePath   string
+	LineNumber int
+}
+
+func (ev IssueCreatedEvent) environ() []string {
+	return []string{
+		fmt.Sprintf("%s=%d", ENV_ISSUE_ID, ev.ID),
+		fmt.Sprintf("%s=%d", ENV_ISSUE_NUM, ev.Number),
+		fmt.Sprintf("%s=%s", ENV_ISSUE_URL, ev.URL),
+		fmt.Sprintf("%s=%s", ENV_ISSUE_TITLE, ev.Title),
+		fmt.Sprintf("%s=%s", ENV_ISSUE_FILE, ev.FilePath),
+		fmt.Sprintf("%s=%d", ENV_ISSUE_LINE, ev.LineNumber),
+	}
+}
+
+// Runner executes a hook command. A zero value Timeout uses DEFAULT_TIMEOUT and
+// a nil Stdout discards the output of the command.
+type Runner struct {
+	Command string
+	Timeout time.Duration
+	Stdout  io.Writer
+}
+
+// RunIssueCreated executes the hook command for a single created issue and waits for it
+// to exit. The stderr output of the command is included in the returned error when the
+// command fails or does not complete before the timeout elapses.
+func (r *Runner) RunIssueCreated(ctx context.Context, ev IssueCreatedEvent) error {
+	if strings.TrimSpace(r.Command) == "" {
+		return nil
+	}
+
+	timeout := r.Timeout
+	if timeout <= 0 {
+		timeout = DEFAULT_TIMEOUT
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	stderr := bytes.Buffer{}
+	cmd := shellCommand(ctx, r.Command)
+	cmd.Env = append(os.Environ(), ev.environ()...)
+	cmd.Stdout = r.Stdout
+	cmd.Stderr = &stderr
+	// child processes spawned by the shell can keep the output pipes open after
+	// the shell itself has been killed, don't wait on them forever
+	cmd.WaitDelay = time.Second
+
+	err := cmd.Run()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf(
+			"hook <%s> timed out after %s\n%s",
+			r.Command,
+			timeout,
+			stderr.String(),
+		)
+	}
+
+	if err != nil {
+		return fmt.Errorf("hook <%s> failed: %s\n%s", r.Command, err, stderr.String())
+	}
+
+	return nil
+}
+
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, "cmd", "/C", command)
+	}
+	return exec.CommandContext(ctx, "sh", "-c", command)
+}
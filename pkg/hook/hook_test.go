@@ -0,0 +1,75 @@
+package hook_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/AntoninoAdornetto/issue-summoner/pkg/hook"
+	"github.com/stretchr/testify/require"
+)
+
+func skipWindows(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook tests rely on a posix shell")
+	}
+}
+
+// should expose the issue details to the hook command as environment variables
+func TestRunIssueCreatedEnvironment(t *testing.T) {
+	skipWindows(t)
+	dir := t.TempDir()
+	out := filepath.Join(dir, "env.txt")
+	script := filepath.Join(dir, "record.sh")
+	err := os.WriteFile(script, []byte("#!/bin/sh\nenv | grep ISSUE_SUMMONER_ | sort > \"$1\"\n"), 0755)
+	require.NoError(t, err)
+
+	runner := hook.Runner{Command: script + " " + out}
+	err = runner.RunIssueCreated(context.Background(), hook.IssueCreatedEvent{
+		ID:         99,
+		Number:     7,
+		URL:        "https://github.com/owner/repo/issues/7",
+		Title:      "fix the thing",
+		FilePath:   "pkg/main.go",
+		LineNumber: 12,
+	})
+	require.NoError(t, err)
+
+	recorded, err := os.ReadFile(out)
+	require.NoError(t, err)
+	expected := "ISSUE_SUMMONER_ISSUE_FILE=pkg/main.go\n" +
+		"ISSUE_SUMMONER_ISSUE_ID=99\n" +
+		"ISSUE_SUMMONER_ISSUE_LINE=12\n" +
+		"ISSUE_SUMMONER_ISSUE_NUMBER=7\n" +
+		"ISSUE_SUMMONER_ISSUE_TITLE=fix the thing\n" +
+		"ISSUE_SUMMONER_ISSUE_URL=https://github.com/owner/repo/issues/7\n"
+	require.Equal(t, expected, string(recorded))
+}
+
+// should include the stderr output of the command when it fails
+func TestRunIssueCreatedFailure(t *testing.T) {
+	skipWindows(t)
+	runner := hook.Runner{Command: "echo something went wrong >&2; exit 1"}
+	err := runner.RunIssueCreated(context.Background(), hook.IssueCreatedEvent{})
+	require.Error(t, err)
+	require.ErrorContains(t, err, "something went wrong")
+}
+
+// should stop the command once the timeout elapses
+func TestRunIssueCreatedTimeout(t *testing.T) {
+	skipWindows(t)
+	runner := hook.Runner{Command: "sleep 5", Timeout: 50 * time.Millisecond}
+	start := time.Now()
+	err := runner.RunIssueCreated(context.Background(), hook.IssueCreatedEvent{})
+	require.ErrorContains(t, err, "timed out")
+	require.Less(t, time.Since(start), 3*time.Second)
+}
+
+// should do nothing when a hook command is not configured
+func TestRunIssueCreatedNoCommand(t *testing.T) {
+	runner := hook.Runner{}
+	require.NoError(t, runner.RunIssueCreated(context.Background(), hook.IssueCreatedEvent{}))
+}
@@ -0,0 +1,871 @@
+/*
+Package ignore translates gitignore patterns into regular expressions so that the
+files and directories excluded by git can be skipped while walking a project.
+
+The global excludes file of git, core.excludesFile, is added with AppendGlobalExcludeFile
+and the directories that are skipped in every project, DefaultIgnores, are added with
+AppendDefaultIgnores.
+Nested .gitignore files are added with EnterDir while a project is walked and only
+apply to the paths beneath their directory.
+
+The patterns of every ignore file are merged into an IgnoreMatcher and evaluated the same
+way git does, the last pattern that matches a path decides whether it's ignored. Patterns
+are ordered by the precedence of their file, from the lowest to the highest: DefaultIgnores,
+core.excludesFile, .git/info/exclude, .gitignore files, where the file closest to the path
+comes last, and the ignore files of issue summoner, such as .issueignore. A negated pattern
+re-includes a path that an earlier pattern excluded, unless a parent directory of the path
+is excluded, since git does not descend into excluded directories. Explain reports the
+pattern that decided a path.
+
+Each line of an ignore file is parsed into an IgnorePattern. Blank lines and lines
+that begin with # are skipped. A leading ! negates the pattern. Patterns that contain
+a separator are anchored to the directory that contains the ignore file, while patterns
+without a separator match a file or directory name at any depth. A double asterisk that
+is a complete path component matches any number of directories, other consecutive
+asterisks are treated as a single asterisk, as documented by gitignore. A bracket
+expression, such as [a-z], [!0-9] or [[:digit:]], matches a single character other than
+a separator. A backslash escapes the character that follows it, so that \#file and
+\!file match names that begin with # or !.
+Lines that are not valid patterns, such as an unclosed bracket or a trailing backslash,
+are skipped like git skips them.
+Trailing spaces are removed, except for a space that is escaped with a backslash, while
+leading spaces and tabs are part of the pattern.
+A trailing / restricts the pattern to directories, so that build/ excludes a directory
+named build but not a file with the same name.
+
+The matching rules are verified against `git check-ignore` in parity_test.go, please add
+a case to the corpus when changing how patterns are translated.
+*/
+package ignore
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	pathpkg "path"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+const (
+	GITIGNORE           = ".gitignore"
+	ISSUEIGNORE         = ".issueignore"
+	ISSUESUMMONERIGNORE = ".issuesummonerignore"
+	INFO_EXCLUDE        = ".git/info/exclude"
+)
+
+// DEFAULT_IGNORES_SRC is the Src of the group of default ignores, see DefaultIgnores
+const DEFAULT_IGNORES_SRC = "(default ignores)"
+
+// DefaultIgnores are the dependency, build and editor directories that are skipped even
+// when a project doesn't ignore them. They have the lowest precedence, a negated pattern in
+// any ignore file of the project re-includes a path that they exclude.
+var DefaultIgnores = []string{
+	"node_modules/",
+	"vendor/",
+	".venv/",
+	"target/",
+	"dist/",
+	".idea/",
+	".vscode/",
+}
+
+// DEFAULT_IGNORE_CASE is set on the platforms whose file systems are case insensitive by
+// default, which is also when git sets core.ignoreCase
+const DEFAULT_IGNORE_CASE = runtime.GOOS == "darwin" || runtime.GOOS == "windows"
+
+// IgnorePattern is a single line of an ignore file. DirOnly is set for patterns with a
+// trailing slash, which only match directories. Line is the line number of the pattern in
+// its ignore file, starting at 1, or 0 when it was not parsed from a file.
+type IgnorePattern struct {
+	Pattern string
+	Negate  bool
+	DirOnly bool
+	Line    int
+	re      *regexp.Regexp
+	fold    *regexp.Regexp // re without regard to case
+}
+
+// Source is the kind of ignore file that an ExcludeGroup was parsed from. Sources are
+// ordered by precedence, from the lowest to the highest.
+type Source int
+
+const (
+	SOURCE_DEFAULTS     Source = iota // DefaultIgnores
+	SOURCE_GLOBAL                     // core.excludesFile
+	SOURCE_INFO_EXCLUDE               // .git/info/exclude
+	SOURCE_GITIGNORE                  // .gitignore files
+	SOURCE_ISSUEIGNORE                // .issueignore, .issuesummonerignore or a custom path
+)
+
+// ExcludeGroup contains the patterns that were parsed from a single ignore file.
+// BasePath is the directory that the patterns are relative to. nested is set for the
+// .gitignore files of subdirectories that were added by EnterDir.
+type ExcludeGroup struct {
+	Src      string
+	BasePath string
+	Patterns []IgnorePattern
+	Source   Source
+	nested   bool
+}
+
+// Ignorer matches paths against the patterns of every ignore file that was added. Patterns
+// are case sensitive unless IgnoreCase is set, which is useful on the case insensitive file
+// systems of macOS and windows, see DEFAULT_IGNORE_CASE. The ignore files are read from FS,
+// using slash separated paths, or from the file system of the OS when it's nil.
+type Ignorer struct {
+	ExcludeGroups []ExcludeGroup
+	IgnoreCase    bool
+	FS            fs.FS
+	matcher       *IgnoreMatcher // built from ExcludeGroups when a path is matched
+}
+
+// NewIgnorer creates an Ignorer with the patterns from the .gitignore file that resides
+// in root and the .git/info/exclude file of the repository that contains root. root may be
+// a subdirectory of the repository, the patterns of the exclude file are relative to the
+// root of the repository. Neither file is required to exist.
+func NewIgnorer(root string) (*Ignorer, error) {
+	return NewIgnorerFS(nil, root)
+}
+
+// NewIgnorerFS is NewIgnorer for a project that resides in fsys, root is a path of fsys.
+// The repository that contains root is searched for within fsys only.
+func NewIgnorerFS(fsys fs.FS, root string) (*Ignorer, error) {
+	ig := &Ignorer{FS: fsys}
+
+	if err := ig.AppendExcludeGroup(root, GITIGNORE); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	if repoRoot, ok := ig.findRepoRoot(root); ok {
+		err := ig.AppendExcludeGroup(repoRoot, INFO_EXCLUDE)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+
+	return ig, nil
+}
+
+// AppendGlobalExcludeFile adds the patterns of the excludes file that git applies to every
+// repository, core.excludesFile. The patterns are relative to the root of the repository
+// that contains root, or to root itself when it's not in a repository. A missing file is
+// not an error.
+func (ig *Ignorer) AppendGlobalExcludeFile(root, path string) error {
+	basePath, ok := ig.findRepoRoot(root)
+	if !ok {
+		basePath = root
+	}
+
+	err := ig.appendExcludeFile(basePath, path, SOURCE_GLOBAL)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+// AppendDefaultIgnores adds the DefaultIgnores, relative to root, at the lowest precedence
+func (ig *Ignorer) AppendDefaultIgnores(root string) error {
+	patterns, err := ParseIgnorePatterns(strings.NewReader(strings.Join(DefaultIgnores, "\n")))
+	if err != nil {
+		return err
+	}
+
+	ig.ExcludeGroups = append(ig.ExcludeGroups, ExcludeGroup{
+		Src:      DEFAULT_IGNORES_SRC,
+		BasePath: filepath.Clean(root),
+		Patterns: patterns,
+		Source:   SOURCE_DEFAULTS,
+	})
+
+	ig.matcher = nil
+	return nil
+}
+
+// findRepoRoot returns the closest directory, starting at dir, that contains a .git
+// directory. Repositories that use a .git file, such as linked worktrees, are not
+// resolved since their exclude file is stored in the git directory of the main worktree.
+// The directory is relative when dir is relative, so that it can be compared with the
+// paths of the walk.
+func (ig *Ignorer) findRepoRoot(dir string) (string, bool) {
+	if ig.FS != nil {
+		return findRepoRootFS(ig.FS, dir)
+	}
+
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", false
+	}
+
+	for {
+		if info, err := os.Stat(filepath.Join(dir, ".git")); err == nil && info.IsDir() {
+			return filepath.Clean(dir), true
+		}
+
+		if filepath.Dir(abs) == abs {
+			return "", false
+		}
+		abs, dir = filepath.Dir(abs), filepath.Join(dir, "..")
+	}
+}
+
+// findRepoRootFS is findRepoRoot for the paths of fsys, which can't leave its root
+func findRepoRootFS(fsys fs.FS, dir string) (string, bool) {
+	dir = pathpkg.Clean(filepath.ToSlash(dir))
+	for {
+		if info, err := fs.Stat(fsys, pathpkg.Join(dir, ".git")); err == nil && info.IsDir() {
+			return dir, true
+		}
+
+		if dir == "." {
+			return "", false
+		}
+		dir = pathpkg.Dir(dir)
+	}
+}
+
+// open opens the ignore file located at path, see FS
+func (ig *Ignorer) open(path string) (fs.File, error) {
+	if ig.FS != nil {
+		return ig.FS.Open(filepath.ToSlash(path))
+	}
+	return os.Open(path)
+}
+
+// AppendExcludeGroup parses the ignore file located at filepath.Join(basePath, src)
+// and adds the patterns to the ignorer. The patterns are relative to basePath. The
+// precedence of the file is derived from src, .gitignore files and the exclude file of
+// the repository are ranked like git ranks them and any other file is an ignore file of
+// issue summoner.
+func (ig *Ignorer) AppendExcludeGroup(basePath, src string) error {
+	source := SOURCE_ISSUEIGNORE
+	switch {
+	case filepath.ToSlash(src) == INFO_EXCLUDE:
+		source = SOURCE_INFO_EXCLUDE
+	case filepath.Base(src) == GITIGNORE:
+		source = SOURCE_GITIGNORE
+	}
+
+	return ig.appendExcludeFile(basePath, filepath.Join(basePath, src), source)
+}
+
+// AppendExcludeFile parses the ignore file located at path, which does not need to
+// reside in basePath, and adds the patterns to the ignorer at the highest precedence,
+// see SOURCE_ISSUEIGNORE. The patterns are relative to basePath.
+func (ig *Ignorer) AppendExcludeFile(basePath, path string) error {
+	return ig.appendExcludeFile(basePath, path, SOURCE_ISSUEIGNORE)
+}
+
+func (ig *Ignorer) appendExcludeFile(basePath, path string, source Source) error {
+	file, err := ig.open(path)
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+	patterns, err := ParseIgnorePatterns(file)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	src, err := filepath.Rel(basePath, path)
+	if err != nil {
+		src = path
+	}
+
+	ig.ExcludeGroups = append(ig.ExcludeGroups, ExcludeGroup{
+		Src:      src,
+		BasePath: filepath.Clean(basePath),
+		Patterns: patterns,
+		Source:   source,
+	})
+
+	ig.matcher = nil
+	return nil
+}
+
+// EnterDir is called when a walk enters dir, a subdirectory of the root of the project.
+// The .gitignore files of directories that dir is not beneath are dropped, since the walk
+// has left them, and the .gitignore file of dir is added when it exists.
+func (ig *Ignorer) EnterDir(dir string) error {
+	dir = filepath.Clean(dir)
+	groups := ig.ExcludeGroups[:0]
+	for _, group := range ig.ExcludeGroups {
+		if !group.nested || isWithin(group.BasePath, dir) {
+			groups = append(groups, group)
+		}
+	}
+	ig.ExcludeGroups, ig.matcher = groups, nil
+
+	err := ig.AppendExcludeGroup(dir, GITIGNORE)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	ig.ExcludeGroups[len(ig.ExcludeGroups)-1].nested = true
+	return nil
+}
+
+// isWithin reports whether path is dir or is beneath it
+func isWithin(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+
+	rel = filepath.ToSlash(rel)
+	return rel != ".." && !strings.HasPrefix(rel, "../")
+}
+
+// ParseIgnorePatterns reads gitignore formatted patterns, one per line, from r. Lines that
+// are not valid patterns, such as foo[ or a lone !, are skipped the same way git skips
+// them, since git never matches them against a path.
+func ParseIgnorePatterns(r io.Reader) ([]IgnorePattern, error) {
+	patterns := make([]IgnorePattern, 0)
+	scanner := bufio.NewScanner(r)
+
+	for n := 1; scanner.Scan(); n++ {
+		line := trimTrailingSpaces(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		pattern, err := NewIgnorePattern(line)
+		if err != nil {
+			continue
+		}
+
+		pattern.Line = n
+		patterns = append(patterns, pattern)
+	}
+
+	return patterns, scanner.Err()
+}
+
+// trimTrailingSpaces removes the spaces at the end of the line, unless the last space is
+// escaped with a backslash. Like git, tabs and leading spaces are part of the pattern.
+func trimTrailingSpaces(line string) string {
+	end := len(strings.TrimRight(line, " "))
+	if end == len(line) || line[end] != ' ' {
+		return line[:end]
+	}
+
+	// the space is escaped when it follows an odd number of backslashes
+	backslashes := 0
+	for i := end - 1; i >= 0 && line[i] == '\\'; i-- {
+		backslashes++
+	}
+
+	if backslashes%2 == 1 {
+		end++
+	}
+
+	return line[:end]
+}
+
+// NewIgnorePattern translates a single gitignore pattern into a regular expression
+func NewIgnorePattern(line string) (IgnorePattern, error) {
+	pattern := IgnorePattern{Pattern: line}
+
+	if strings.HasPrefix(line, "!") {
+		pattern.Negate = true
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") {
+		pattern.DirOnly = true
+		line = strings.TrimSuffix(line, "/")
+	}
+
+	if line == "" {
+		return pattern, fmt.Errorf("invalid pattern: %s", pattern.Pattern)
+	}
+
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+
+	body, err := translate(line)
+	if err != nil {
+		return pattern, fmt.Errorf("invalid pattern %s: %w", pattern.Pattern, err)
+	}
+
+	expr := "(^|/)" + body + "$"
+	if anchored {
+		expr = "^" + body + "$"
+	}
+
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return pattern, fmt.Errorf("invalid pattern %s: %w", pattern.Pattern, err)
+	}
+
+	pattern.re, pattern.fold = re, regexp.MustCompile("(?i)"+expr)
+	return pattern, nil
+}
+
+// translate converts the glob syntax of a pattern into regular expression syntax
+func translate(glob string) (string, error) {
+	builder := strings.Builder{}
+
+	for i := 0; i < len(glob); i++ {
+		switch glob[i] {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				i = translateGlobstar(glob, i, &builder)
+				continue
+			}
+			builder.WriteString("[^/]*")
+		case '?':
+			builder.WriteString("[^/]")
+		case '[':
+			end, class, err := translateRange(glob, i)
+			if err != nil {
+				return "", err
+			}
+			builder.WriteString(class)
+			i = end
+		case '\\':
+			// a backslash escapes the next character, which is matched literally
+			if i == len(glob)-1 {
+				return "", errors.New("trailing backslash")
+			}
+			i++
+			builder.WriteString(regexp.QuoteMeta(string(glob[i])))
+		default:
+			builder.WriteString(regexp.QuoteMeta(string(glob[i])))
+		}
+	}
+
+	return builder.String(), nil
+}
+
+// translateGlobstar converts the run of asterisks at index start and returns the index of
+// the last character that was consumed. A run that is a complete path component matches
+// any number of directories, otherwise it is treated the same as a single *.
+func translateGlobstar(glob string, start int, builder *strings.Builder) int {
+	end := start + 1
+	for end+1 < len(glob) && glob[end+1] == '*' {
+		end++
+	}
+	leading := start == 0 || glob[start-1] == '/'
+
+	switch {
+	case leading && end == len(glob)-1:
+		// "foo/**" matches everything inside of foo, "**" matches everything
+		builder.WriteString(".+")
+		return end
+	case leading && glob[end+1] == '/':
+		// "**/foo" and "a/**/b" match zero or more directories
+		builder.WriteString("(?:.*/)?")
+		return end + 1
+	default:
+		builder.WriteString("[^/]*")
+		return end
+	}
+}
+
+// charRange is an inclusive range of characters of a bracket expression
+type charRange struct {
+	lo, hi rune
+}
+
+// posixClasses are the character classes of bracket expressions, such as [[:digit:]].
+// Like git, the classes of the C locale are used.
+var posixClasses = map[string][]charRange{
+	"alnum":  {{'0', '9'}, {'A', 'Z'}, {'a', 'z'}},
+	"alpha":  {{'A', 'Z'}, {'a', 'z'}},
+	"blank":  {{' ', ' '}, {'\t', '\t'}},
+	"cntrl":  {{0x00, 0x1f}, {0x7f, 0x7f}},
+	"digit":  {{'0', '9'}},
+	"graph":  {{'!', '~'}},
+	"lower":  {{'a', 'z'}},
+	"print":  {{' ', '~'}},
+	"punct":  {{'!', '/'}, {':', '@'}, {'[', '`'}, {'{', '~'}},
+	"space":  {{'\t', '\r'}, {' ', ' '}},
+	"upper":  {{'A', 'Z'}},
+	"xdigit": {{'0', '9'}, {'A', 'F'}, {'a', 'f'}},
+}
+
+// translateRange converts a bracket expression, such as [a-z], [!0-9] or [[:digit:]],
+// starting at index start and returns the index of the closing bracket along with the
+// regexp class. The expression is parsed the way the wildmatch of git parses it: a ]
+// right after the opening bracket is a literal, a - is a literal at either end and after
+// a range or class, and a reversed range such as [z-a] matches nothing. Like git, the
+// class never matches a /, even when it's negated.
+func translateRange(glob string, start int) (int, string, error) {
+	chars := []rune(glob[start+1:])
+	i, negate := 0, false
+	if i < len(chars) && (chars[i] == '!' || chars[i] == '^') {
+		negate = true
+		i++
+	}
+
+	ranges := make([]charRange, 0)
+	prev, hasPrev := rune(0), false
+	for first := true; first || chars[i] != ']'; first = false {
+		if i >= len(chars) {
+			return 0, "", errors.New("unbalanced range notation")
+		}
+
+		ch := chars[i]
+		switch {
+		case ch == '\\':
+			i++
+			if i >= len(chars) {
+				return 0, "", errors.New("unbalanced range notation")
+			}
+			ch = chars[i]
+			ranges = append(ranges, charRange{ch, ch})
+			prev, hasPrev = ch, true
+		case ch == '-' && hasPrev && i+1 < len(chars) && chars[i+1] != ']':
+			i++
+			hi := chars[i]
+			if hi == '\\' {
+				i++
+				if i >= len(chars) {
+					return 0, "", errors.New("unbalanced range notation")
+				}
+				hi = chars[i]
+			}
+			if prev <= hi {
+				ranges = append(ranges, charRange{prev, hi})
+			}
+			hasPrev = false
+		case ch == '[' && i+1 < len(chars) && chars[i+1] == ':':
+			end := i + 2
+			for end < len(chars) && chars[end] != ']' {
+				end++
+			}
+			if end >= len(chars) {
+				return 0, "", errors.New("unbalanced range notation")
+			}
+
+			// without a closing :] the bracket is a literal
+			if end-i < 4 || chars[end-1] != ':' {
+				ranges = append(ranges, charRange{ch, ch})
+				prev, hasPrev = ch, true
+				break
+			}
+
+			name := string(chars[i+2 : end-1])
+			class, ok := posixClasses[name]
+			if !ok {
+				return 0, "", fmt.Errorf("unknown character class %s", name)
+			}
+			ranges = append(ranges, class...)
+			i, hasPrev = end, false
+		default:
+			ranges = append(ranges, charRange{ch, ch})
+			prev, hasPrev = ch, true
+		}
+		i++
+	}
+
+	end := start + 1 + len(string(chars[:i]))
+	if negate {
+		ranges = append(ranges, charRange{'/', '/'})
+	} else {
+		ranges = withoutSlash(ranges)
+	}
+
+	// an empty class, such as [z-a], matches nothing
+	if len(ranges) == 0 {
+		return end, `[^\x00-\x{10FFFF}]`, nil
+	}
+
+	class := strings.Builder{}
+	class.WriteByte('[')
+	if negate {
+		class.WriteByte('^')
+	}
+	for _, r := range ranges {
+		fmt.Fprintf(&class, `\x{%x}`, r.lo)
+		if r.hi != r.lo {
+			fmt.Fprintf(&class, `-\x{%x}`, r.hi)
+		}
+	}
+	class.WriteByte(']')
+	return end, class.String(), nil
+}
+
+// withoutSlash removes the / from the ranges, splitting the ranges that contain it
+func withoutSlash(ranges []charRange) []charRange {
+	out := make([]charRange, 0, len(ranges))
+	for _, r := range ranges {
+		if r.lo > '/' || r.hi < '/' {
+			out = append(out, r)
+			continue
+		}
+
+		if r.lo < '/' {
+			out = append(out, charRange{r.lo, '/' - 1})
+		}
+		if r.hi > '/' {
+			out = append(out, charRange{'/' + 1, r.hi})
+		}
+	}
+	return out
+}
+
+// Match reports whether the slash separated path, relative to the directory of the
+// ignore file, is matched by the pattern. isDir reports if the path is a directory, which
+// is required for patterns that are restricted to directories.
+func (p *IgnorePattern) Match(rel string, isDir bool) bool {
+	return p.match(rel, isDir, false)
+}
+
+// MatchFold is the same as Match, except that the case of letters is ignored
+func (p *IgnorePattern) MatchFold(rel string, isDir bool) bool {
+	return p.match(rel, isDir, true)
+}
+
+func (p *IgnorePattern) match(rel string, isDir, ignoreCase bool) bool {
+	if p.DirOnly && !isDir {
+		return false
+	}
+
+	if ignoreCase {
+		return p.fold.MatchString(rel)
+	}
+	return p.re.MatchString(rel)
+}
+
+// Match reports if the path should be ignored, see IgnoreMatcher. isDir reports if the path
+// itself is a directory.
+func (ig *Ignorer) Match(path string, isDir bool) (bool, error) {
+	entry, err := ig.Explain(path, isDir)
+	return entry != nil && !entry.Pattern.Negate, err
+}
+
+// Explain returns the pattern that decides whether the path is ignored, or nil when no
+// pattern matches it, see IgnoreMatcher.Explain
+func (ig *Ignorer) Explain(path string, isDir bool) (*IgnoreEntry, error) {
+	if ig.matcher == nil {
+		ig.matcher = NewIgnoreMatcher(ig.ExcludeGroups, ig.IgnoreCase)
+	}
+
+	ig.matcher.IgnoreCase = ig.IgnoreCase
+	return ig.matcher.Explain(path, isDir)
+}
+
+// Precedence returns the exclude groups ordered from the lowest to the highest precedence,
+// which is the order that their patterns are evaluated in. Groups of the same Source keep
+// the order they were added in, except for .gitignore files where the file that is closest
+// to the paths beneath it comes last.
+func (ig *Ignorer) Precedence() []ExcludeGroup {
+	return byPrecedence(ig.ExcludeGroups)
+}
+
+func byPrecedence(excludeGroups []ExcludeGroup) []ExcludeGroup {
+	groups := make([]ExcludeGroup, len(excludeGroups))
+	copy(groups, excludeGroups)
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].Source != groups[j].Source {
+			return groups[i].Source < groups[j].Source
+		}
+
+		// the base paths of .gitignore files that apply to the same path are nested, the
+		// deeper file is closer to the path
+		if groups[i].Source == SOURCE_GITIGNORE {
+			return len(groups[i].BasePath) < len(groups[j].BasePath)
+		}
+		return false
+	})
+
+	return groups
+}
+
+// IgnoreEntry is a pattern along with the ignore file that it was parsed from
+type IgnoreEntry struct {
+	Src      string
+	BasePath string
+	Source   Source
+	Pattern  IgnorePattern
+}
+
+// String formats the entry like `git check-ignore --verbose`, src:line:pattern
+func (entry IgnoreEntry) String() string {
+	return fmt.Sprintf("%s:%d:%s", entry.Src, entry.Pattern.Line, entry.Pattern.Pattern)
+}
+
+// IgnoreMatcher holds the patterns of every ignore file ordered from the lowest to the
+// highest precedence. Like git, the last pattern that matches a path decides whether it's
+// ignored, so a negated pattern re-includes a path that an earlier pattern excluded. A path
+// is ignored when one of its parent directories is ignored, no pattern can re-include it
+// since git does not descend into excluded directories.
+type IgnoreMatcher struct {
+	Entries    []IgnoreEntry
+	IgnoreCase bool
+}
+
+// NewIgnoreMatcher merges the patterns of the groups, ordered by precedence. See
+// Ignorer.Precedence.
+func NewIgnoreMatcher(groups []ExcludeGroup, ignoreCase bool) *IgnoreMatcher {
+	m := &IgnoreMatcher{IgnoreCase: ignoreCase}
+	for _, group := range byPrecedence(groups) {
+		for _, pattern := range group.Patterns {
+			m.Entries = append(m.Entries, IgnoreEntry{
+				Src:      group.Src,
+				BasePath: group.BasePath,
+				Source:   group.Source,
+				Pattern:  pattern,
+			})
+		}
+	}
+
+	return m
+}
+
+// Match reports if the path should be ignored. isDir reports if the path itself is a
+// directory.
+func (m *IgnoreMatcher) Match(path string, isDir bool) (bool, error) {
+	entry, err := m.Explain(path, isDir)
+	return entry != nil && !entry.Pattern.Negate, err
+}
+
+// Explain returns the entry whose pattern decides whether the path is ignored, or nil when
+// no pattern matches the path or its parent directories. The path is ignored when the
+// pattern of the entry is not negated. When a parent directory is ignored the entry that
+// ignored the directory is returned. The patterns are only evaluated against the part of
+// the path that is beneath the BasePath of their entry, so the names of the directories
+// that contain BasePath are never matched.
+func (m *IgnoreMatcher) Explain(path string, isDir bool) (*IgnoreEntry, error) {
+	components := make(map[string][]string)
+	for _, entry := range m.Entries {
+		if _, ok := components[entry.BasePath]; ok {
+			continue
+		}
+
+		basePath, path, err := comparablePaths(entry.BasePath, path)
+		if err != nil {
+			return nil, err
+		}
+
+		sep := filepath.Separator
+		if rel, ok := relativeSlashPath(toSlash(basePath, sep), toSlash(path, sep)); ok {
+			components[entry.BasePath] = strings.Split(rel, "/")
+		} else {
+			components[entry.BasePath] = nil
+		}
+	}
+
+	return m.explain(components, isDir), nil
+}
+
+// explain decides the path whose components, relative to the base path of each entry, are
+// keyed by base path. The parent directories are checked first, from the top.
+func (m *IgnoreMatcher) explain(components map[string][]string, isDir bool) *IgnoreEntry {
+	depth := 0
+	for _, c := range components {
+		depth = max(depth, len(c)-1)
+	}
+
+	for up := depth; up > 0; up-- {
+		if entry := m.decide(components, up, true); entry != nil && !entry.Pattern.Negate {
+			return entry
+		}
+	}
+
+	return m.decide(components, 0, isDir)
+}
+
+// decide returns the last entry that matches the ancestor of the path that is up levels
+// above it, the path itself when up is 0
+func (m *IgnoreMatcher) decide(components map[string][]string, up int, isDir bool) *IgnoreEntry {
+	for i := len(m.Entries) - 1; i >= 0; i-- {
+		entry := &m.Entries[i]
+		c := components[entry.BasePath]
+		if len(c)-up < 1 {
+			continue
+		}
+
+		if entry.Pattern.match(strings.Join(c[:len(c)-up], "/"), isDir, m.IgnoreCase) {
+			return entry
+		}
+	}
+
+	return nil
+}
+
+// Match reports if the path is excluded by the patterns of the group alone
+func (group *ExcludeGroup) Match(path string, isDir bool) (bool, error) {
+	return NewIgnoreMatcher([]ExcludeGroup{*group}, false).Match(path, isDir)
+}
+
+// comparablePaths makes both paths absolute when only one of them is, which happens when
+// the root of a walk is relative and an absolute path is matched, or the other way around
+func comparablePaths(basePath, path string) (string, string, error) {
+	if filepath.IsAbs(basePath) == filepath.IsAbs(path) {
+		return basePath, path, nil
+	}
+
+	absBase, err := filepath.Abs(basePath)
+	if err != nil {
+		return "", "", err
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", "", err
+	}
+
+	return absBase, absPath, nil
+}
+
+// toSlash replaces sep, the path separator of the platform that produced path, with a
+// slash. Unlike filepath.ToSlash the separator is a parameter, so that the paths of windows
+// can be matched on any platform.
+func toSlash(path string, sep rune) string {
+	if sep == '/' {
+		return path
+	}
+	return strings.ReplaceAll(path, string(sep), "/")
+}
+
+// relativeSlashPath returns target relative to base, both slash separated. ok is false
+// when target is not beneath base. The drive letters of windows paths, such as C:, are
+// compared without regard to case.
+func relativeSlashPath(base, target string) (rel string, ok bool) {
+	base, target = pathpkg.Clean(base), pathpkg.Clean(target)
+	baseVolume, targetVolume := volumeName(base), volumeName(target)
+	if !strings.EqualFold(baseVolume, targetVolume) {
+		return "", false
+	}
+
+	base, target = base[len(baseVolume):], target[len(targetVolume):]
+	switch {
+	case base == target:
+		return "", false
+	case base == ".":
+		rel = target
+	case strings.HasPrefix(target, strings.TrimSuffix(base, "/")+"/"):
+		rel = target[len(strings.TrimSuffix(base, "/"))+1:]
+	default:
+		return "", false
+	}
+
+	if rel == ".." || strings.HasPrefix(rel, "../") || strings.HasPrefix(rel, "/") {
+		return "", false
+	}
+	return rel, true
+}
+
+// volumeName returns the drive letter of a slash separated windows path, such as C:
+func volumeName(path string) string {
+	if len(path) >= 2 && path[1] == ':' &&
+		('a' <= path[0] && path[0] <= 'z' || 'A' <= path[0] && path[0] <= 'Z') {
+		return path[:2]
+	}
+	return ""
+}
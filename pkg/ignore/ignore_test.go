@@ -0,0 +1,527 @@
+package ignore_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/AntoninoAdornetto/issue-summoner/pkg/ignore"
+	"github.com/stretchr/testify/require"
+)
+
+// should match across any number of directories when ** is leading, trailing or in the
+// middle of a pattern
+func TestIgnorePatternGlobstar(t *testing.T) {
+	cases := []struct {
+		pattern string
+		path    string
+		matched bool
+	}{
+		{pattern: "**/foo", path: "foo", matched: true},
+		{pattern: "**/foo", path: "a/foo", matched: true},
+		{pattern: "**/foo", path: "a/b/c/foo", matched: true},
+		{pattern: "**/foo", path: "a/foobar", matched: false},
+		{pattern: "foo/**", path: "foo/a", matched: true},
+		{pattern: "foo/**", path: "foo/a/b/c.go", matched: true},
+		{pattern: "foo/**", path: "foo", matched: false},
+		{pattern: "foo/**", path: "bar/foo/a", matched: false},
+		{pattern: "src/**/test", path: "src/test", matched: true},
+		{pattern: "src/**/test", path: "src/a/test", matched: true},
+		{pattern: "src/**/test", path: "src/a/b/test", matched: true},
+		{pattern: "src/**/test", path: "src/a/b/testing", matched: false},
+		{pattern: "src/**/test", path: "lib/src/a/test", matched: false},
+		{pattern: "**", path: "a/b/c", matched: true},
+		{pattern: "a**b", path: "axyb", matched: true},
+		{pattern: "a**b", path: "ax/yb", matched: false},
+	}
+
+	for _, tc := range cases {
+		pattern, err := ignore.NewIgnorePattern(tc.pattern)
+		require.NoError(t, err)
+		require.Equal(
+			t,
+			tc.matched,
+			pattern.Match(tc.path, false),
+			"pattern %q and path %q",
+			tc.pattern,
+			tc.path,
+		)
+	}
+}
+
+// should follow the examples of the PATTERN FORMAT section of the gitignore man page
+func TestIgnorePatternManPage(t *testing.T) {
+	cases := []struct {
+		pattern string
+		path    string
+		isDir   bool
+		matched bool
+	}{
+		// "**/foo" matches file or directory "foo" anywhere
+		{pattern: "**/foo", path: "foo", matched: true},
+		{pattern: "**/foo", path: "x/y/foo", isDir: true, matched: true},
+		// "**/foo/bar" matches "bar" anywhere that is directly under directory "foo"
+		{pattern: "**/foo/bar", path: "foo/bar", matched: true},
+		{pattern: "**/foo/bar", path: "x/foo/bar", matched: true},
+		{pattern: "**/foo/bar", path: "foo/x/bar", matched: false},
+		// "abc/**" matches all files inside directory "abc"
+		{pattern: "abc/**", path: "abc/x", matched: true},
+		{pattern: "abc/**", path: "abc/x/y", matched: true},
+		{pattern: "abc/**", path: "abc", isDir: true, matched: false},
+		{pattern: "abc/**", path: "x/abc/y", matched: false},
+		// "a/**/b" matches "a/b", "a/x/b", "a/x/y/b" and so on
+		{pattern: "a/**/b", path: "a/b", matched: true},
+		{pattern: "a/**/b", path: "a/x/b", matched: true},
+		{pattern: "a/**/b", path: "a/x/y/b", matched: true},
+		{pattern: "a/**/b", path: "x/a/b", matched: false},
+		// other consecutive asterisks are considered regular asterisks
+		{pattern: "a**/b", path: "ax/b", matched: true},
+		{pattern: "a**/b", path: "ax/y/b", matched: false},
+		{pattern: "a/**b", path: "a/x/yb", matched: false},
+		{pattern: "a/***/b", path: "a/x/y/b", matched: true},
+		// "**/__pycache__/**" matches everything inside of any __pycache__ directory
+		{pattern: "**/__pycache__/**", path: "__pycache__/m.pyc", matched: true},
+		{pattern: "**/__pycache__/**", path: "pkg/sub/__pycache__/m.pyc", matched: true},
+		{pattern: "**/__pycache__/**", path: "pkg/__pycache__x/m.pyc", matched: false},
+	}
+
+	for _, tc := range cases {
+		pattern, err := ignore.NewIgnorePattern(tc.pattern)
+		require.NoError(t, err)
+		require.Equal(
+			t,
+			tc.matched,
+			pattern.Match(tc.path, tc.isDir),
+			"pattern %q and path %q",
+			tc.pattern,
+			tc.path,
+		)
+	}
+}
+
+// should anchor patterns with a leading slash to the directory of the ignore file
+func TestIgnorePatternLeadingSlash(t *testing.T) {
+	cases := []struct {
+		pattern string
+		path    string
+		matched bool
+	}{
+		{pattern: "/build", path: "build", matched: true},
+		{pattern: "/build", path: "src/build", matched: false},
+		{pattern: "/build", path: "a/b/build", matched: false},
+		{pattern: "/src/build", path: "src/build", matched: true},
+		{pattern: "/src/build", path: "lib/src/build", matched: false},
+		{pattern: "/*.c", path: "main.c", matched: true},
+		{pattern: "/*.c", path: "src/main.c", matched: false},
+		{pattern: "build", path: "src/build", matched: true},
+	}
+
+	for _, tc := range cases {
+		pattern, err := ignore.NewIgnorePattern(tc.pattern)
+		require.NoError(t, err)
+		require.Equal(
+			t,
+			tc.matched,
+			pattern.Match(tc.path, false),
+			"pattern %q and path %q",
+			tc.pattern,
+			tc.path,
+		)
+	}
+}
+
+// should only match directories when the pattern has a trailing slash
+func TestIgnorePatternDirOnly(t *testing.T) {
+	cases := []struct {
+		pattern string
+		path    string
+		isDir   bool
+		matched bool
+	}{
+		{pattern: "build/", path: "build", isDir: true, matched: true},
+		{pattern: "build/", path: "build", isDir: false, matched: false},
+		{pattern: "build/", path: "src/build", isDir: true, matched: true},
+		{pattern: "build", path: "build", isDir: true, matched: true},
+		{pattern: "build", path: "build", isDir: false, matched: true},
+		{pattern: "docs/build/", path: "docs/build", isDir: true, matched: true},
+		{pattern: "docs/build/", path: "docs/build", isDir: false, matched: false},
+		{pattern: "docs/build/", path: "src/docs/build", isDir: true, matched: false},
+	}
+
+	for _, tc := range cases {
+		pattern, err := ignore.NewIgnorePattern(tc.pattern)
+		require.NoError(t, err)
+		require.Equal(
+			t,
+			tc.matched,
+			pattern.Match(tc.path, tc.isDir),
+			"pattern %q and path %q, directory: %t",
+			tc.pattern,
+			tc.path,
+			tc.isDir,
+		)
+	}
+}
+
+// should apply a directory pattern to the files beneath the directory but not to a
+// file with the same name
+func TestIgnorerDirOnly(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".gitignore"), []byte("build/\n"), 0644))
+
+	ig, err := ignore.NewIgnorer(root)
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		path    string
+		isDir   bool
+		ignored bool
+	}{
+		{path: "build", isDir: false, ignored: false},
+		{path: "build", isDir: true, ignored: true},
+		{path: "build/main.c", isDir: false, ignored: true},
+		{path: "scripts/build", isDir: false, ignored: false},
+	} {
+		matched, err := ig.Match(filepath.Join(root, filepath.FromSlash(tc.path)), tc.isDir)
+		require.NoError(t, err)
+		require.Equal(t, tc.ignored, matched, tc.path)
+	}
+}
+
+// should match escaped characters literally
+func TestIgnorePatternEscapes(t *testing.T) {
+	cases := []struct {
+		pattern string
+		path    string
+		matched bool
+	}{
+		{pattern: `\#file`, path: "#file", matched: true},
+		{pattern: `\#file`, path: "dir/#file", matched: true},
+		{pattern: `file\ name`, path: "file name", matched: true},
+		{pattern: `file\ name`, path: "file\\ name", matched: false},
+		{pattern: `\[abc\]`, path: "[abc]", matched: true},
+		{pattern: `\[abc\]`, path: "b", matched: false},
+		{pattern: `[\]a]`, path: "]", matched: true},
+		{pattern: `[\]a]`, path: "a", matched: true},
+		{pattern: `\*`, path: "*", matched: true},
+		{pattern: `\*`, path: "a", matched: false},
+		{pattern: `a\\b`, path: `a\b`, matched: true},
+	}
+
+	for _, tc := range cases {
+		pattern, err := ignore.NewIgnorePattern(tc.pattern)
+		require.NoError(t, err, tc.pattern)
+		require.Equal(
+			t,
+			tc.matched,
+			pattern.Match(tc.path, false),
+			"pattern %q and path %q",
+			tc.pattern,
+			tc.path,
+		)
+	}
+}
+
+// should not parse an escaped hash as a comment, or an escaped ! as a negation
+func TestParseIgnorePatternsEscapes(t *testing.T) {
+	patterns, err := ignore.ParseIgnorePatterns(strings.NewReader("# comment\n\\#file\n\\!file\n"))
+	require.NoError(t, err)
+	require.Len(t, patterns, 2)
+	require.Equal(t, `\#file`, patterns[0].Pattern)
+	require.False(t, patterns[1].Negate)
+	require.True(t, patterns[1].Match("!file", false))
+}
+
+// should remove trailing spaces, except for a space that is escaped, and keep leading
+// whitespace and trailing tabs
+func TestParseIgnorePatternsTrailingSpaces(t *testing.T) {
+	src := "notes.txt  \nfile with trailing space\\ \nodd\\\\ \n\tleading\n   \n*.c\t\n"
+	patterns, err := ignore.ParseIgnorePatterns(strings.NewReader(src))
+	require.NoError(t, err)
+	require.Len(t, patterns, 5)
+	require.Equal(t, "notes.txt", patterns[0].Pattern)
+	require.Equal(t, `file with trailing space\ `, patterns[1].Pattern)
+	require.True(t, patterns[1].Match("file with trailing space ", false))
+	require.False(t, patterns[1].Match("file with trailing space", false))
+	require.Equal(t, `odd\\`, patterns[2].Pattern)
+	require.Equal(t, "\tleading", patterns[3].Pattern)
+	require.False(t, patterns[3].Match("leading", false))
+	require.Equal(t, "*.c\t", patterns[4].Pattern)
+	require.False(t, patterns[4].Match("a.c", false))
+}
+
+// should skip the lines that are not valid patterns and keep parsing the rest of the file
+func TestParseIgnorePatternsInvalid(t *testing.T) {
+	src := "foo[\n*.log\nbar\\\n/\n!\n[[:nope:]]\nbuild/\n"
+	patterns, err := ignore.ParseIgnorePatterns(strings.NewReader(src))
+	require.NoError(t, err)
+	require.Len(t, patterns, 2)
+	require.Equal(t, "*.log", patterns[0].Pattern)
+	require.Equal(t, 2, patterns[0].Line)
+	require.Equal(t, "build/", patterns[1].Pattern)
+	require.Equal(t, 7, patterns[1].Line)
+}
+
+// should return an error for a pattern that ends with a backslash
+func TestIgnorePatternTrailingBackslash(t *testing.T) {
+	_, err := ignore.NewIgnorePattern(`file\`)
+	require.ErrorContains(t, err, "trailing backslash")
+}
+
+// should add the .gitignore file of a directory when it is entered and drop it once the
+// walk moves on to a sibling directory
+func TestIgnorerEnterDir(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"a", "a/nested", "b"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(root, dir), 0755))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(root, "a", ".gitignore"), []byte("*.o\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "a", "nested", ".gitignore"), []byte("*.c\n"), 0644))
+
+	ig, err := ignore.NewIgnorer(root)
+	require.NoError(t, err)
+
+	require.NoError(t, ig.EnterDir(filepath.Join(root, "a")))
+	require.NoError(t, ig.EnterDir(filepath.Join(root, "a", "nested")))
+	require.Len(t, ig.ExcludeGroups, 2)
+
+	for path, ignored := range map[string]bool{
+		"a/main.o":        true,
+		"a/main.c":        false,
+		"a/nested/main.o": true,
+		"a/nested/main.c": true,
+		"main.o":          false,
+	} {
+		matched, err := ig.Match(filepath.Join(root, filepath.FromSlash(path)), false)
+		require.NoError(t, err)
+		require.Equal(t, ignored, matched, path)
+	}
+
+	require.NoError(t, ig.EnterDir(filepath.Join(root, "b")))
+	require.Empty(t, ig.ExcludeGroups)
+}
+
+// should read the exclude file of the repository when root is a subdirectory of it, with
+// patterns that are relative to the root of the repository
+func TestNewIgnorerInfoExclude(t *testing.T) {
+	repo := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(repo, ".git", "info"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(repo, "pkg", "scratch"), 0755))
+	require.NoError(t, os.WriteFile(
+		filepath.Join(repo, ".git", "info", "exclude"),
+		[]byte("/pkg/scratch\n*.local\n"),
+		0644,
+	))
+
+	for _, root := range []string{repo, filepath.Join(repo, "pkg")} {
+		ig, err := ignore.NewIgnorer(root)
+		require.NoError(t, err)
+		require.Len(t, ig.ExcludeGroups, 1)
+
+		for path, ignored := range map[string]bool{
+			"pkg/scratch/main.c": true,
+			"pkg/main.c":         false,
+			"pkg/env.local":      true,
+		} {
+			matched, err := ig.Match(filepath.Join(repo, filepath.FromSlash(path)), false)
+			require.NoError(t, err)
+			require.Equal(t, ignored, matched, path)
+		}
+	}
+}
+
+// should only match patterns against the part of the path beneath the directory of the
+// ignore file, even when the directories above it have names that match a pattern
+func TestIgnorerAnchoredToBasePath(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "cmdline-tools", "build", "project")
+	require.NoError(t, os.MkdirAll(root, 0755))
+	require.NoError(t, os.WriteFile(
+		filepath.Join(root, ".gitignore"),
+		[]byte("cmd*\nbuild/\nproject\n/tools\n*-tools/\n"),
+		0644,
+	))
+
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	relRoot, err := filepath.Rel(cwd, root)
+	require.NoError(t, err)
+
+	paths := map[string]bool{
+		"main.go":           false,
+		"src/main.go":       false,
+		"cmd/main.go":       true,
+		"src/cmdline.go":    true,
+		"build/main.go":     true,
+		"src/build/main.go": true,
+		"tools/main.go":     true,
+		"src/tools/main.go": false,
+		"x-tools/main.go":   true,
+	}
+
+	for _, base := range []string{root, relRoot} {
+		ig, err := ignore.NewIgnorer(base)
+		require.NoError(t, err)
+
+		for path, ignored := range paths {
+			for _, candidate := range []string{
+				filepath.Join(root, filepath.FromSlash(path)),
+				filepath.Join(relRoot, filepath.FromSlash(path)),
+			} {
+				matched, err := ig.Match(candidate, false)
+				require.NoError(t, err)
+				require.Equal(t, ignored, matched, "%s with root %s", candidate, base)
+			}
+		}
+	}
+}
+
+// should match patterns without regard to case when IgnoreCase is set
+func TestIgnorerIgnoreCase(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(
+		filepath.Join(root, ".gitignore"),
+		[]byte("*.log\nThumbs.db\n/Build/\n"),
+		0644,
+	))
+
+	ig, err := ignore.NewIgnorer(root)
+	require.NoError(t, err)
+	require.False(t, ig.IgnoreCase)
+
+	paths := []struct {
+		path  string
+		isDir bool
+	}{
+		{path: "ERROR.LOG"},
+		{path: "logs/Debug.Log"},
+		{path: "thumbs.DB"},
+		{path: "build", isDir: true},
+		{path: "BUILD/main.c"},
+	}
+
+	for _, tc := range paths {
+		path := filepath.Join(root, filepath.FromSlash(tc.path))
+		matched, err := ig.Match(path, tc.isDir)
+		require.NoError(t, err)
+		require.False(t, matched, "%s is matched when case matters", tc.path)
+	}
+
+	ig.IgnoreCase = true
+	for _, tc := range paths {
+		path := filepath.Join(root, filepath.FromSlash(tc.path))
+		matched, err := ig.Match(path, tc.isDir)
+		require.NoError(t, err)
+		require.True(t, matched, "%s is not matched when case is ignored", tc.path)
+	}
+}
+
+// should only fold the case of a single pattern with MatchFold
+func TestIgnorePatternMatchFold(t *testing.T) {
+	pattern, err := ignore.NewIgnorePattern("*.log")
+	require.NoError(t, err)
+	require.False(t, pattern.Match("ERROR.LOG", false))
+	require.True(t, pattern.MatchFold("ERROR.LOG", false))
+	require.False(t, pattern.MatchFold("ERROR.TXT", false))
+}
+
+// should exclude the default ignores unless a negated pattern of a project ignore file
+// re-includes the path
+func TestIgnorerDefaultIgnores(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".gitignore"), []byte("!dist/\n"), 0644))
+
+	ig, err := ignore.NewIgnorer(root)
+	require.NoError(t, err)
+	require.NoError(t, ig.AppendDefaultIgnores(root))
+
+	group := ig.ExcludeGroups[len(ig.ExcludeGroups)-1]
+	require.Equal(t, ignore.DEFAULT_IGNORES_SRC, group.Src)
+	require.Len(t, group.Patterns, len(ignore.DefaultIgnores))
+
+	matched, err := ig.Match(filepath.Join(root, "node_modules", "lodash", "index.js"), false)
+	require.NoError(t, err)
+	require.True(t, matched)
+
+	matched, err = ig.Match(filepath.Join(root, "web", "vendor"), true)
+	require.NoError(t, err)
+	require.True(t, matched)
+
+	matched, err = ig.Match(filepath.Join(root, "dist", "bundle.js"), false)
+	require.NoError(t, err)
+	require.False(t, matched)
+
+	matched, err = ig.Match(filepath.Join(root, "src", "main.c"), false)
+	require.NoError(t, err)
+	require.False(t, matched)
+}
+
+// should evaluate the patterns of every ignore file by precedence, where the last pattern
+// that matches decides, and report the pattern that decided each path
+func TestIgnorerPrecedence(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		".git/info/exclude": "!keep.tmp\n",
+		".gitignore":        "*.log\nsecret/\n",
+		"sub/.gitignore":    "!debug.log\n",
+		".issueignore":      "sub/keep.tmp\n!secret/notes.c\n!node_modules/\n",
+		"global":            "*.tmp\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+
+	ig, err := ignore.NewIgnorer(root)
+	require.NoError(t, err)
+	require.NoError(t, ig.AppendDefaultIgnores(root))
+	require.NoError(t, ig.AppendGlobalExcludeFile(root, filepath.Join(root, "global")))
+	require.NoError(t, ig.AppendExcludeGroup(root, ignore.ISSUEIGNORE))
+	require.NoError(t, ig.EnterDir(filepath.Join(root, "sub")))
+
+	sources := make([]ignore.Source, 0)
+	for _, group := range ig.Precedence() {
+		sources = append(sources, group.Source)
+	}
+	require.Equal(t, []ignore.Source{
+		ignore.SOURCE_DEFAULTS,
+		ignore.SOURCE_GLOBAL,
+		ignore.SOURCE_INFO_EXCLUDE,
+		ignore.SOURCE_GITIGNORE,
+		ignore.SOURCE_GITIGNORE,
+		ignore.SOURCE_ISSUEIGNORE,
+	}, sources)
+
+	for _, tc := range []struct {
+		path    string
+		isDir   bool
+		ignored bool
+		decided string
+	}{
+		{path: "main.c", ignored: false},
+		{path: "error.log", ignored: true, decided: ".gitignore:1:*.log"},
+		{path: "sub/debug.log", ignored: false, decided: ".gitignore:1:!debug.log"},
+		{path: "sub/error.log", ignored: true, decided: ".gitignore:1:*.log"},
+		{path: "cache.tmp", ignored: true, decided: "global:1:*.tmp"},
+		{path: "keep.tmp", ignored: false, decided: ".git/info/exclude:1:!keep.tmp"},
+		{path: "sub/keep.tmp", ignored: true, decided: ".issueignore:1:sub/keep.tmp"},
+		{path: "secret/notes.c", ignored: true, decided: ".gitignore:2:secret/"},
+		{path: "node_modules", isDir: true, ignored: false, decided: ".issueignore:3:!node_modules/"},
+		{path: "web/vendor", isDir: true, ignored: true, decided: "(default ignores):2:vendor/"},
+	} {
+		path := filepath.Join(root, filepath.FromSlash(tc.path))
+		matched, err := ig.Match(path, tc.isDir)
+		require.NoError(t, err)
+		require.Equal(t, tc.ignored, matched, tc.path)
+
+		entry, err := ig.Explain(path, tc.isDir)
+		require.NoError(t, err)
+		if tc.decided == "" {
+			require.Nil(t, entry, tc.path)
+			continue
+		}
+		require.NotNil(t, entry, tc.path)
+		require.Equal(t, tc.decided, entry.String(), tc.path)
+	}
+}
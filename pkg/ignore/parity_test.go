@@ -0,0 +1,279 @@
+package ignore_test
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/AntoninoAdornetto/issue-summoner/pkg/ignore"
+	"github.com/stretchr/testify/require"
+)
+
+// parityCase is a single path that is checked against the patterns of a .gitignore
+// file. Ignored is the verdict that git produces. Paths are relative to the repository
+// root and directories are denoted with a trailing slash.
+type parityCase struct {
+	Patterns string
+	Path     string
+	Ignored  bool
+}
+
+// parityCorpus is the source of truth for how patterns are matched. When git is available
+// every case is also verified with `git check-ignore` so that the corpus can't drift from
+// git's behavior. Please add cases here when fixing a divergence.
+var parityCorpus = []parityCase{
+	{Patterns: "*.log", Path: "debug.log", Ignored: true},
+	{Patterns: "*.log", Path: "logs/debug.log", Ignored: true},
+	{Patterns: "*.log", Path: "debug.log.txt", Ignored: false},
+	{Patterns: "debug?.log", Path: "debug1.log", Ignored: true},
+	{Patterns: "debug?.log", Path: "debug10.log", Ignored: false},
+	{Patterns: "debug[0-9].log", Path: "debug3.log", Ignored: true},
+	{Patterns: "debug[0-9].log", Path: "debuga.log", Ignored: false},
+	{Patterns: "debug[!0-9].log", Path: "debuga.log", Ignored: true},
+	{Patterns: "debug[!0-9].log", Path: "debug3.log", Ignored: false},
+	{Patterns: "build", Path: "build/", Ignored: true},
+	{Patterns: "build", Path: "build", Ignored: true},
+	{Patterns: "build", Path: "src/build/", Ignored: true},
+	{Patterns: "build", Path: "src/build/main.c", Ignored: true},
+	{Patterns: "logs/debug.log", Path: "logs/debug.log", Ignored: true},
+	{Patterns: "logs/debug.log", Path: "debug.log", Ignored: false},
+	{Patterns: "logs/*.log", Path: "logs/debug.log", Ignored: true},
+	{Patterns: "logs/*.log", Path: "logs/nested/debug.log", Ignored: false},
+	{Patterns: "exclude/", Path: "exclude/ignore.sh", Ignored: true},
+	{Patterns: "# comment\n\n*.tmp", Path: "a.tmp", Ignored: true},
+	{Patterns: "# comment\n\n*.tmp", Path: "# comment", Ignored: false},
+	{Patterns: "a+b(c).txt", Path: "a+b(c).txt", Ignored: true},
+	{Patterns: "a+b(c).txt", Path: "aab(c).txt", Ignored: false},
+	{Patterns: "**/foo", Path: "foo", Ignored: true},
+	{Patterns: "**/foo", Path: "a/b/foo", Ignored: true},
+	{Patterns: "**/foo/bar", Path: "a/foo/bar", Ignored: true},
+	{Patterns: "**/foo/bar", Path: "a/foo/baz/bar", Ignored: false},
+	{Patterns: "src/**", Path: "src/a/b.c", Ignored: true},
+	{Patterns: "src/**", Path: "lib/src/a.c", Ignored: false},
+	{Patterns: "src/**/test", Path: "src/test/", Ignored: true},
+	{Patterns: "src/**/test", Path: "src/a/b/test/", Ignored: true},
+	{Patterns: "src/**/test", Path: "lib/src/a/test/", Ignored: false},
+	{Patterns: "a**b", Path: "axyb", Ignored: true},
+	{Patterns: "a**b", Path: "ax/yb", Ignored: false},
+	{Patterns: "/build", Path: "build/", Ignored: true},
+	{Patterns: "/build", Path: "build/out.o", Ignored: true},
+	{Patterns: "/build", Path: "src/build/", Ignored: false},
+	{Patterns: "/build", Path: "src/build/out.o", Ignored: false},
+	{Patterns: "/*.c", Path: "main.c", Ignored: true},
+	{Patterns: "/*.c", Path: "src/main.c", Ignored: false},
+	{Patterns: `\#file`, Path: "#file", Ignored: true},
+	{Patterns: `\#file`, Path: "file", Ignored: false},
+	{Patterns: `\!important`, Path: "!important", Ignored: true},
+	{Patterns: `file\ name`, Path: "file name", Ignored: true},
+	{Patterns: `file\ name`, Path: "filename", Ignored: false},
+	{Patterns: `\[abc\]`, Path: "[abc]", Ignored: true},
+	{Patterns: `\[abc\]`, Path: "a", Ignored: false},
+	{Patterns: `\*.txt`, Path: "*.txt", Ignored: true},
+	{Patterns: `\*.txt`, Path: "a.txt", Ignored: false},
+	{Patterns: `what\?`, Path: "what?", Ignored: true},
+	{Patterns: `what\?`, Path: "whats", Ignored: false},
+	{Patterns: `[\]]x`, Path: "]x", Ignored: true},
+	{Patterns: "**/foo", Path: "a/foo/", Ignored: true},
+	{Patterns: "**/foo", Path: "a/foo/x.c", Ignored: true},
+	{Patterns: "**/foo/bar", Path: "foo/bar", Ignored: true},
+	{Patterns: "abc/**", Path: "abc/x", Ignored: true},
+	{Patterns: "abc/**", Path: "abc/x/y/z.c", Ignored: true},
+	{Patterns: "abc/**", Path: "x/abc/y", Ignored: false},
+	{Patterns: "a/**/b", Path: "a/b", Ignored: true},
+	{Patterns: "a/**/b", Path: "a/x/b", Ignored: true},
+	{Patterns: "a/**/b", Path: "a/x/y/b", Ignored: true},
+	{Patterns: "a/**/b", Path: "a/xb", Ignored: false},
+	{Patterns: "a/**/b", Path: "x/a/b", Ignored: false},
+	{Patterns: "**/logs", Path: "logs/", Ignored: true},
+	{Patterns: "**/logs", Path: "a/b/logs/today.log", Ignored: true},
+	{Patterns: "logs/**", Path: "logs/today.log", Ignored: true},
+	{Patterns: "**/__pycache__/**", Path: "__pycache__/m.pyc", Ignored: true},
+	{Patterns: "**/__pycache__/**", Path: "pkg/sub/__pycache__/m.pyc", Ignored: true},
+	{Patterns: "**/__pycache__/**", Path: "pkg/__pycache__x/m.pyc", Ignored: false},
+	{Patterns: "/**/foo", Path: "a/b/foo", Ignored: true},
+	{Patterns: "a/**/*.c", Path: "a/main.c", Ignored: true},
+	{Patterns: "a/**/*.c", Path: "a/x/y/main.c", Ignored: true},
+	{Patterns: "a/**/*.c", Path: "b/main.c", Ignored: false},
+	{Patterns: "**/*.log", Path: "x/y.log", Ignored: true},
+	{Patterns: "a/**/", Path: "a/x/", Ignored: true},
+	{Patterns: "a/**/", Path: "a/x", Ignored: false},
+	{Patterns: "**", Path: "x/y.c", Ignored: true},
+	{Patterns: "foo**", Path: "foobar", Ignored: true},
+	{Patterns: "foo**", Path: "a/foobar", Ignored: true},
+	{Patterns: "a/b**", Path: "a/bcd", Ignored: true},
+	{Patterns: "a/b**", Path: "a/b/c", Ignored: true},
+	{Patterns: "**a", Path: "x/ba", Ignored: true},
+	{Patterns: "a/***/b", Path: "a/x/y/b", Ignored: true},
+	{Patterns: "a/**b", Path: "a/xb", Ignored: true},
+	{Patterns: "a/**b", Path: "a/x/yb", Ignored: false},
+	{Patterns: "foo/**/", Path: "foo/", Ignored: false},
+	{Patterns: `\#notes.txt`, Path: "#notes.txt", Ignored: true},
+	{Patterns: "#notes.txt", Path: "#notes.txt", Ignored: false},
+	{Patterns: `\!keep.me`, Path: "!keep.me", Ignored: true},
+	{Patterns: `\!keep.me`, Path: "keep.me", Ignored: false},
+	{Patterns: `file with trailing space\ `, Path: "file with trailing space ", Ignored: true},
+	{Patterns: `file with trailing space\ `, Path: "file with trailing space", Ignored: false},
+	{Patterns: `file with trailing space\   `, Path: "file with trailing space ", Ignored: true},
+	{Patterns: `two spaces \ `, Path: "two spaces  ", Ignored: true},
+	{Patterns: "notes.txt   ", Path: "notes.txt", Ignored: true},
+	{Patterns: "notes.txt\r\n", Path: "notes.txt", Ignored: true},
+	{Patterns: " lead", Path: "lead", Ignored: false},
+	{Patterns: " lead", Path: " lead", Ignored: true},
+	{Patterns: "\tlead", Path: "lead", Ignored: false},
+	{Patterns: "*.c\t", Path: "a.c", Ignored: false},
+	{Patterns: "*.c\t", Path: "a.c\t", Ignored: true},
+	{Patterns: "*.c \t", Path: "a.c", Ignored: false},
+	{Patterns: `notes\\ `, Path: `notes\`, Ignored: true},
+	{Patterns: "build/", Path: "build/", Ignored: true},
+	{Patterns: "build/", Path: "build", Ignored: false},
+	{Patterns: "build/", Path: "src/build/", Ignored: true},
+	{Patterns: "build/", Path: "src/build/main.c", Ignored: true},
+	{Patterns: "build/", Path: "src/build", Ignored: false},
+	{Patterns: "docs/build/", Path: "docs/build/", Ignored: true},
+	{Patterns: "docs/build/", Path: "docs/build/index.md", Ignored: true},
+	{Patterns: "docs/build/", Path: "docs/build", Ignored: false},
+	{Patterns: "docs/build/", Path: "src/docs/build/", Ignored: false},
+	{Patterns: "*.d/", Path: "conf.d", Ignored: false},
+	{Patterns: "*.log\n!keep.log", Path: "keep.log", Ignored: false},
+	{Patterns: "!keep.log\n*.log", Path: "keep.log", Ignored: true},
+	{Patterns: "*.log\n!keep.log\nkeep.log", Path: "keep.log", Ignored: true},
+	{Patterns: "build/\n!build/keep.c", Path: "build/keep.c", Ignored: true},
+	{Patterns: "/*\n!/src/", Path: "src/main.c", Ignored: false},
+	{Patterns: "/*\n!/src/", Path: "lib/main.c", Ignored: true},
+	{Patterns: "foo[\n*.log", Path: "foo[", Ignored: false},
+	{Patterns: "foo[\n*.log", Path: "debug.log", Ignored: true},
+	{Patterns: "foo\\\n*.log", Path: "foo", Ignored: false},
+	{Patterns: "foo\\\n*.log", Path: "debug.log", Ignored: true},
+	{Patterns: "/\n*.log", Path: "debug.log", Ignored: true},
+	{Patterns: "/\n*.log", Path: "src/", Ignored: false},
+	{Patterns: "*.log\n!", Path: "debug.log", Ignored: true},
+	{Patterns: "[z-a]\n*.log", Path: "a", Ignored: false},
+	{Patterns: "[z-a]\n*.log", Path: "debug.log", Ignored: true},
+	{Patterns: "[z-a]", Path: "z", Ignored: true},
+	{Patterns: "[[:nope:]]\n*.log", Path: "debug.log", Ignored: true},
+	{Patterns: "[[:nope:]]\n*.log", Path: "n", Ignored: false},
+	{Patterns: "a[!x]b", Path: "ayb", Ignored: true},
+	{Patterns: "a[!x]b", Path: "a/b", Ignored: false},
+	{Patterns: "a[!-0]b", Path: "a/b", Ignored: false},
+	{Patterns: "a[!-0]b", Path: "a-b", Ignored: false},
+	{Patterns: "a[!-0]b", Path: "axb", Ignored: true},
+	{Patterns: "a[.-0]b", Path: "a/b", Ignored: false},
+	{Patterns: "a[.-0]b", Path: "a0b", Ignored: true},
+	{Patterns: "a[[:punct:]]b", Path: "a/b", Ignored: false},
+	{Patterns: "a[[:punct:]]b", Path: "a-b", Ignored: true},
+	{Patterns: "[[:digit:]]x", Path: "1x", Ignored: true},
+	{Patterns: "[[:digit:]]x", Path: "ax", Ignored: false},
+	{Patterns: "[[:alpha:]]x", Path: "ax", Ignored: true},
+	{Patterns: "[[:alpha:]]x", Path: "1x", Ignored: false},
+	{Patterns: "a[[:space:]]b", Path: "a b", Ignored: true},
+	{Patterns: "a[[:space:]]b", Path: "a_b", Ignored: false},
+	{Patterns: "[![:digit:]]x", Path: "1x", Ignored: false},
+	{Patterns: "[![:digit:]]x", Path: "ax", Ignored: true},
+	{Patterns: "[[:upper:][:digit:]]x", Path: "Bx", Ignored: true},
+	{Patterns: "[a-c-e]x", Path: "-x", Ignored: true},
+	{Patterns: "[a-c-e]x", Path: "dx", Ignored: false},
+	{Patterns: "[a-]x", Path: "-x", Ignored: true},
+	{Patterns: "[]a]x", Path: "]x", Ignored: true},
+	{Patterns: "[[:x]x", Path: "[x", Ignored: true},
+}
+
+// should match every path in the corpus the same way that git does
+func TestParityCorpus(t *testing.T) {
+	for _, tc := range parityCorpus {
+		root := t.TempDir()
+		writeFixture(t, root, tc)
+
+		ig, err := ignore.NewIgnorer(root)
+		require.NoError(t, err)
+
+		actual, err := ig.Match(filepath.Join(root, filepath.FromSlash(tc.Path)), strings.HasSuffix(tc.Path, "/"))
+		require.NoError(t, err)
+		require.Equal(
+			t,
+			tc.Ignored,
+			actual,
+			"pattern %q and path %q",
+			tc.Patterns,
+			tc.Path,
+		)
+	}
+}
+
+// should agree with `git check-ignore` for every case in the corpus. The test is skipped
+// when a git binary is not available.
+func TestParityGitCheckIgnore(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not available")
+	}
+
+	for _, tc := range parityCorpus {
+		root := t.TempDir()
+		runGit(t, root, "init", "--quiet")
+		writeFixture(t, root, tc)
+
+		expected := gitCheckIgnore(t, root, tc.Path)
+		require.Equal(
+			t,
+			expected,
+			tc.Ignored,
+			"corpus disagrees with git for pattern %q and path %q",
+			tc.Patterns,
+			tc.Path,
+		)
+
+		ig, err := ignore.NewIgnorer(root)
+		require.NoError(t, err)
+		actual, err := ig.Match(filepath.Join(root, filepath.FromSlash(tc.Path)), strings.HasSuffix(tc.Path, "/"))
+		require.NoError(t, err)
+		require.Equal(
+			t,
+			expected,
+			actual,
+			"matcher disagrees with git for pattern %q and path %q",
+			tc.Patterns,
+			tc.Path,
+		)
+	}
+}
+
+// writeFixture writes the patterns to a .gitignore file in root and creates the path
+func writeFixture(t *testing.T, root string, tc parityCase) {
+	err := os.WriteFile(filepath.Join(root, ".gitignore"), []byte(tc.Patterns), 0644)
+	require.NoError(t, err)
+
+	path := filepath.Join(root, filepath.FromSlash(tc.Path))
+	if strings.HasSuffix(tc.Path, "/") {
+		require.NoError(t, os.MkdirAll(path, 0755))
+		return
+	}
+
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte{}, 0644))
+}
+
+func gitCheckIgnore(t *testing.T, root, path string) bool {
+	cmd := exec.Command("git", "check-ignore", "--quiet", "--no-index", "--", strings.TrimSuffix(path, "/"))
+	cmd.Dir = root
+	err := cmd.Run()
+	if err == nil {
+		return true
+	}
+
+	exitErr := &exec.ExitError{}
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return false
+	}
+
+	t.Fatalf("git check-ignore failed for %s: %s", path, err)
+	return false
+}
+
+func runGit(t *testing.T, dir string, args ...string) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+}
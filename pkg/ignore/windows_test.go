@@ -0,0 +1,68 @@
+package ignore
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// should compute the slash separated relative path of windows paths on any platform
+func TestRelativeSlashPathWindows(t *testing.T) {
+	testCases := []struct {
+		base, path string
+		rel        string
+		ok         bool
+	}{
+		{base: `C:\repo`, path: `C:\repo\build\out.o`, rel: "build/out.o", ok: true},
+		{base: `C:\repo\`, path: `C:\repo\main.go`, rel: "main.go", ok: true},
+		{base: `c:\repo`, path: `C:\repo\.git\config`, rel: ".git/config", ok: true},
+		{base: `C:\repo`, path: `C:\repo`, ok: false},
+		{base: `C:\repo`, path: `C:\repository\main.go`, ok: false},
+		{base: `C:\repo`, path: `D:\repo\main.go`, ok: false},
+		{base: `C:\repo\pkg`, path: `C:\repo\main.go`, ok: false},
+		{base: `..\..\testdata`, path: `..\..\testdata\exclude\ignore.sh`, rel: "exclude/ignore.sh", ok: true},
+		{base: `.`, path: `src\main.c`, rel: "src/main.c", ok: true},
+	}
+
+	for _, tc := range testCases {
+		rel, ok := relativeSlashPath(toSlash(tc.base, '\\'), toSlash(tc.path, '\\'))
+		require.Equal(t, tc.ok, ok, "%s in %s", tc.path, tc.base)
+		require.Equal(t, tc.rel, rel, "%s in %s", tc.path, tc.base)
+	}
+}
+
+// should match the patterns of an ignore file against windows paths on any platform
+func TestMatcherWindowsPaths(t *testing.T) {
+	patterns, err := ParseIgnorePatterns(strings.NewReader("*.o\nbuild/\n/docs/*.md\n!/docs/keep.md\n"))
+	require.NoError(t, err)
+	group := ExcludeGroup{BasePath: `C:\Users\dev\repo`, Patterns: patterns}
+	m := NewIgnoreMatcher([]ExcludeGroup{group}, false)
+
+	testCases := []struct {
+		path    string
+		isDir   bool
+		ignored bool
+	}{
+		{path: `C:\Users\dev\repo\main.o`, ignored: true},
+		{path: `C:\Users\dev\repo\pkg\lexer\lexer.o`, ignored: true},
+		{path: `C:\Users\dev\repo\build`, isDir: true, ignored: true},
+		{path: `C:\Users\dev\repo\build\main.c`, ignored: true},
+		{path: `C:\Users\dev\repo\docs\guide.md`, ignored: true},
+		{path: `C:\Users\dev\repo\docs\keep.md`, ignored: false},
+		{path: `C:\Users\dev\repo\pkg\docs\guide.md`, ignored: false},
+		{path: `C:\Users\dev\repo\main.c`, ignored: false},
+		{path: `D:\Users\dev\repo\main.o`, ignored: false},
+	}
+
+	for _, tc := range testCases {
+		components := make(map[string][]string)
+		rel, ok := relativeSlashPath(toSlash(group.BasePath, '\\'), toSlash(tc.path, '\\'))
+		if ok {
+			components[group.BasePath] = strings.Split(rel, "/")
+		}
+
+		entry := m.explain(components, tc.isDir)
+		require.Equal(t, tc.ignored, entry != nil && !entry.Pattern.Negate, tc.path)
+	}
+}
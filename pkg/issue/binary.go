@@ -0,0 +1,120 @@
+package issue
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// sniff_len is the number of bytes at the start of a file that are inspected to decide
+// whether it's binary
+const sniff_len = 8192
+
+// binary_threshold is the fraction of the sniffed runes that can be invalid utf-8 or
+// control characters before the file is considered binary
+const binary_threshold = 0.1
+
+// binaryExtensions are the extensions of files that are known to be binary, these files are
+// not read to decide whether they are binary
+var binaryExtensions = map[string]bool{
+	".png":   true,
+	".jpg":   true,
+	".jpeg":  true,
+	".gif":   true,
+	".ico":   true,
+	".zip":   true,
+	".gz":    true,
+	".tar":   true,
+	".pdf":   true,
+	".exe":   true,
+	".dll":   true,
+	".so":    true,
+	".dylib": true,
+	".o":     true,
+	".a":     true,
+	".class": true,
+	".jar":   true,
+	".wasm":  true,
+}
+
+// IsBinaryFile reports whether the file located at path is binary. The file is binary when
+// its extension is a known binary extension or when the first sniff_len bytes are binary,
+// see IsBinary.
+func IsBinaryFile(path string) (bool, error) {
+	if binaryExtensions[strings.ToLower(filepath.Ext(path))] {
+		return true, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+
+	defer file.Close()
+	head := make([]byte, sniff_len)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return false, err
+	}
+
+	return IsBinary(head[:n]), nil
+}
+
+// readSource reads the source code of the file that meta describes from r. Unless
+// includeBinary is set, binary files are reported instead, see IsBinaryFile. Only the
+// sniffed bytes of a binary file are read.
+func readSource(r io.Reader, meta FileMeta, includeBinary bool) (src []byte, binary bool, err error) {
+	if !includeBinary && binaryExtensions[strings.ToLower(filepath.Ext(meta.Name))] {
+		return nil, true, nil
+	}
+
+	buf := bytes.NewBuffer(make([]byte, 0, max(meta.Size, 0)+bytes.MinRead))
+	if _, err := io.CopyN(buf, r, sniff_len); err != nil && err != io.EOF {
+		return nil, false, err
+	}
+
+	if !includeBinary && IsBinary(buf.Bytes()) {
+		return nil, true, nil
+	}
+
+	if _, err := buf.ReadFrom(r); err != nil {
+		return nil, false, err
+	}
+
+	return buf.Bytes(), false, nil
+}
+
+// IsBinary reports whether head, the start of a file, is binary. It is binary when it
+// contains a NUL byte, or when more than a tenth of it is invalid utf-8 or control
+// characters other than whitespace. A rune that is cut off at the end of head is not
+// counted as invalid.
+func IsBinary(head []byte) bool {
+	if len(head) == 0 {
+		return false
+	}
+
+	runes, suspicious := 0, 0
+	for i := 0; i < len(head); {
+		r, size := utf8.DecodeRune(head[i:])
+		if r == utf8.RuneError && size == 1 && !utf8.FullRune(head[i:]) {
+			break
+		}
+
+		switch {
+		case r == 0:
+			return true
+		case r == utf8.RuneError && size == 1:
+			suspicious++
+		case r < 0x20 && !strings.ContainsRune("\t\n\r\f\v\b\x1b", r):
+			suspicious++
+		}
+
+		runes++
+		i += size
+	}
+
+	return float64(suspicious) > float64(runes)*binary_threshold
+}
@@ -0,0 +1,75 @@
+package issue_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/AntoninoAdornetto/issue-summoner/pkg/issue"
+	"github.com/stretchr/testify/require"
+)
+
+// should detect binary content by NUL bytes and invalid utf-8
+func TestIsBinary(t *testing.T) {
+	require.False(t, issue.IsBinary(nil))
+	require.False(t, issue.IsBinary([]byte("// @TODO plain text\n\tindented\r\n")))
+	require.False(t, issue.IsBinary([]byte("// héllo wörld, こんにちは 🎉\n")))
+	require.True(t, issue.IsBinary([]byte("ELF\x00\x01\x02")))
+	require.True(t, issue.IsBinary([]byte{0xff, 0xfe, 0xfd, 0x89, 0x50, 0x4e, 0x47, 'a'}))
+
+	// a multi byte rune that is cut off by the end of the sniffed bytes is not binary
+	emoji := []byte("🎉")
+	head := append(bytes.Repeat([]byte("a"), 10), emoji[:2]...)
+	require.False(t, issue.IsBinary(head))
+
+	// a few invalid bytes in a text file, such as latin-1 in a comment, are tolerated
+	latin := []byte(strings.Repeat("// caf\xe9 menu item\n", 10))
+	require.False(t, issue.IsBinary(latin))
+}
+
+// should detect binary files by extension without reading them
+func TestIsBinaryFile(t *testing.T) {
+	dir := t.TempDir()
+	binary, err := issue.IsBinaryFile(filepath.Join(dir, "missing.PNG"))
+	require.NoError(t, err)
+	require.True(t, binary)
+
+	path := filepath.Join(dir, "main.c")
+	require.NoError(t, os.WriteFile(path, []byte("int main() {}\n"), 0644))
+	binary, err = issue.IsBinaryFile(path)
+	require.NoError(t, err)
+	require.False(t, binary)
+
+	_, err = issue.IsBinaryFile(filepath.Join(dir, "missing.c"))
+	require.Error(t, err)
+}
+
+// compares sniffing a small file before reading it, which opens the file twice, with
+// reading the file once and sniffing its content, which is what Walk does for files that
+// are no larger than the sniffed bytes
+func BenchmarkReadSmallFile(b *testing.B) {
+	path := filepath.Join(b.TempDir(), "main.c")
+	src := strings.Repeat("// @TODO benchmark the fast path\nint x = 1;\n", 50)
+	require.NoError(b, os.WriteFile(path, []byte(src), 0644))
+
+	b.Run("sniff then read", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			binary, err := issue.IsBinaryFile(path)
+			require.NoError(b, err)
+			require.False(b, binary)
+
+			_, err = os.ReadFile(path)
+			require.NoError(b, err)
+		}
+	})
+
+	b.Run("read once", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			data, err := os.ReadFile(path)
+			require.NoError(b, err)
+			require.False(b, issue.IsBinary(data))
+		}
+	})
+}
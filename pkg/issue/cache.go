@@ -0,0 +1,101 @@
+package issue
+
+import (
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// scan_cache_version is bumped whenever a change to the lexers would produce different
+// issues for the same source file, so that stale cache files are discarded
+const scan_cache_version = 2
+
+// ScanCache maps the path of a source file to the issues that were found the last time
+// the file was scanned. A cached entry is used while the modification time and size of
+// the file are unchanged. The cache is discarded when it was written for a different
+// annotation. Entries for files that no longer exist are dropped on Save.
+type ScanCache struct {
+	Version    int                   `json:"version"`
+	Annotation string                `json:"annotation"`
+	Files      map[string]CachedFile `json:"files"`
+	path       string
+	seen       map[string]bool
+}
+
+type CachedFile struct {
+	ModTime time.Time `json:"mod_time"`
+	Size    int64     `json:"size"`
+	Issues  []Issue   `json:"issues"`
+}
+
+// LoadScanCache reads the cache file located at path. An empty cache is returned when
+// the file does not exist, can't be decoded, or was written for a different annotation.
+func LoadScanCache(path, annotation string) *ScanCache {
+	cache := &ScanCache{
+		Version:    scan_cache_version,
+		Annotation: annotation,
+		Files:      make(map[string]CachedFile),
+		path:       path,
+		seen:       make(map[string]bool),
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cache
+	}
+
+	stored := ScanCache{}
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return cache
+	}
+
+	if stored.Version == scan_cache_version && stored.Annotation == annotation &&
+		stored.Files != nil {
+		cache.Files = stored.Files
+	}
+
+	return cache
+}
+
+// Lookup returns the cached issues for the file when it has not been modified since
+// it was cached
+func (c *ScanCache) Lookup(path string, info fs.FileInfo) ([]Issue, bool) {
+	c.seen[path] = true
+	entry, ok := c.Files[path]
+	if !ok || entry.Size != info.Size() || !entry.ModTime.Equal(info.ModTime()) {
+		return nil, false
+	}
+	return entry.Issues, true
+}
+
+func (c *ScanCache) Store(path string, info fs.FileInfo, issues []Issue) {
+	c.seen[path] = true
+	c.Files[path] = CachedFile{ModTime: info.ModTime(), Size: info.Size(), Issues: issues}
+}
+
+// Save writes the cache file. The entries of files that were not visited are kept, since
+// a walk that was canceled or that didn't use the cache leaves files unvisited, unless
+// the file no longer exists.
+func (c *ScanCache) Save() error {
+	for path := range c.Files {
+		if c.seen[path] {
+			continue
+		}
+		if _, err := os.Lstat(path); os.IsNotExist(err) {
+			delete(c.Files, path)
+		}
+	}
+
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(c.path, data, 0644)
+}
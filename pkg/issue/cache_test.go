@@ -0,0 +1,135 @@
+package issue_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/AntoninoAdornetto/issue-summoner/pkg/issue"
+	"github.com/stretchr/testify/require"
+)
+
+// walkWithCache walks root with the cache file located at cachePath and saves the cache
+func walkWithCache(t *testing.T, root, cachePath, annotation string) []issue.Issue {
+	im, err := issue.NewIssueManager(issue.PENDING_ISSUE, annotation)
+	require.NoError(t, err)
+
+	cache := issue.LoadScanCache(cachePath, annotation)
+	_, err = im.Walk(issue.WalkParams{Root: root, Cache: cache})
+	require.NoError(t, err)
+	require.NoError(t, cache.Save())
+	return im.GetIssues()
+}
+
+// rewrite changes the contents of the file while keeping its size and modification time
+func rewrite(t *testing.T, path, content string) {
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Len(t, content, int(info.Size()))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	require.NoError(t, os.Chtimes(path, info.ModTime(), info.ModTime()))
+}
+
+// should reuse the cached issues when the size and modification time of a file are
+// unchanged. The contents are changed behind the caches back to prove it was used.
+func TestWalkCacheHit(t *testing.T) {
+	root := t.TempDir()
+	cachePath := filepath.Join(t.TempDir(), "scan.json")
+	writeFiles(t, root, map[string]string{"main.c": "// @TEST_TODO first title\n"})
+
+	issues := walkWithCache(t, root, cachePath, annotation)
+	require.Len(t, issues, 1)
+	require.Equal(t, "first title", issues[0].Title)
+	require.FileExists(t, cachePath)
+
+	rewrite(t, filepath.Join(root, "main.c"), "// @TEST_TODO other title\n")
+	issues = walkWithCache(t, root, cachePath, annotation)
+	require.Len(t, issues, 1)
+	require.Equal(t, "first title", issues[0].Title)
+}
+
+// should scan the file again when it has been modified
+func TestWalkCacheModified(t *testing.T) {
+	root := t.TempDir()
+	cachePath := filepath.Join(t.TempDir(), "scan.json")
+	path := filepath.Join(root, "main.c")
+	writeFiles(t, root, map[string]string{"main.c": "// @TEST_TODO first title\n"})
+
+	issues := walkWithCache(t, root, cachePath, annotation)
+	require.Equal(t, "first title", issues[0].Title)
+
+	require.NoError(t, os.WriteFile(path, []byte("// @TEST_TODO a longer second title\n"), 0644))
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(path, later, later))
+
+	issues = walkWithCache(t, root, cachePath, annotation)
+	require.Len(t, issues, 1)
+	require.Equal(t, "a longer second title", issues[0].Title)
+}
+
+// should discard the cache when the annotation changes
+func TestWalkCacheAnnotationChanged(t *testing.T) {
+	root := t.TempDir()
+	cachePath := filepath.Join(t.TempDir(), "scan.json")
+	writeFiles(t, root, map[string]string{
+		"main.c": "// @TEST_TODO todo title\n// @TEST_FIX fix title\n",
+	})
+
+	issues := walkWithCache(t, root, cachePath, annotation)
+	require.Len(t, issues, 1)
+	require.Equal(t, "todo title", issues[0].Title)
+
+	issues = walkWithCache(t, root, cachePath, "@TEST_FIX")
+	require.Len(t, issues, 1)
+	require.Equal(t, "fix title", issues[0].Title)
+}
+
+// should drop entries of files that no longer exist when the cache is saved
+func TestWalkCachePrunesDeletedFiles(t *testing.T) {
+	root := t.TempDir()
+	cachePath := filepath.Join(t.TempDir(), "scan.json")
+	writeFiles(t, root, map[string]string{
+		"main.c": "// @TEST_TODO main\n",
+		"old.c":  "// @TEST_TODO old\n",
+	})
+
+	walkWithCache(t, root, cachePath, annotation)
+	require.NoError(t, os.Remove(filepath.Join(root, "old.c")))
+	walkWithCache(t, root, cachePath, annotation)
+
+	cache := issue.LoadScanCache(cachePath, annotation)
+	require.Len(t, cache.Files, 1)
+	require.Contains(t, cache.Files, filepath.Join(root, "main.c"))
+}
+
+// should keep the entries of files that a walk didn't visit, such as a processed walk,
+// which doesn't use the cache, or a canceled walk
+func TestWalkCacheKeepsUnvisitedFiles(t *testing.T) {
+	root := t.TempDir()
+	cachePath := filepath.Join(t.TempDir(), "scan.json")
+	writeFiles(t, root, map[string]string{
+		"main.c": "// @TEST_TODO main\n",
+		"util.c": "// @TEST_TODO util\n",
+	})
+	walkWithCache(t, root, cachePath, annotation)
+
+	im, err := issue.NewIssueManager(issue.PROCESSED_ISSUE, annotation)
+	require.NoError(t, err)
+	cache := issue.LoadScanCache(cachePath, annotation)
+	_, err = im.Walk(issue.WalkParams{Root: root, Cache: cache})
+	require.NoError(t, err)
+	require.NoError(t, cache.Save())
+	require.Len(t, issue.LoadScanCache(cachePath, annotation).Files, 2)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	im, err = issue.NewIssueManager(issue.PENDING_ISSUE, annotation)
+	require.NoError(t, err)
+	cache = issue.LoadScanCache(cachePath, annotation)
+	_, err = im.Walk(issue.WalkParams{Root: root, Cache: cache, Ctx: ctx})
+	require.ErrorIs(t, err, context.Canceled)
+	require.NoError(t, cache.Save())
+	require.Len(t, issue.LoadScanCache(cachePath, annotation).Files, 2)
+}
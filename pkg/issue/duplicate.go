@@ -0,0 +1,114 @@
+package issue
+
+import "strings"
+
+// DEFAULT_SIMILARITY is the title similarity, see TitleSimilarity, at which two titles are
+// considered to describe the same issue
+const DEFAULT_SIMILARITY = 0.9
+
+// FindDuplicates groups issues that share the same title once the titles have been
+// normalized. It's common for the same annotation to be copied and pasted into multiple
+// files, which would create duplicate issues when reported. Only groups with more than
+// one issue are returned and the groups are ordered by the first occurrence of each title.
+func FindDuplicates(issues []Issue) [][]Issue {
+	groups := make(map[string][]Issue)
+	order := make([]string, 0)
+
+	for _, is := range issues {
+		key := NormalizeTitle(is.Title, false)
+		if key == "" {
+			continue
+		}
+
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], is)
+	}
+
+	duplicates := make([][]Issue, 0)
+	for _, key := range order {
+		if len(groups[key]) > 1 {
+			duplicates = append(duplicates, groups[key])
+		}
+	}
+
+	return duplicates
+}
+
+// NormalizeTitle collapses all whitespace into single spaces and lowercases the title
+// unless caseSensitive is true
+func NormalizeTitle(title string, caseSensitive bool) string {
+	title = strings.Join(strings.Fields(title), " ")
+	if caseSensitive {
+		return title
+	}
+	return strings.ToLower(title)
+}
+
+// TitleSet contains normalized titles, such as the titles of the open issues of a
+// repository, so that issues can be skipped when an issue with the same title exists
+type TitleSet struct {
+	caseSensitive bool
+	titles        map[string]bool
+}
+
+func NewTitleSet(titles []string, caseSensitive bool) TitleSet {
+	set := TitleSet{caseSensitive: caseSensitive, titles: make(map[string]bool, len(titles))}
+	for _, title := range titles {
+		set.titles[NormalizeTitle(title, caseSensitive)] = true
+	}
+	return set
+}
+
+func (set TitleSet) Contains(title string) bool {
+	return set.titles[NormalizeTitle(title, set.caseSensitive)]
+}
+
+// TitleSimilarity compares the normalized titles and returns a score between 0 and 1, where
+// 1 means that the titles are equal. The score is the edit distance between the titles
+// divided by the length of the longer title, subtracted from 1.
+func TitleSimilarity(a, b string) float64 {
+	ra := []rune(NormalizeTitle(a, false))
+	rb := []rune(NormalizeTitle(b, false))
+	longest := max(len(ra), len(rb))
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(editDistance(ra, rb))/float64(longest)
+}
+
+// MostSimilar returns the index of the title, in titles, that is the most similar to title
+// along with its score. The index is -1 when titles is empty.
+func MostSimilar(title string, titles []string) (int, float64) {
+	index, score := -1, 0.0
+	for i, candidate := range titles {
+		if s := TitleSimilarity(title, candidate); index == -1 || s > score {
+			index, score = i, s
+		}
+	}
+	return index, score
+}
+
+// editDistance is the levenshtein distance between a and b
+func editDistance(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
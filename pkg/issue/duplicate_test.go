@@ -0,0 +1,68 @@
+package issue_test
+
+import (
+	"testing"
+
+	"github.com/AntoninoAdornetto/issue-summoner/pkg/issue"
+	"github.com/stretchr/testify/require"
+)
+
+// should group issues from different files that share the same normalized title
+func TestFindDuplicates(t *testing.T) {
+	im, err := issue.NewIssueManager(issue.PENDING_ISSUE, annotation)
+	require.NoError(t, err)
+
+	first := []byte("int main() {\n  // @TEST_TODO  Handle the error case\n  return 0;\n}\n")
+	second := []byte("void run() {\n  // @TEST_TODO handle the   error case\n}\n")
+	third := []byte("void stop() {\n  // @TEST_TODO a unique annotation\n}\n")
+
+	require.NoError(t, im.Scan(first, "first.c"))
+	require.NoError(t, im.Scan(second, "second.c"))
+	require.NoError(t, im.Scan(third, "third.c"))
+
+	duplicates := issue.FindDuplicates(im.GetIssues())
+	require.Len(t, duplicates, 1)
+	require.Len(t, duplicates[0], 2)
+	require.Equal(t, "first.c", duplicates[0][0].FilePath)
+	require.Equal(t, "second.c", duplicates[0][1].FilePath)
+}
+
+// should return an empty slice when there are no duplicate titles
+func TestFindDuplicatesNone(t *testing.T) {
+	issues := []issue.Issue{{Title: "one"}, {Title: "two"}, {Title: ""}, {Title: " "}}
+	require.Empty(t, issue.FindDuplicates(issues))
+}
+
+// should match titles after normalizing whitespace, and case unless case sensitive
+func TestTitleSet(t *testing.T) {
+	open := []string{"Fix  the parser", "Add retries"}
+
+	set := issue.NewTitleSet(open, false)
+	require.True(t, set.Contains("fix the parser"))
+	require.True(t, set.Contains(" Add\tretries "))
+	require.False(t, set.Contains("Add retries to the client"))
+
+	set = issue.NewTitleSet(open, true)
+	require.True(t, set.Contains("Fix the parser"))
+	require.False(t, set.Contains("fix the parser"))
+}
+
+// should score titles by their edit distance after normalizing whitespace and case
+func TestTitleSimilarity(t *testing.T) {
+	require.Equal(t, 1.0, issue.TitleSimilarity("Fix  the parser", "fix the parser"))
+	require.Equal(t, 1.0, issue.TitleSimilarity("", " "))
+	require.Equal(t, 0.0, issue.TitleSimilarity("abc", "xyz"))
+	require.InDelta(t, 11.0/12, issue.TitleSimilarity("add retries", "add retries."), 0.001)
+	require.Less(t, issue.TitleSimilarity("add retries", "remove the cache"), 0.5)
+}
+
+// should return the index and score of the most similar title
+func TestMostSimilar(t *testing.T) {
+	i, score := issue.MostSimilar("add retries", nil)
+	require.Equal(t, -1, i)
+	require.Equal(t, 0.0, score)
+
+	i, score = issue.MostSimilar("add retries", []string{"remove the cache", "Add retries.", "add"})
+	require.Equal(t, 1, i)
+	require.Greater(t, score, 0.9)
+}
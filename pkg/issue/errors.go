@@ -0,0 +1,46 @@
+package issue
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FileError is an error that occurred while a single file or directory was walked, such as
+// a file that can't be read. The walk moves on to the next file instead of stopping.
+type FileError struct {
+	Path string
+	Err  error
+}
+
+func (e *FileError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Err)
+}
+
+func (e *FileError) Unwrap() error {
+	return e.Err
+}
+
+// FileErrors are the errors of every file that could not be walked. Walk returns them once
+// the rest of the files were scanned, so the issues that were located are still available.
+// Use errors.As to tell them apart from an error that stopped the walk.
+type FileErrors []*FileError
+
+func (errs FileErrors) Error() string {
+	if len(errs) == 1 {
+		return errs[0].Error()
+	}
+
+	msgs := make([]string, 0, len(errs))
+	for _, err := range errs {
+		msgs = append(msgs, err.Error())
+	}
+	return fmt.Sprintf("%d files could not be scanned:\n%s", len(errs), strings.Join(msgs, "\n"))
+}
+
+func (errs FileErrors) Unwrap() []error {
+	unwrapped := make([]error, 0, len(errs))
+	for _, err := range errs {
+		unwrapped = append(unwrapped, err)
+	}
+	return unwrapped
+}
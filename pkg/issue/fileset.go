@@ -0,0 +1,45 @@
+package issue
+
+import (
+	"path"
+	"path/filepath"
+)
+
+// FileSet restricts a walk to a set of files, such as the files that changed since a git
+// ref. Directories that don't contain any of the files are not traversed.
+type FileSet struct {
+	files map[string]bool
+	dirs  map[string]bool
+}
+
+// NewFileSet creates a set of the files located at paths, which are relative to the root of
+// the walk
+func NewFileSet(paths []string) *FileSet {
+	set := &FileSet{files: make(map[string]bool), dirs: make(map[string]bool)}
+	for _, p := range paths {
+		rel := path.Clean(filepath.ToSlash(p))
+		set.files[rel] = true
+		for dir := path.Dir(rel); dir != "."; dir = path.Dir(dir) {
+			set.dirs[dir] = true
+		}
+	}
+	return set
+}
+
+// Len returns the number of files in the set
+func (set *FileSet) Len() int {
+	return len(set.files)
+}
+
+// Contains reports whether the file located at rel, a path relative to the root of the
+// walk, is in the set
+func (set *FileSet) Contains(rel string) bool {
+	return set.files[filepath.ToSlash(rel)]
+}
+
+// ContainsDir reports whether the directory located at rel, a path relative to the root of
+// the walk, contains a file of the set
+func (set *FileSet) ContainsDir(rel string) bool {
+	rel = filepath.ToSlash(rel)
+	return rel == "." || set.dirs[rel]
+}
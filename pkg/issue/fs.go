@@ -0,0 +1,68 @@
+package issue
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// FileMeta describes a file that is scanned from an io.Reader. Name is the path that the
+// issues are reported with, its extension selects the lexer. Size is the number of bytes
+// of the file, it's only used to size the buffer that the file is read into and may be 0
+// when it's not known.
+type FileMeta struct {
+	Name string
+	Size int64
+	Mode fs.FileMode
+}
+
+// fileMeta describes the file located at path
+func fileMeta(path string, info fs.FileInfo) FileMeta {
+	return FileMeta{Name: path, Size: info.Size(), Mode: info.Mode()}
+}
+
+// osFS is the file system of the OS. Unlike os.DirFS, the paths are the paths of the OS,
+// which may be absolute or relative to the working directory, so that Walk reports the
+// same paths as before it traversed an fs.FS.
+type osFS struct{}
+
+func (osFS) Open(name string) (fs.File, error) {
+	return os.Open(name)
+}
+
+func (osFS) Stat(name string) (fs.FileInfo, error) {
+	return os.Stat(name)
+}
+
+func (osFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return os.ReadDir(name)
+}
+
+// walkDir walks the file tree of fsys rooted at root. The tree of the OS is walked with
+// filepath.WalkDir, so that the paths use the separator of the OS.
+func walkDir(fsys fs.FS, root string, fn fs.WalkDirFunc) error {
+	if _, ok := fsys.(osFS); ok {
+		return filepath.WalkDir(root, fn)
+	}
+	return fs.WalkDir(fsys, root, fn)
+}
+
+// walkSymlinkDir walks the directory that the symlink located at path points to. The paths
+// beneath it are still relative to the symlink.
+func walkSymlinkDir(fsys fs.FS, path string, fn fs.WalkDirFunc) error {
+	if _, ok := fsys.(osFS); ok {
+		// the trailing separator makes WalkDir resolve the symlink
+		return filepath.WalkDir(path+string(filepath.Separator), fn)
+	}
+	return fs.WalkDir(fsys, path, fn)
+}
+
+// evalSymlinks returns the real path of the directory located at path. io/fs can't read
+// symlinks, so the paths of any file system other than the OS are already real.
+func evalSymlinks(fsys fs.FS, name string) (string, error) {
+	if _, ok := fsys.(osFS); ok {
+		return filepath.EvalSymlinks(name)
+	}
+	return path.Clean(name), nil
+}
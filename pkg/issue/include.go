@@ -0,0 +1,55 @@
+package issue
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/AntoninoAdornetto/issue-summoner/pkg/ignore"
+)
+
+// IncludeFilter restricts a walk to the files that match at least one of its patterns.
+// The patterns use the syntax of ignore files and are relative to the root of the walk. A
+// pattern that matches a directory includes every file beneath it, such as cmd/. Include
+// patterns are checked after the ignore files, so a file that is ignored is never scanned.
+// Filtered is the number of files that the walk skipped since they matched no pattern.
+type IncludeFilter struct {
+	Patterns []ignore.IgnorePattern
+	Filtered int
+}
+
+// NewIncludeFilter parses the include patterns. Negated patterns are rejected since a file
+// that should not be scanned belongs in an ignore file.
+func NewIncludeFilter(patterns []string) (*IncludeFilter, error) {
+	filter := &IncludeFilter{}
+	for _, line := range patterns {
+		pattern, err := ignore.NewIgnorePattern(line)
+		if err != nil {
+			return nil, fmt.Errorf("include pattern: %w", err)
+		}
+
+		if pattern.Negate {
+			return nil, fmt.Errorf("invalid include pattern %q: include patterns can't be negated", line)
+		}
+
+		filter.Patterns = append(filter.Patterns, pattern)
+	}
+
+	return filter, nil
+}
+
+// Match reports whether the file located at rel, a slash separated path relative to the
+// root of the walk, or one of its parent directories matches an include pattern
+func (f *IncludeFilter) Match(rel string, ignoreCase bool) bool {
+	components := strings.Split(rel, "/")
+	for i := range components {
+		path := strings.Join(components[:i+1], "/")
+		isDir := i < len(components)-1
+		for _, pattern := range f.Patterns {
+			if ignoreCase && pattern.MatchFold(path, isDir) || pattern.Match(path, isDir) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,31 @@
+package issue_test
+
+import (
+	"testing"
+
+	"github.com/AntoninoAdornetto/issue-summoner/pkg/issue"
+	"github.com/stretchr/testify/require"
+)
+
+// should match files that match a pattern or are beneath a directory that matches one
+func TestIncludeFilterMatch(t *testing.T) {
+	filter, err := issue.NewIncludeFilter([]string{"**/*.go", "cmd/**", "docs/"})
+	require.NoError(t, err)
+
+	require.True(t, filter.Match("main.go", false))
+	require.True(t, filter.Match("pkg/lexer/lexer.go", false))
+	require.True(t, filter.Match("cmd/scan.c", false))
+	require.True(t, filter.Match("docs/guide/index.js", false))
+	require.False(t, filter.Match("pkg/lexer/lexer.c", false))
+	require.False(t, filter.Match("MAIN.GO", false))
+	require.True(t, filter.Match("MAIN.GO", true))
+}
+
+// should reject negated and invalid include patterns
+func TestNewIncludeFilterInvalid(t *testing.T) {
+	_, err := issue.NewIncludeFilter([]string{"!*.go"})
+	require.Error(t, err)
+
+	_, err = issue.NewIncludeFilter([]string{"foo\\"})
+	require.Error(t, err)
+}
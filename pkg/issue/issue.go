@@ -2,9 +2,12 @@ package issue
 
 import (
 	"bytes"
+	"context"
 	"errors"
+	"io/fs"
 	"runtime"
 	"text/template"
+	"time"
 )
 
 const (
@@ -12,23 +15,96 @@ const (
 	PROCESSED_ISSUE = "processed"
 )
 
+// DEFAULT_MAX_FILE_SIZE is the size, in bytes, of the largest file that the scan and
+// report commands scan by default. Larger files tend to be generated, such as database
+// dumps and minified bundles.
+const DEFAULT_MAX_FILE_SIZE = 5 << 20
+
+// Issue is an annotated comment that was located while scanning a source code file.
+// LineNumber is the line that the comment starts on and EndLineNumber is the line that
+// the comment ends on, both are relative to the start of the file.
 type Issue struct {
-	ID          string
-	Title       string
-	Description string
-	FilePath    string
-	FileName    string
-	LineNumber  int
-	Environment string
-	StartIndex  int
-	EndIndex    int
+	ID            string
+	Annotation    string
+	Title         string
+	Description   string
+	FilePath      string
+	FileName      string
+	LineNumber    int
+	EndLineNumber int
+	Environment   string
+	StartIndex    int
+	EndIndex      int
+}
+
+// WalkParams configures how the project directory is traversed. Root is the directory
+// to walk. IssueIgnorePath is an optional path to an ignore file, using gitignore
+// syntax, for files that should not be scanned for issues. When it is not provided, the
+// .issueignore and .issuesummonerignore files in Root are used if they exist.
+// ExcludesFile is the global excludes file of git, core.excludesFile, it's skipped when
+// empty. Files that have not changed since they were cached are not scanned again when
+// Cache is set. IgnoreCase matches the patterns of the ignore files without regard to
+// case, see ignore.DEFAULT_IGNORE_CASE. The directories in ignore.DefaultIgnores are
+// skipped unless NoDefaultIgnores is set. Only the files that match Include are scanned
+// when it's set. Binary files are skipped unless IncludeBinary is set, see
+// IsBinaryFile. Only the files in Files are scanned when it's set. Files larger than
+// MaxFileSize, in bytes, are skipped unless it's 0. Symlinks are skipped unless
+// FollowSymlinks is set, a directory is only walked once when they are followed. Hidden
+// files and directories, whose name starts with a dot, are skipped unless ScanHidden is
+// set or their name is in AllowHidden, see DefaultAllowHidden. The .git, .hg and .svn
+// directories are always skipped. The files that are visited are counted in Stats when
+// it's set. The walk stops before the next file or directory once Ctx is done, Walk
+// then returns the error of Ctx, such as context.Canceled, and the issues that were
+// located so far are kept. A file or directory that can't be walked, such as a file
+// without read permission, is skipped and Walk returns FileErrors once the rest of the
+// files were scanned. Progress is called after each file that is counted as scanned in
+// Stats, with the number of files that were scanned so far and the path of the file.
+// Calls are never made concurrently, so Progress does not need to synchronize.
+// OnProgress is called with a ProgressEvent, at most once every ProgressInterval,
+// DEFAULT_PROGRESS_INTERVAL when it's 0, and once more with the final counts before
+// Walk returns. Like Progress, it's never called concurrently or after Walk returns.
+// Files are read and scanned by Workers goroutines, GOMAXPROCS when it's 0, while the
+// directories are traversed. The issues are ordered by path, line and annotation, see
+// CompareIssues, once the walk is done, so the order doesn't depend on which worker
+// finished first. The project is walked in FS when it's set, Root and the paths of the
+// issues are then the slash separated paths of FS, such as ".". The file system of the
+// OS is walked otherwise. IssueIgnorePath and ExcludesFile are read from FS as well.
+// Only the files that are at most MaxDepth directories beneath Root are scanned when
+// it's set, 0 scans the files in Root itself and a negative depth is unlimited. Deeper
+// directories are pruned without being matched against the ignore patterns.
+type WalkParams struct {
+	Root             string
+	FS               fs.FS
+	IssueIgnorePath  string
+	ExcludesFile     string
+	Cache            *ScanCache
+	IgnoreCase       bool
+	NoDefaultIgnores bool
+	Include          *IncludeFilter
+	Stats            *WalkStats
+	IncludeBinary    bool
+	Files            *FileSet
+	MaxFileSize      int64
+	FollowSymlinks   bool
+	ScanHidden       bool
+	AllowHidden      []string
+	Ctx              context.Context
+	Progress         func(processed int, path string)
+	OnProgress       func(event ProgressEvent)
+	ProgressInterval time.Duration
+	Workers          int
+	MaxDepth         *int
 }
 
+// DefaultAllowHidden are the names of the hidden files and directories that are walked
+// by default, since they often contain annotations that are worth reporting
+var DefaultAllowHidden = []string{".github"}
+
 type IssueManager interface {
 	GetIssues() []Issue
 	Scan(src []byte, path string) error
-	Walk(root string) (int, error)
-	WriteIssueID(id int64, issueIndex int) error
+	Walk(params WalkParams) (int, error)
+	WriteIssueRef(ref string, issueIndex int) error
 }
 
 // NewIssueManager will return either a PendingIssue struct or ProcessedIssue struct
@@ -50,9 +126,18 @@ func NewIssueManager(issueType string, annotation string) (IssueManager, error)
 	}
 }
 
-func (issue *Issue) ExecuteIssueTemplate(tmpl *template.Template) ([]byte, error) {
+// templateData exposes the fields of the issue, and the source of the annotation as
+// .Source, to the issue template
+type templateData struct {
+	*Issue
+	Source Source
+}
+
+// ExecuteIssueTemplate renders the body of the issue. The snippet and permalink sections
+// of the template are left out when they are empty in source.
+func (issue *Issue) ExecuteIssueTemplate(tmpl *template.Template, source Source) ([]byte, error) {
 	buf := bytes.Buffer{}
 	issue.Environment = runtime.GOOS
-	err := tmpl.Execute(&buf, issue)
+	err := tmpl.Execute(&buf, templateData{Issue: issue, Source: source})
 	return buf.Bytes(), err
 }
@@ -0,0 +1,62 @@
+package issue
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// MARKER_TOOL identifies the issues that were created by issue-summoner
+const MARKER_TOOL = "issue-summoner"
+
+var markerRe = regexp.MustCompile(
+	`<!--\s*` + MARKER_TOOL + `\s+fingerprint=([0-9a-f]+)(?:\s+version=(\S+))?\s*-->`,
+)
+
+// Marker is embedded in the body of a reported issue, as a hidden html comment, so that
+// the issue can be matched with its annotation later on
+type Marker struct {
+	Fingerprint string
+	Version     string
+}
+
+func (m Marker) String() string {
+	return fmt.Sprintf("<!-- %s fingerprint=%s version=%s -->", MARKER_TOOL, m.Fingerprint, m.Version)
+}
+
+// Fingerprint identifies an annotation by the path of its file, relative to root, and its
+// text. The line number is left out so that moving code around doesn't change it. Runs of
+// whitespace in the text are collapsed, so reformatting a comment doesn't change it either.
+func Fingerprint(is Issue, root string) string {
+	path := is.FilePath
+	if rel, err := filepath.Rel(root, path); err == nil {
+		path = rel
+	}
+
+	title, desc := strings.Join(strings.Fields(is.Title), " "), strings.Join(strings.Fields(is.Description), " ")
+	h := sha256.New()
+	for _, part := range []string{filepath.ToSlash(path), is.Annotation, title, desc} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// AppendMarker appends the marker to the end of the issue body
+func AppendMarker(body string, m Marker) string {
+	return strings.TrimRight(body, "\n") + "\n\n" + m.String() + "\n"
+}
+
+// ParseMarker returns the marker in the issue body. Issues that were not created by
+// issue-summoner, or were created before markers were introduced, don't have one.
+func ParseMarker(body string) (Marker, bool) {
+	match := markerRe.FindStringSubmatch(body)
+	if match == nil {
+		return Marker{}, false
+	}
+	return Marker{Fingerprint: match[1], Version: match[2]}, true
+}
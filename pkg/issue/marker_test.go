@@ -0,0 +1,61 @@
+package issue_test
+
+import (
+	"testing"
+
+	"github.com/AntoninoAdornetto/issue-summoner/pkg/issue"
+	"github.com/stretchr/testify/require"
+)
+
+// should ignore the line number and the location of the project
+func TestFingerprint(t *testing.T) {
+	is := issue.Issue{
+		Annotation:  "@TODO",
+		Title:       "add retries",
+		Description: "requests fail on flaky networks",
+		FilePath:    "/home/a/project/pkg/scm/github.go",
+		LineNumber:  42,
+	}
+
+	fingerprint := issue.Fingerprint(is, "/home/a/project")
+	require.Len(t, fingerprint, 16)
+
+	moved := is
+	moved.FilePath = "/tmp/checkout/pkg/scm/github.go"
+	moved.LineNumber = 90
+	require.Equal(t, fingerprint, issue.Fingerprint(moved, "/tmp/checkout"))
+
+	renamed := is
+	renamed.FilePath = "/home/a/project/pkg/scm/gitlab.go"
+	require.NotEqual(t, fingerprint, issue.Fingerprint(renamed, "/home/a/project"))
+
+	edited := is
+	edited.Title = "add retries with backoff"
+	require.NotEqual(t, fingerprint, issue.Fingerprint(edited, "/home/a/project"))
+
+	wrapped := is
+	wrapped.Description = "requests fail\non  flaky networks"
+	require.Equal(t, fingerprint, issue.Fingerprint(wrapped, "/home/a/project"))
+}
+
+// should parse the marker that was appended to the body
+func TestParseMarker(t *testing.T) {
+	marker := issue.Marker{Fingerprint: "0123456789abcdef", Version: "v1.2.0"}
+	body := issue.AppendMarker("### Description\nsome text\n", marker)
+	require.Equal(
+		t,
+		"### Description\nsome text\n\n<!-- issue-summoner fingerprint=0123456789abcdef version=v1.2.0 -->\n",
+		body,
+	)
+
+	parsed, ok := issue.ParseMarker(body)
+	require.True(t, ok)
+	require.Equal(t, marker, parsed)
+
+	parsed, ok = issue.ParseMarker("<!--issue-summoner fingerprint=abc-->")
+	require.True(t, ok)
+	require.Equal(t, issue.Marker{Fingerprint: "abc"}, parsed)
+
+	_, ok = issue.ParseMarker("an issue that was created by hand")
+	require.False(t, ok)
+}
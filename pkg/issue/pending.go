@@ -2,15 +2,19 @@ package issue
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
+	"runtime"
+	"sort"
 	"strings"
+	"sync"
 
-	ignore "github.com/AntoninoAdornetto/go-gitignore"
+	"github.com/AntoninoAdornetto/issue-summoner/pkg/ignore"
 	"github.com/AntoninoAdornetto/issue-summoner/pkg/lexer"
 )
 
@@ -19,25 +23,159 @@ type PendingIssue struct {
 	Issues     []Issue
 }
 
-func (pi *PendingIssue) Walk(root string) (int, error) {
+func (pi *PendingIssue) Walk(params WalkParams) (int, error) {
 	n := 0
-	ignorer, err := ignore.NewIgnorer(root)
+	root := params.Root
+	start := len(pi.Issues)
+	fsys := params.FS
+	if fsys == nil {
+		fsys = osFS{}
+	}
+
+	ignorer, err := NewWalkIgnorer(params)
 	if err != nil {
 		return n, err
 	}
 
-	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+	// the real paths of the directories that were walked, so that a symlink that points to
+	// one of them is not followed again
+	visited := make(map[string]bool)
+
+	// a file or directory that can't be walked is recorded and skipped, so that a single
+	// unreadable file doesn't prevent the rest of the project from being scanned
+	fileErrs := make(FileErrors, 0)
+	fail := func(path string, d fs.DirEntry, err error) error {
+		fileErrs = append(fileErrs, &FileError{Path: path, Err: err})
+		if d != nil && d.IsDir() {
+			return filepath.SkipDir
+		}
+		return nil
+	}
+
+	// the files are counted even when Stats is not set, since OnProgress reports them. The
+	// counts of earlier walks that share Stats are left out of the events.
+	stats := params.Stats
+	if stats == nil {
+		stats = &WalkStats{}
+	}
+	scanned, skipped := stats.Scanned, stats.Skipped
+
+	maxDepth := -1
+	if params.MaxDepth != nil {
+		maxDepth = *params.MaxDepth
+	}
+
+	var reporter *progressReporter
+	if params.OnProgress != nil {
+		reporter = newProgressReporter(params.OnProgress, params.ProgressInterval)
+	}
+
+	// processed is the number of files that were scanned, it's passed to Progress
+	processed := 0
+	progress := func(path string) {
+		processed++
+		if params.Progress != nil {
+			params.Progress(processed, path)
+		}
+
+		reporter.report(ProgressEvent{
+			Path:    path,
+			Scanned: stats.Scanned - scanned,
+			Skipped: stats.Skipped - skipped,
+			Issues:  len(pi.Issues) - start,
+		})
+	}
+
+	// the directories are traversed on this goroutine while the workers read and lex the
+	// files. Results are collected on this goroutine too, so the issues, stats, cache and
+	// Progress are never accessed concurrently.
+	workers := params.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	jobs, results := make(chan scanJob, workers), make(chan scanResult, workers)
+	wg := sync.WaitGroup{}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			pi.scanWorker(params.Ctx, fsys, params.IncludeBinary, jobs, results)
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	collect := func(r scanResult) {
+		switch {
+		case r.binary:
+			stats.skipFile(r.path, SKIP_BINARY)
+			return
+		case !r.scanned:
+			fail(r.path, nil, r.err)
+			return
+		}
+
+		stats.scan()
+		if r.err != nil {
+			fail(r.path, nil, r.err)
+		} else {
+			pi.Issues = append(pi.Issues, r.issues...)
+			if params.Cache != nil {
+				params.Cache.Store(r.path, r.info, r.issues)
+			}
+		}
+		progress(r.path)
+	}
+
+	// submit hands a file to the workers, the results that are ready are collected while
+	// the workers are busy so that neither side blocks the other
+	submit := func(job scanJob) {
+		for {
+			select {
+			case jobs <- job:
+				return
+			case r := <-results:
+				collect(r)
+			}
+		}
+	}
+
+	var visit fs.WalkDirFunc
+	visit = func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
-			return err
+			if path == root {
+				return err
+			}
+			return fail(path, d, err)
 		}
 
-		if d.IsDir() {
-			// @TODO Flag for Walking/Scanning hidden dirs? Revisit this thought
-			if strings.HasPrefix(d.Name(), ".") {
+		// the issues that were located so far are kept when the walk is canceled
+		if params.Ctx != nil {
+			if err := params.Ctx.Err(); err != nil {
+				return err
+			}
+		}
+
+		// directories beyond MaxDepth are pruned before they are matched against the ignore
+		// patterns or read
+		if d.IsDir() && maxDepth >= 0 && pathDepth(root, path) > maxDepth {
+			return filepath.SkipDir
+		}
+
+		// hidden entries are pruned by name, before a file is opened or a directory is read
+		if path != root && isHidden(d.Name(), d.IsDir(), params) {
+			stats.hide()
+			if d.IsDir() {
 				return filepath.SkipDir
 			}
+			return nil
+		}
 
-			isIgnored, err := ignorer.Match(path)
+		if d.IsDir() {
+			isIgnored, err := ignorer.Match(path, true)
 			if err != nil {
 				return err
 			}
@@ -46,42 +184,307 @@ func (pi *PendingIssue) Walk(root string) (int, error) {
 				return filepath.SkipDir
 			}
 
+			if params.FollowSymlinks {
+				real, err := evalSymlinks(fsys, path)
+				if err != nil {
+					return fail(path, d, err)
+				}
+
+				if visited[real] {
+					stats.skipFile(filepath.Clean(path), SKIP_VISITED)
+					return filepath.SkipDir
+				}
+				visited[real] = true
+			}
+
+			if path == root {
+				return nil
+			}
+
+			if params.Files != nil {
+				rel, err := filepath.Rel(root, path)
+				if err != nil {
+					return err
+				}
+
+				if !params.Files.ContainsDir(rel) {
+					return filepath.SkipDir
+				}
+			}
+
+			if err := ignorer.EnterDir(path); err != nil {
+				return fail(path, d, err)
+			}
 			return nil
 		}
 
-		isIgnored, err := ignorer.Match(path)
+		isIgnored, err := ignorer.Match(path, false)
 		if err != nil {
 			return err
 		}
 
 		if isIgnored {
+			stats.skip()
 			return nil
 		}
 
-		src, err := os.ReadFile(path)
+		info, err := d.Info()
+		if err != nil {
+			return fail(path, d, err)
+		}
+
+		if info.Mode()&fs.ModeSymlink != 0 {
+			if !params.FollowSymlinks {
+				stats.skipFile(path, SKIP_SYMLINK)
+				return nil
+			}
+
+			info, err = fs.Stat(fsys, path)
+			if err != nil {
+				stats.skipFile(path, SKIP_BROKEN_SYMLINK)
+				return nil
+			}
+
+			if info.IsDir() {
+				return walkSymlinkDir(fsys, path, visit)
+			}
+		}
+
+		rel, err := filepath.Rel(root, path)
 		if err != nil {
 			return err
 		}
 
+		if params.Files != nil && !params.Files.Contains(rel) {
+			stats.skip()
+			return nil
+		}
+
+		if params.Include != nil && !params.Include.Match(filepath.ToSlash(rel), params.IgnoreCase) {
+			params.Include.Filtered++
+			stats.skip()
+			return nil
+		}
+
 		n++
-		return pi.Scan(src, path)
+		// files of languages without a lexer are not read
+		if !lexer.IsSupported(lexer.FileType(path)) {
+			stats.skip()
+			return nil
+		}
+
+		if params.MaxFileSize > 0 && info.Size() > params.MaxFileSize {
+			stats.skipFile(path, SKIP_SIZE)
+			return nil
+		}
+
+		if params.Cache != nil {
+			if issues, ok := params.Cache.Lookup(path, info); ok {
+				stats.scan()
+				pi.Issues = append(pi.Issues, issues...)
+				progress(path)
+				return nil
+			}
+		}
+
+		submit(scanJob{path: path, info: info})
+		return nil
+	}
+
+	walkErr := walkDir(fsys, root, visit)
+	close(jobs)
+	for r := range results {
+		collect(r)
+	}
+	reporter.done()
+
+	// the workers finish files in any order
+	sortIssues(pi.Issues[start:])
+	sort.Slice(fileErrs, func(i, j int) bool { return comparePaths(fileErrs[i].Path, fileErrs[j].Path) < 0 })
+	if params.Stats != nil {
+		skipped := stats.SkippedFiles
+		sort.SliceStable(skipped, func(i, j int) bool { return comparePaths(skipped[i].Path, skipped[j].Path) < 0 })
+	}
+
+	if walkErr != nil {
+		return n, walkErr
+	}
+
+	if len(fileErrs) > 0 {
+		return n, fileErrs
+	}
+	return n, nil
+}
+
+// scanJob is a file that a worker of Walk scans
+type scanJob struct {
+	path string
+	info fs.FileInfo
+}
+
+// scanResult is the outcome of a scanJob. scanned is false when the file was not lexed,
+// since it's binary or could not be read.
+type scanResult struct {
+	scanJob
+	issues  []Issue
+	binary  bool
+	scanned bool
+	err     error
+}
+
+// scanWorker scans the files of jobs, which are read from fsys, until it's closed. The
+// files that are left once ctx is done are dropped, ctx may be nil.
+func (pi *PendingIssue) scanWorker(
+	ctx context.Context,
+	fsys fs.FS,
+	includeBinary bool,
+	jobs <-chan scanJob,
+	results chan<- scanResult,
+) {
+	var done <-chan struct{}
+	if ctx != nil {
+		done = ctx.Done()
+	}
+
+	for job := range jobs {
+		select {
+		case <-done:
+			continue
+		default:
+		}
+
+		result := scanResult{scanJob: job}
+		src, binary, err := readFile(fsys, job.path, fileMeta(job.path, job.info), includeBinary)
+		if err != nil || binary {
+			result.binary, result.err = binary, err
+			results <- result
+			continue
+		}
+
+		file := &PendingIssue{Annotation: pi.Annotation}
+		result.scanned = true
+		result.err = file.scan(src, job.path)
+		result.issues = file.Issues
+		results <- result
+	}
+}
+
+// sortIssues orders issues with CompareIssues
+func sortIssues(issues []Issue) {
+	sort.SliceStable(issues, func(i, j int) bool {
+		return CompareIssues(issues[i], issues[j]) < 0
 	})
+}
+
+// CompareIssues orders issues by path, see comparePaths, then by line and then by
+// annotation. Issues on the same line with the same annotation are ordered by their
+// position in the file, so the order never depends on the order the files were scanned
+// in. It returns a negative number when a sorts before b, a positive number when a sorts
+// after b and 0 when they are equal.
+func CompareIssues(a, b Issue) int {
+	if c := comparePaths(a.FilePath, b.FilePath); c != 0 {
+		return c
+	}
+
+	if a.LineNumber != b.LineNumber {
+		return a.LineNumber - b.LineNumber
+	}
+
+	if c := strings.Compare(a.Annotation, b.Annotation); c != 0 {
+		return c
+	}
+
+	return a.StartIndex - b.StartIndex
+}
+
+// comparePaths compares two paths one component at a time, which is the order that
+// filepath.WalkDir visits them in. Comparing the paths as strings would order a.c before
+// a/main.c, since . sorts before the separator.
+func comparePaths(a, b string) int {
+	as := strings.Split(filepath.ToSlash(a), "/")
+	bs := strings.Split(filepath.ToSlash(b), "/")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if as[i] != bs[i] {
+			return strings.Compare(as[i], bs[i])
+		}
+	}
+	return len(as) - len(bs)
+}
+
+// readFile reads the source code of the file located at path in fsys, see readSource
+func readFile(fsys fs.FS, path string, meta FileMeta, includeBinary bool) ([]byte, bool, error) {
+	file, err := fsys.Open(path)
+	if err != nil {
+		return nil, false, err
+	}
+
+	defer file.Close()
+	return readSource(file, meta, includeBinary)
+}
 
-	return n, err
+// ScanFile reads the source code of the file that meta describes from r and locates the
+// annotated comments in it. Binary content is skipped, see IsBinaryFile.
+func (pi *PendingIssue) ScanFile(r io.Reader, meta FileMeta) error {
+	src, binary, err := readSource(r, meta, false)
+	if err != nil || binary {
+		return err
+	}
+	return pi.scan(src, meta.Name)
 }
 
+// NewWalkIgnorer creates the ignorer that Walk starts with. It has the patterns from the
+// .gitignore files in the root of the project, the global excludes file, the patterns from
+// the .issueignore and .issuesummonerignore files, if they exist, and the default ignores.
+// The .gitignore files of subdirectories are added as the walk enters them.
+func NewWalkIgnorer(params WalkParams) (*ignore.Ignorer, error) {
+	ignorer, err := ignore.NewIgnorerFS(params.FS, params.Root)
+	if err != nil {
+		return nil, err
+	}
+	ignorer.IgnoreCase = params.IgnoreCase
+
+	if !params.NoDefaultIgnores {
+		if err := ignorer.AppendDefaultIgnores(params.Root); err != nil {
+			return nil, err
+		}
+	}
+
+	if params.ExcludesFile != "" {
+		if err := ignorer.AppendGlobalExcludeFile(params.Root, params.ExcludesFile); err != nil {
+			return nil, err
+		}
+	}
+
+	if params.IssueIgnorePath != "" {
+		return ignorer, ignorer.AppendExcludeFile(params.Root, params.IssueIgnorePath)
+	}
+
+	for _, src := range []string{ignore.ISSUEIGNORE, ignore.ISSUESUMMONERIGNORE} {
+		err = ignorer.AppendExcludeGroup(params.Root, src)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+
+	return ignorer, nil
+}
+
+// Scan locates the annotated comments in src, the content of the file located at path.
+// Binary content is skipped, see IsBinary.
 func (pi *PendingIssue) Scan(src []byte, path string) error {
-	base := filepath.Base(path)
-	ext := filepath.Ext(base)
+	if IsBinary(src[:min(len(src), sniff_len)]) {
+		return nil
+	}
+	return pi.scan(src, path)
+}
 
-	/*
-	* @TODO remove file extension check when additional language support is added.
-	* The implementation has changed drastically and is now utilizing a scanning/lexing
-	* approach. I am starting out with languages that have adopted similar comment syntax
-	* to C, since it's the most common. Once more languages are added, we can remove this check
-	 */
+// scan locates the annotated comments in src without checking whether it's binary, which
+// Walk does before the file is read unless binary files are included
+func (pi *PendingIssue) scan(src []byte, path string) error {
+	base := filepath.Base(path)
 
-	if !lexer.IsAdoptedFromC(ext) {
+	// files of languages without a lexer, or a registered comment syntax, are skipped
+	if !lexer.IsSupported(lexer.FileType(base)) {
 		return nil
 	}
 
@@ -101,28 +504,38 @@ func (pi *PendingIssue) Scan(src []byte, path string) error {
 	}
 
 	for _, c := range comments {
-		token := tokens[c.TokenIndex]
+		// an annotation that is followed by a reference, such as @TODO(#42), was already
+		// reported and is located by ProcessedIssue instead
+		if _, issued := ParseAnnotation(string(c.Source), pi.Annotation); issued {
+			continue
+		}
+
+		token, end := tokens[c.TokenIndex], tokens[c.EndTokenIndex]
 		pi.Issues = append(pi.Issues, Issue{
-			ID:          fmt.Sprintf("%s-%d:%d", base, token.StartByteIndex, token.EndByteIndex),
-			Title:       string(c.Title),
-			Description: string(c.Description),
-			FileName:    base,
-			FilePath:    path,
-			LineNumber:  token.Line,
-			StartIndex:  token.StartByteIndex,
-			EndIndex:    token.EndByteIndex,
+			ID:            fmt.Sprintf("%s-%d:%d", base, token.StartByteIndex, token.EndByteIndex),
+			Annotation:    string(c.Annotation),
+			Title:         string(c.Title),
+			Description:   string(c.Description),
+			FileName:      base,
+			FilePath:      path,
+			LineNumber:    token.Line,
+			EndLineNumber: end.EndLine,
+			StartIndex:    token.StartByteIndex,
+			EndIndex:      end.EndByteIndex,
 		})
 	}
 
 	return nil
 }
 
-func (pi *PendingIssue) WriteIssueID(id int64, issueIndex int) error {
+// WriteIssueRef replaces the annotation of the issue at issueIndex with @ISSUE(ref), where
+// ref is the reference to the issue that was reported for it, see scm.ReferenceFormat
+func (pi *PendingIssue) WriteIssueRef(ref string, issueIndex int) error {
 	if len(pi.Issues) == 0 {
-		return errors.New("cannot write issue_id with an empty issue slice")
+		return errors.New("cannot write issue reference with an empty issue slice")
 	}
 
-	if issueIndex < 0 || issueIndex > len(pi.Issues) {
+	if issueIndex < 0 || issueIndex >= len(pi.Issues) {
 		return fmt.Errorf(
 			"issue index %d out of range. issue slice len: %d",
 			issueIndex,
@@ -144,8 +557,7 @@ func (pi *PendingIssue) WriteIssueID(id int64, issueIndex int) error {
 
 	start, end := currentIssue.StartIndex, currentIssue.EndIndex
 	comment := src[start : end+1]
-	annotationId := fmt.Sprintf("(%d)", id)
-	newAnnotation := "@ISSUE" + annotationId
+	newAnnotation := fmt.Sprintf("%s(%s)", ISSUE_ANNOTATION, ref)
 	comment = bytes.Replace(comment, []byte(pi.Annotation), []byte(newAnnotation), 1)
 	buf := make([]byte, 0)
 
@@ -180,3 +592,43 @@ func (pi *PendingIssue) WriteIssueID(id int64, issueIndex int) error {
 func (pi *PendingIssue) GetIssues() []Issue {
 	return pi.Issues
 }
+
+// pathDepth is the depth of the files in dir, which is the number of separators in the
+// path of dir relative to root plus one. The files in root are at depth 0.
+func pathDepth(root, dir string) int {
+	rel, err := filepath.Rel(root, dir)
+	if err != nil || rel == "." {
+		return 0
+	}
+	return strings.Count(filepath.ToSlash(rel), "/") + 1
+}
+
+// vcsDirs are the metadata directories of version control systems. They are never walked,
+// the names are compared exactly so that .github, .gitlab and .gitignore are not matched.
+var vcsDirs = map[string]bool{
+	".git": true,
+	".hg":  true,
+	".svn": true,
+}
+
+// isHidden reports whether the file or directory name should be skipped since it's hidden.
+// The metadata directories of version control systems, see vcsDirs, are always hidden,
+// including the .git directory of a nested repository. Other names that start with a dot
+// are hidden unless ScanHidden is set or they are listed in AllowHidden.
+func isHidden(name string, isDir bool, params WalkParams) bool {
+	if isDir && vcsDirs[name] {
+		return true
+	}
+
+	if !strings.HasPrefix(name, ".") || params.ScanHidden {
+		return false
+	}
+
+	for _, allowed := range params.AllowHidden {
+		if name == allowed {
+			return false
+		}
+	}
+
+	return true
+}
@@ -1,11 +1,23 @@
 package issue_test
 
 import (
+	"context"
+	"fmt"
 	"io"
+	"io/fs"
 	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"testing/fstest"
+	"time"
 
 	"github.com/AntoninoAdornetto/issue-summoner/pkg/issue"
+	"github.com/AntoninoAdornetto/issue-summoner/pkg/lexer"
+	"github.com/AntoninoAdornetto/issue-summoner/pkg/scm"
 	"github.com/stretchr/testify/require"
 )
 
@@ -31,44 +43,52 @@ func TestScanSingleLineCommentsGo(t *testing.T) {
 	// appear in the expected slice.
 	expected := []issue.Issue{
 		{
-			ID:          "test.c-62:95",
-			Title:       "inline comment #1",
-			Description: "",
-			LineNumber:  5,
-			FileName:    "test.c",
-			FilePath:    "../../testdata/test.c",
-			StartIndex:  62,
-			EndIndex:    95,
+			ID:            "test.c-62:95",
+			Annotation:    annotation,
+			Title:         "inline comment #1",
+			Description:   "",
+			LineNumber:    5,
+			EndLineNumber: 5,
+			FileName:      "test.c",
+			FilePath:      "../../testdata/test.c",
+			StartIndex:    62,
+			EndIndex:      95,
 		},
 		{
-			ID:          "test.c-115:148",
-			Title:       "inline comment #2",
-			Description: "",
-			LineNumber:  6,
-			FileName:    "test.c",
-			FilePath:    "../../testdata/test.c",
-			StartIndex:  115,
-			EndIndex:    148,
+			ID:            "test.c-115:148",
+			Annotation:    annotation,
+			Title:         "inline comment #2",
+			Description:   "",
+			LineNumber:    6,
+			EndLineNumber: 6,
+			FileName:      "test.c",
+			FilePath:      "../../testdata/test.c",
+			StartIndex:    115,
+			EndIndex:      148,
 		},
 		{
-			ID:          "test.c-192:252",
-			Title:       "decode the message and clean up after yourself!",
-			Description: "",
-			FileName:    "test.c",
-			FilePath:    "../../testdata/test.c",
-			LineNumber:  10,
-			StartIndex:  192,
-			EndIndex:    252,
+			ID:            "test.c-192:252",
+			Annotation:    annotation,
+			Title:         "decode the message and clean up after yourself!",
+			Description:   "",
+			FileName:      "test.c",
+			FilePath:      "../../testdata/test.c",
+			LineNumber:    10,
+			EndLineNumber: 10,
+			StartIndex:    192,
+			EndIndex:      252,
 		},
 		{
-			ID:          "test.c-269:561",
-			Title:       "drop a star if you know about this code wars challenge",
-			Description: "Digital Cypher assigns to each letter of the alphabet unique number. Instead of letters in encrypted word we write the corresponding number Then we add to each obtained digit consecutive digits from the key",
-			FileName:    "test.c",
-			FilePath:    "../../testdata/test.c",
-			LineNumber:  19,
-			StartIndex:  269,
-			EndIndex:    561,
+			ID:            "test.c-269:561",
+			Annotation:    annotation,
+			Title:         "drop a star if you know about this code wars challenge",
+			Description:   "Digital Cypher assigns to each letter of the alphabet unique number.\nInstead of letters in encrypted word we write the corresponding number\nThen we add to each obtained digit consecutive digits from the key",
+			FileName:      "test.c",
+			FilePath:      "../../testdata/test.c",
+			LineNumber:    14,
+			EndLineNumber: 19,
+			StartIndex:    269,
+			EndIndex:      561,
 		},
 	}
 
@@ -83,14 +103,14 @@ func TestWalkCountScans(t *testing.T) {
 	require.NoError(t, err)
 	require.NotNil(t, im)
 
-	// Walk is called 4 times in total within the testdata directory
-	// 1 time for the .gitignore file
+	// Walk is called 3 times in total within the testdata directory, the hidden
+	// .gitignore file is pruned
 	// 1 time for the ignore.sh file
 	// 1 time for test.c
 	// 1 time for test.log
 	// this test does not include gitignore exclude rules. see next test for that.
-	expected := 4
-	actual, err := im.Walk("../../testdata/")
+	expected := 3
+	actual, err := im.Walk(issue.WalkParams{Root: "../../testdata/"})
 	require.NoError(t, err)
 	require.Equal(t, expected, actual)
 }
@@ -110,13 +130,14 @@ func TestWalkCountStepsWithIgnorePatterns(t *testing.T) {
 	originalBytes, err := io.ReadAll(ignoreFile)
 	require.NoError(t, err)
 
+	// like git, leading whitespace is part of a pattern, so the patterns are not indented
 	ignorePatterns := `
-	# Wildcard pattern
-	*.log
+# Wildcard pattern
+*.log
 
-	# ignore everything within the exclude directory
-	exclude/
-	`
+# ignore everything within the exclude directory
+exclude/
+`
 
 	_, err = ignoreFile.Seek(0, 0)
 	require.NoError(t, err)
@@ -141,10 +162,9 @@ func TestWalkCountStepsWithIgnorePatterns(t *testing.T) {
 		require.NoError(t, err)
 	}()
 
-	// walk should call scan one time for test.c and one test for .gitignore
-	// might change calling it on .gitignore file but for now it's ok
-	expected := 2
-	actual, err := im.Walk("../../testdata")
+	// walk should call scan one time for test.c, the hidden .gitignore file is pruned
+	expected := 1
+	actual, err := im.Walk(issue.WalkParams{Root: "../../testdata"})
 	require.NoError(t, err)
 	require.Equal(t, expected, actual)
 }
@@ -155,6 +175,947 @@ func TestWalkNoneExistentRoot(t *testing.T) {
 	require.NoError(t, err)
 	require.NotNil(t, im)
 
-	_, err = im.Walk("unknown-path")
+	_, err = im.Walk(issue.WalkParams{Root: "unknown-path"})
 	require.Error(t, err)
 }
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+}
+
+// mapFS is the fstest.MapFS fixture of the files, which are slash separated paths that are
+// relative to root
+func mapFS(root string, files map[string]string) fstest.MapFS {
+	fsys := fstest.MapFS{}
+	for name, content := range files {
+		fsys[path.Join(root, name)] = &fstest.MapFile{Data: []byte(content), Mode: 0644}
+	}
+	return fsys
+}
+
+// should skip files that are matched by the .issueignore file even though
+// they are not excluded by the .gitignore file
+func TestWalkIssueIgnore(t *testing.T) {
+	fsys := mapFS(".", map[string]string{
+		".gitignore":         "*.log\n",
+		".issueignore":       "generated/\n",
+		"main.c":             "// @TEST_TODO tracked and scanned\n",
+		"generated/types.c":  "// @TEST_TODO tracked but not scanned\n",
+		"generated/other.go": "// @TEST_TODO tracked but not scanned\n",
+	})
+
+	im, err := issue.NewIssueManager(issue.PENDING_ISSUE, annotation)
+	require.NoError(t, err)
+
+	_, err = im.Walk(issue.WalkParams{Root: ".", FS: fsys})
+	require.NoError(t, err)
+
+	issues := im.GetIssues()
+	require.Len(t, issues, 1)
+	require.Equal(t, "tracked and scanned", issues[0].Title)
+}
+
+// should use the ignore file provided by IssueIgnorePath instead of .issueignore
+func TestWalkIssueIgnorePath(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".gitignore":   "",
+		".issueignore": "main.c\n",
+		"main.c":       "// @TEST_TODO scanned\n",
+		"vendor/lib.c": "// @TEST_TODO not scanned\n",
+	})
+
+	ignorePath := filepath.Join(t.TempDir(), "custom-ignore")
+	require.NoError(t, os.WriteFile(ignorePath, []byte("vendor/\n"), 0644))
+
+	im, err := issue.NewIssueManager(issue.PENDING_ISSUE, annotation)
+	require.NoError(t, err)
+
+	_, err = im.Walk(issue.WalkParams{Root: root, IssueIgnorePath: ignorePath})
+	require.NoError(t, err)
+
+	issues := im.GetIssues()
+	require.Len(t, issues, 1)
+	require.Equal(t, "scanned", issues[0].Title)
+}
+
+// should skip dependency and build directories unless NoDefaultIgnores is set
+func TestWalkDefaultIgnores(t *testing.T) {
+	fsys := mapFS(".", map[string]string{
+		".gitignore":            "",
+		"main.c":                "// @TEST_TODO scanned\n",
+		"node_modules/dep/a.js": "// @TEST_TODO dependency\n",
+		"target/gen.c":          "// @TEST_TODO generated\n",
+	})
+
+	im, err := issue.NewIssueManager(issue.PENDING_ISSUE, annotation)
+	require.NoError(t, err)
+
+	_, err = im.Walk(issue.WalkParams{Root: ".", FS: fsys})
+	require.NoError(t, err)
+	require.Len(t, im.GetIssues(), 1)
+
+	im, err = issue.NewIssueManager(issue.PENDING_ISSUE, annotation)
+	require.NoError(t, err)
+
+	_, err = im.Walk(issue.WalkParams{Root: ".", FS: fsys, NoDefaultIgnores: true})
+	require.NoError(t, err)
+	require.Len(t, im.GetIssues(), 3)
+}
+
+// should only scan the files that match the include patterns, after the ignore files are
+// applied, and count the files that were filtered out
+func TestWalkInclude(t *testing.T) {
+	fsys := mapFS(".", map[string]string{
+		".gitignore":       "gen/\n",
+		"main.go":          "// @TEST_TODO main\n",
+		"cmd/root.c":       "// @TEST_TODO cmd\n",
+		"pkg/lexer/lex.go": "// @TEST_TODO lexer\n",
+		"pkg/lexer/lex.c":  "// @TEST_TODO not included\n",
+		"gen/types.go":     "// @TEST_TODO ignored\n",
+	})
+
+	include, err := issue.NewIncludeFilter([]string{"**/*.go", "cmd/**"})
+	require.NoError(t, err)
+
+	im, err := issue.NewIssueManager(issue.PENDING_ISSUE, annotation)
+	require.NoError(t, err)
+
+	_, err = im.Walk(issue.WalkParams{Root: ".", FS: fsys, Include: include})
+	require.NoError(t, err)
+
+	titles := make([]string, 0)
+	for _, is := range im.GetIssues() {
+		titles = append(titles, is.Title)
+	}
+	require.ElementsMatch(t, []string{"main", "cmd", "lexer"}, titles)
+	// pkg/lexer/lex.c, the hidden .gitignore file is pruned before the include patterns
+	require.Equal(t, 1, include.Filtered)
+}
+
+// should not locate annotations in binary content, even when the extension of the file is
+// supported
+func TestScanSkipsBinary(t *testing.T) {
+	png, err := os.ReadFile(filepath.Join("testdata", "pixel.png"))
+	require.NoError(t, err)
+	require.Contains(t, string(png), "@TEST_TODO")
+
+	text, err := os.ReadFile(filepath.Join("testdata", "main.c"))
+	require.NoError(t, err)
+
+	im, err := issue.NewIssueManager(issue.PENDING_ISSUE, annotation)
+	require.NoError(t, err)
+	require.NoError(t, im.Scan(png, "pixel.c"))
+	require.Empty(t, im.GetIssues())
+
+	require.NoError(t, im.Scan(text, "main.c"))
+	require.Len(t, im.GetIssues(), 1)
+	require.Equal(t, "text fixture", im.GetIssues()[0].Title)
+}
+
+// should skip binary files unless IncludeBinary is set
+func TestWalkSkipsBinaryFiles(t *testing.T) {
+	fsys := mapFS(".", map[string]string{
+		"main.c":   "// @TEST_TODO text\n",
+		"blob.c":   "\x00\x01// @TEST_TODO garbage\n",
+		"image.go": "// @TEST_TODO not text\x00\n",
+	})
+
+	im, err := issue.NewIssueManager(issue.PENDING_ISSUE, annotation)
+	require.NoError(t, err)
+
+	stats := &issue.WalkStats{}
+	_, err = im.Walk(issue.WalkParams{Root: ".", FS: fsys, Stats: stats})
+	require.NoError(t, err)
+	require.Len(t, im.GetIssues(), 1)
+	require.Equal(t, 1, stats.Scanned)
+	require.Equal(t, 2, stats.Skipped)
+	require.ElementsMatch(t, []issue.SkippedFile{
+		{Path: "blob.c", Reason: issue.SKIP_BINARY},
+		{Path: "image.go", Reason: issue.SKIP_BINARY},
+	}, stats.SkippedFiles)
+
+	im, err = issue.NewIssueManager(issue.PENDING_ISSUE, annotation)
+	require.NoError(t, err)
+
+	_, err = im.Walk(issue.WalkParams{Root: ".", FS: fsys, IncludeBinary: true})
+	require.NoError(t, err)
+	require.Len(t, im.GetIssues(), 3)
+}
+
+// should only scan the files of the file set, such as the output of git diff --name-only
+func TestWalkFileSet(t *testing.T) {
+	fsys := mapFS(".", map[string]string{
+		".gitignore":      "gen/\n",
+		"main.c":          "// @TEST_TODO unchanged\n",
+		"cmd/scan.c":      "// @TEST_TODO changed\n",
+		"cmd/report.c":    "// @TEST_TODO unchanged\n",
+		"pkg/issue/fs.go": "// @TEST_TODO changed too\n",
+		"gen/types.go":    "// @TEST_TODO ignored even though it changed\n",
+		"lib/util.c":      "// @TEST_TODO never visited\n",
+	})
+
+	runner := &scm.FakeGitRunner{Outputs: map[string]string{
+		"rev-parse --verify --quiet main^{commit}":            "1f0c2a\n",
+		"diff --name-only -z --relative --diff-filter=d main": "cmd/scan.c\x00pkg/issue/fs.go\x00gen/types.go\x00",
+	}}
+	files, err := scm.ChangedFiles(runner, ".", "main")
+	require.NoError(t, err)
+
+	set := issue.NewFileSet(files)
+	require.Equal(t, 3, set.Len())
+	require.True(t, set.ContainsDir("pkg"))
+	require.False(t, set.ContainsDir("lib"))
+
+	im, err := issue.NewIssueManager(issue.PENDING_ISSUE, annotation)
+	require.NoError(t, err)
+
+	stats := &issue.WalkStats{}
+	_, err = im.Walk(issue.WalkParams{Root: ".", FS: fsys, Files: set, Stats: stats})
+	require.NoError(t, err)
+
+	titles := make([]string, 0)
+	for _, is := range im.GetIssues() {
+		titles = append(titles, is.Title)
+	}
+	require.ElementsMatch(t, []string{"changed", "changed too"}, titles)
+	// main.c and cmd/report.c, lib/ is never entered
+	require.Equal(t, 2, stats.Skipped)
+}
+
+// should skip the files that are larger than MaxFileSize, and only list the files that
+// were skipped because of their size
+func TestWalkMaxFileSize(t *testing.T) {
+	large := "// @TEST_TODO large\n" + strings.Repeat("int x;\n", 100)
+	fsys := mapFS(".", map[string]string{
+		".gitignore":  "dump.c\n",
+		"main.c":      "// @TEST_TODO small\n",
+		"bundle.c":    large,
+		"dump.c":      large,
+		"generated.x": large,
+	})
+
+	im, err := issue.NewIssueManager(issue.PENDING_ISSUE, annotation)
+	require.NoError(t, err)
+
+	stats := &issue.WalkStats{}
+	_, err = im.Walk(issue.WalkParams{Root: ".", FS: fsys, MaxFileSize: 100, Stats: stats})
+	require.NoError(t, err)
+	require.Len(t, im.GetIssues(), 1)
+	require.Equal(t, "small", im.GetIssues()[0].Title)
+	require.Equal(t, []issue.SkippedFile{
+		{Path: "bundle.c", Reason: issue.SKIP_SIZE},
+	}, stats.SkippedFiles)
+
+	im, err = issue.NewIssueManager(issue.PENDING_ISSUE, annotation)
+	require.NoError(t, err)
+
+	_, err = im.Walk(issue.WalkParams{Root: ".", FS: fsys})
+	require.NoError(t, err)
+	require.Len(t, im.GetIssues(), 2)
+}
+
+// newSymlinkTree creates a root with a symlink that references the root itself, a symlink
+// to a directory and a file outside of the root, and a broken symlink
+func newSymlinkTree(t *testing.T) string {
+	root, outside := t.TempDir(), t.TempDir()
+	writeFiles(t, root, map[string]string{"main.c": "// @TEST_TODO inside\n"})
+	writeFiles(t, outside, map[string]string{
+		"lib/lib.c": "// @TEST_TODO outside dir\n",
+		"single.c":  "// @TEST_TODO outside file\n",
+	})
+
+	require.NoError(t, os.Symlink(root, filepath.Join(root, "loop")))
+	require.NoError(t, os.Symlink(filepath.Join(outside, "lib"), filepath.Join(root, "lib")))
+	require.NoError(t, os.Symlink(filepath.Join(outside, "single.c"), filepath.Join(root, "single.c")))
+	require.NoError(t, os.Symlink(filepath.Join(root, "missing"), filepath.Join(root, "broken.c")))
+	return root
+}
+
+// should skip symlinks unless FollowSymlinks is set
+func TestWalkSkipsSymlinks(t *testing.T) {
+	root := newSymlinkTree(t)
+	im, err := issue.NewIssueManager(issue.PENDING_ISSUE, annotation)
+	require.NoError(t, err)
+
+	stats := &issue.WalkStats{}
+	_, err = im.Walk(issue.WalkParams{Root: root, Stats: stats})
+	require.NoError(t, err)
+	require.Len(t, im.GetIssues(), 1)
+	require.Equal(t, "inside", im.GetIssues()[0].Title)
+
+	skipped := make([]issue.SkippedFile, 0)
+	for _, name := range []string{"broken.c", "lib", "loop", "single.c"} {
+		path := filepath.Join(root, name)
+		skipped = append(skipped, issue.SkippedFile{Path: path, Reason: issue.SKIP_SYMLINK})
+	}
+	require.ElementsMatch(t, skipped, stats.SkippedFiles)
+}
+
+// should follow symlinks without walking a directory twice, or forever when a symlink
+// references one of its parents
+func TestWalkFollowSymlinks(t *testing.T) {
+	root := newSymlinkTree(t)
+	im, err := issue.NewIssueManager(issue.PENDING_ISSUE, annotation)
+	require.NoError(t, err)
+
+	stats := &issue.WalkStats{}
+	_, err = im.Walk(issue.WalkParams{Root: root, FollowSymlinks: true, Stats: stats})
+	require.NoError(t, err)
+
+	titles, paths := make([]string, 0), make([]string, 0)
+	for _, is := range im.GetIssues() {
+		titles = append(titles, is.Title)
+		paths = append(paths, is.FilePath)
+	}
+	require.ElementsMatch(t, []string{"inside", "outside dir", "outside file"}, titles)
+	// issues are located through the path of the symlink
+	require.Contains(t, paths, filepath.Join(root, "lib", "lib.c"))
+
+	require.ElementsMatch(t, []issue.SkippedFile{
+		{Path: filepath.Join(root, "broken.c"), Reason: issue.SKIP_BROKEN_SYMLINK},
+		{Path: filepath.Join(root, "loop"), Reason: issue.SKIP_VISITED},
+	}, stats.SkippedFiles)
+}
+
+// should break a cycle beneath a symlinked directory, and skip a symlink that references
+// itself instead of resolving it forever
+func TestWalkFollowSymlinkCycles(t *testing.T) {
+	root, outside := t.TempDir(), t.TempDir()
+	writeFiles(t, outside, map[string]string{"lib/lib.c": "// @TEST_TODO outside dir\n"})
+
+	require.NoError(t, os.Symlink(filepath.Join(outside, "lib"), filepath.Join(root, "lib")))
+	require.NoError(t, os.Symlink(filepath.Join(outside, "lib"), filepath.Join(outside, "lib", "self")))
+	require.NoError(t, os.Symlink(filepath.Join(root, "itself.c"), filepath.Join(root, "itself.c")))
+
+	im, err := issue.NewIssueManager(issue.PENDING_ISSUE, annotation)
+	require.NoError(t, err)
+
+	stats := &issue.WalkStats{}
+	_, err = im.Walk(issue.WalkParams{Root: root, FollowSymlinks: true, Stats: stats})
+	require.NoError(t, err)
+	require.Len(t, im.GetIssues(), 1)
+	require.Equal(t, filepath.Join(root, "lib", "lib.c"), im.GetIssues()[0].FilePath)
+
+	require.ElementsMatch(t, []issue.SkippedFile{
+		{Path: filepath.Join(root, "itself.c"), Reason: issue.SKIP_BROKEN_SYMLINK},
+		{Path: filepath.Join(root, "lib", "self"), Reason: issue.SKIP_VISITED},
+	}, stats.SkippedFiles)
+}
+
+// should apply the patterns of a nested .gitignore file to the paths beneath its
+// directory only
+func TestWalkNestedGitignore(t *testing.T) {
+	fsys := mapFS(".", map[string]string{
+		".gitignore":               "*.log\n",
+		"frontend/.gitignore":      "dist/\n/config.c\n",
+		"frontend/main.c":          "// @TEST_TODO frontend\n",
+		"frontend/config.c":        "// @TEST_TODO not scanned\n",
+		"frontend/dist/bundle.c":   "// @TEST_TODO not scanned\n",
+		"frontend/src/config.c":    "// @TEST_TODO frontend src\n",
+		"backend/dist/generated.c": "// @TEST_TODO backend dist\n",
+		"backend/config.c":         "// @TEST_TODO backend\n",
+	})
+
+	im, err := issue.NewIssueManager(issue.PENDING_ISSUE, annotation)
+	require.NoError(t, err)
+
+	_, err = im.Walk(issue.WalkParams{Root: ".", FS: fsys, NoDefaultIgnores: true})
+	require.NoError(t, err)
+
+	titles := make([]string, 0)
+	for _, is := range im.GetIssues() {
+		titles = append(titles, is.Title)
+	}
+	require.ElementsMatch(t, []string{"frontend", "frontend src", "backend dist", "backend"}, titles)
+}
+
+// should scan the project when the directories that contain the root have names that
+// are matched by the patterns of its .gitignore file
+func TestWalkPatternsAnchoredToRoot(t *testing.T) {
+	root := "cmd/vendor/project"
+	fsys := mapFS(root, map[string]string{
+		".gitignore":    "cmd\nvendor/\nproject\n",
+		"main.c":        "// @TEST_TODO scanned\n",
+		"cmd/main.c":    "// @TEST_TODO not scanned\n",
+		"vendor/lib.c":  "// @TEST_TODO not scanned\n",
+		"src/project.c": "// @TEST_TODO also scanned\n",
+	})
+
+	im, err := issue.NewIssueManager(issue.PENDING_ISSUE, annotation)
+	require.NoError(t, err)
+
+	_, err = im.Walk(issue.WalkParams{Root: root, FS: fsys})
+	require.NoError(t, err)
+
+	titles := make([]string, 0)
+	for _, is := range im.GetIssues() {
+		titles = append(titles, is.Title)
+	}
+	require.ElementsMatch(t, []string{"scanned", "also scanned"}, titles)
+}
+
+// should skip files whose names only differ in case from a pattern when IgnoreCase is set
+func TestWalkIgnoreCase(t *testing.T) {
+	fsys := mapFS(".", map[string]string{
+		".gitignore":        "generated/\n",
+		"main.c":            "// @TEST_TODO main\n",
+		"Generated/types.c": "// @TEST_TODO generated\n",
+	})
+
+	for ignoreCase, expected := range map[bool][]string{
+		false: {"main", "generated"},
+		true:  {"main"},
+	} {
+		im, err := issue.NewIssueManager(issue.PENDING_ISSUE, annotation)
+		require.NoError(t, err)
+
+		_, err = im.Walk(issue.WalkParams{Root: ".", FS: fsys, IgnoreCase: ignoreCase})
+		require.NoError(t, err)
+
+		titles := make([]string, 0)
+		for _, is := range im.GetIssues() {
+			titles = append(titles, is.Title)
+		}
+		require.ElementsMatch(t, expected, titles, "ignore case: %t", ignoreCase)
+	}
+}
+
+// should skip the files that are only excluded by .git/info/exclude
+func TestWalkInfoExclude(t *testing.T) {
+	fsys := mapFS(".", map[string]string{
+		".git/info/exclude":  "# personal ignores\nnotes/\nscratch.c\n",
+		"main.c":             "// @TEST_TODO scanned\n",
+		"scratch.c":          "// @TEST_TODO not scanned\n",
+		"notes/ideas.c":      "// @TEST_TODO not scanned\n",
+		"pkg/scratch_test.c": "// @TEST_TODO scanned too\n",
+	})
+
+	im, err := issue.NewIssueManager(issue.PENDING_ISSUE, annotation)
+	require.NoError(t, err)
+
+	_, err = im.Walk(issue.WalkParams{Root: ".", FS: fsys})
+	require.NoError(t, err)
+
+	titles := make([]string, 0)
+	for _, is := range im.GetIssues() {
+		titles = append(titles, is.Title)
+	}
+	require.ElementsMatch(t, []string{"scanned", "scanned too"}, titles)
+}
+
+// should skip the files that are matched by the global excludes file and ignore a missing
+// excludes file
+func TestWalkExcludesFile(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"main.c":     "// @TEST_TODO scanned\n",
+		"main.bak.c": "// @TEST_TODO not scanned\n",
+		"cache/b.c":  "// @TEST_TODO not scanned\n",
+	})
+
+	excludes := filepath.Join(t.TempDir(), "ignore")
+	require.NoError(t, os.WriteFile(excludes, []byte("*.bak.c\ncache/\n"), 0644))
+
+	for path, expected := range map[string]int{excludes: 1, filepath.Join(root, "missing"): 3} {
+		im, err := issue.NewIssueManager(issue.PENDING_ISSUE, annotation)
+		require.NoError(t, err)
+
+		_, err = im.Walk(issue.WalkParams{Root: root, ExcludesFile: path})
+		require.NoError(t, err)
+		require.Len(t, im.GetIssues(), expected, path)
+	}
+}
+
+// should skip the files that are matched by .issuesummonerignore, along with the files
+// that are matched by .issueignore
+func TestWalkIssueSummonerIgnore(t *testing.T) {
+	fsys := mapFS(".", map[string]string{
+		".issueignore":           "generated/\n",
+		".issuesummonerignore":   "third_party/\n",
+		"main.c":                 "// @TEST_TODO scanned\n",
+		"generated/types.c":      "// @TEST_TODO not scanned\n",
+		"third_party/vendored.c": "// @TEST_TODO not scanned\n",
+	})
+
+	im, err := issue.NewIssueManager(issue.PENDING_ISSUE, annotation)
+	require.NoError(t, err)
+
+	_, err = im.Walk(issue.WalkParams{Root: ".", FS: fsys})
+	require.NoError(t, err)
+
+	issues := im.GetIssues()
+	require.Len(t, issues, 1)
+	require.Equal(t, "scanned", issues[0].Title)
+}
+
+// should report an annotated comment that is continued on the next lines as one issue,
+// whose description and span include the lines that continue it
+func TestScanContinuedComment(t *testing.T) {
+	src := "#!/bin/sh\n" +
+		"# @TEST_TODO pin the versions\n" +
+		"# the latest release broke the build\n" +
+		"# twice this month\n" +
+		"apt-get install -y curl\n"
+
+	im, err := issue.NewIssueManager(issue.PENDING_ISSUE, annotation)
+	require.NoError(t, err)
+	require.NoError(t, im.Scan([]byte(src), "install.sh"))
+
+	issues := im.GetIssues()
+	require.Len(t, issues, 1)
+	require.Equal(t, "pin the versions", issues[0].Title)
+	require.Equal(t, "the latest release broke the build\ntwice this month", issues[0].Description)
+	require.Equal(t, 2, issues[0].LineNumber)
+	require.Equal(t, 4, issues[0].EndLineNumber)
+	require.Equal(t, "# @TEST_TODO", src[issues[0].StartIndex:issues[0].StartIndex+12])
+	require.Equal(t, "month", src[issues[0].EndIndex-4:issues[0].EndIndex+1])
+}
+
+// should scan files of an extension that was registered at runtime
+func TestScanRegisteredCommentSyntax(t *testing.T) {
+	err := lexer.RegisterCommentSyntax(".ficty", lexer.CommentSyntax{SingleLine: ";;"})
+	require.NoError(t, err)
+
+	im, err := issue.NewIssueManager(issue.PENDING_ISSUE, annotation)
+	require.NoError(t, err)
+
+	src := []byte("x := 1\n;; @TEST_TODO registered syntax\n")
+	require.NoError(t, im.Scan(src, "main.ficty"))
+
+	issues := im.GetIssues()
+	require.Len(t, issues, 1)
+	require.Equal(t, "registered syntax", issues[0].Title)
+	require.Equal(t, 2, issues[0].LineNumber)
+}
+
+// should prune hidden files and directories, except for the allowed names, and always
+// skip the .git directory
+func TestWalkSkipsHidden(t *testing.T) {
+	fsys := mapFS(".", map[string]string{
+		"main.go":                  "// @TEST_TODO main\n",
+		".cache/gen.go":            "// @TEST_TODO cached\n",
+		".eslintrc.js":             "// @TEST_TODO dotfile\n",
+		".github/workflows/ci.sh":  "# @TEST_TODO workflow\n",
+		".git/hooks/pre-commit.sh": "# @TEST_TODO git hook\n",
+		"pkg/.terraform/main.c":    "// @TEST_TODO terraform\n",
+		"pkg/lexer/lex.go":         "// @TEST_TODO lexer\n",
+	})
+
+	walk := func(params issue.WalkParams) ([]string, *issue.WalkStats) {
+		im, err := issue.NewIssueManager(issue.PENDING_ISSUE, annotation)
+		require.NoError(t, err)
+
+		params.Root, params.FS, params.Stats = ".", fsys, &issue.WalkStats{}
+		_, err = im.Walk(params)
+		require.NoError(t, err)
+
+		titles := make([]string, 0)
+		for _, is := range im.GetIssues() {
+			titles = append(titles, is.Title)
+		}
+		return titles, params.Stats
+	}
+
+	titles, stats := walk(issue.WalkParams{})
+	require.ElementsMatch(t, []string{"main", "lexer"}, titles)
+	// .cache/, .eslintrc.js, .github/, .git/ and pkg/.terraform/
+	require.Equal(t, 5, stats.Hidden)
+	require.Equal(t, 0, stats.Skipped)
+
+	titles, stats = walk(issue.WalkParams{AllowHidden: issue.DefaultAllowHidden})
+	require.ElementsMatch(t, []string{"main", "workflow", "lexer"}, titles)
+	require.Equal(t, 4, stats.Hidden)
+
+	titles, stats = walk(issue.WalkParams{ScanHidden: true})
+	require.ElementsMatch(t, []string{"main", "cached", "dotfile", "workflow", "terraform", "lexer"}, titles)
+	// .git/
+	require.Equal(t, 1, stats.Hidden)
+}
+
+// should always skip the metadata directories of version control systems, even in a nested
+// repository, without matching the names that only start with .git
+func TestWalkSkipsVCSDirectories(t *testing.T) {
+	fsys := mapFS(".", map[string]string{
+		"main.go":                       "// @TEST_TODO main\n",
+		".github/workflows/ci.sh":       "# @TEST_TODO workflow\n",
+		".gitlab/ci.sh":                 "# @TEST_TODO gitlab\n",
+		"my.gitops/deploy.sh":           "# @TEST_TODO gitops\n",
+		".git/hooks/pre-commit.sh":      "# @TEST_TODO git hook\n",
+		".hg/hgrc.sh":                   "# @TEST_TODO mercurial\n",
+		".svn/pristine.c":               "// @TEST_TODO subversion\n",
+		"vendor/lib/.git/hooks/post.sh": "# @TEST_TODO nested repository\n",
+		"vendor/lib/lib.c":              "// @TEST_TODO nested source\n",
+	})
+
+	walk := func(params issue.WalkParams) ([]string, *issue.WalkStats) {
+		im, err := issue.NewIssueManager(issue.PENDING_ISSUE, annotation)
+		require.NoError(t, err)
+
+		params.Root, params.FS, params.Stats = ".", fsys, &issue.WalkStats{}
+		params.NoDefaultIgnores = true
+		_, err = im.Walk(params)
+		require.NoError(t, err)
+
+		titles := make([]string, 0)
+		for _, is := range im.GetIssues() {
+			titles = append(titles, is.Title)
+		}
+		return titles, params.Stats
+	}
+
+	titles, stats := walk(issue.WalkParams{ScanHidden: true})
+	require.ElementsMatch(t, []string{"main", "workflow", "gitlab", "gitops", "nested source"}, titles)
+	// .git/, .hg/, .svn/ and vendor/lib/.git/
+	require.Equal(t, 4, stats.Hidden)
+
+	titles, _ = walk(issue.WalkParams{AllowHidden: []string{".github", ".gitlab", ".git", ".hg"}})
+	require.ElementsMatch(t, []string{"main", "workflow", "gitlab", "gitops", "nested source"}, titles)
+}
+
+// recordingFS records the paths that are opened, including the directories that are read
+type recordingFS struct {
+	fsys   fs.FS
+	mu     sync.Mutex
+	opened []string
+}
+
+func (r *recordingFS) Open(name string) (fs.File, error) {
+	r.mu.Lock()
+	r.opened = append(r.opened, name)
+	r.mu.Unlock()
+	return r.fsys.Open(name)
+}
+
+// should only scan the files that are at most MaxDepth directories beneath the root, and
+// never read or match the directories beyond it
+func TestWalkMaxDepth(t *testing.T) {
+	fsys := mapFS(".", map[string]string{
+		".gitignore":       "skipped.c\n",
+		"main.c":           "// @TEST_TODO depth 0\n",
+		"skipped.c":        "// @TEST_TODO ignored\n",
+		"a/a.c":            "// @TEST_TODO depth 1\n",
+		"a/b/b.c":          "// @TEST_TODO depth 2\n",
+		"a/b/skipped.c":    "// @TEST_TODO ignored\n",
+		"a/b/c/.gitignore": "# never read beyond the max depth\n",
+		"a/b/c/c.c":        "// @TEST_TODO depth 3\n",
+	})
+
+	for _, tc := range []struct {
+		depth    *int
+		expected []string
+	}{
+		{depth: nil, expected: []string{"depth 0", "depth 1", "depth 2", "depth 3"}},
+		{depth: ptr(-1), expected: []string{"depth 0", "depth 1", "depth 2", "depth 3"}},
+		{depth: ptr(0), expected: []string{"depth 0"}},
+		{depth: ptr(1), expected: []string{"depth 0", "depth 1"}},
+		{depth: ptr(2), expected: []string{"depth 0", "depth 1", "depth 2"}},
+	} {
+		im, err := issue.NewIssueManager(issue.PENDING_ISSUE, annotation)
+		require.NoError(t, err)
+
+		recorder := &recordingFS{fsys: fsys}
+		_, err = im.Walk(issue.WalkParams{Root: ".", FS: recorder, MaxDepth: tc.depth})
+		require.NoError(t, err)
+
+		titles := make([]string, 0)
+		for _, is := range im.GetIssues() {
+			titles = append(titles, is.Title)
+		}
+		require.ElementsMatch(t, tc.expected, titles, "max depth %v", tc.depth)
+
+		if tc.depth != nil && *tc.depth == 2 {
+			for _, name := range recorder.opened {
+				require.NotContains(t, name, "a/b/c", "opened beyond the max depth")
+			}
+		}
+	}
+}
+
+func ptr(n int) *int {
+	return &n
+}
+
+// cancelAfter is a context that is canceled once Err has been called more than limit times
+type cancelAfter struct {
+	context.Context
+	calls, limit int
+}
+
+func (ctx *cancelAfter) Err() error {
+	ctx.calls++
+	if ctx.calls > ctx.limit {
+		return context.Canceled
+	}
+	return nil
+}
+
+// should stop walking once the context is canceled and keep the issues that were located
+// before it was canceled
+func TestWalkCanceled(t *testing.T) {
+	fsys := mapFS(".", map[string]string{
+		"a.c": "// @TEST_TODO a\n",
+		"b.c": "// @TEST_TODO b\n",
+		"c.c": "// @TEST_TODO c\n",
+	})
+
+	im, err := issue.NewIssueManager(issue.PENDING_ISSUE, annotation)
+	require.NoError(t, err)
+
+	// the root directory, a.c and b.c are visited before the walk is canceled
+	stats := &issue.WalkStats{}
+	ctx := &cancelAfter{Context: context.Background(), limit: 3}
+	_, err = im.Walk(issue.WalkParams{Root: ".", FS: fsys, Stats: stats, Ctx: ctx})
+	require.ErrorIs(t, err, context.Canceled)
+	require.Equal(t, 2, stats.Scanned)
+	require.Len(t, im.GetIssues(), 2)
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	im, err = issue.NewIssueManager(issue.PENDING_ISSUE, annotation)
+	require.NoError(t, err)
+	_, err = im.Walk(issue.WalkParams{Root: ".", FS: fsys, Ctx: canceled})
+	require.ErrorIs(t, err, context.Canceled)
+	require.Empty(t, im.GetIssues())
+}
+
+// should skip the files that can't be scanned, such as a file without read permission or
+// a file the lexer rejects, and still scan the rest of the files
+func TestWalkFileErrors(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"main.c":       "// @TEST_TODO main\n",
+		"broken.c":     "/* @TEST_TODO never closed\n",
+		"lib/util.c":   "// @TEST_TODO util\n",
+		"lib/secret.c": "// @TEST_TODO secret\n",
+	})
+
+	secret := filepath.Join(root, "lib", "secret.c")
+	require.NoError(t, os.Chmod(secret, 0))
+	// permissions are not enforced for root
+	readable := os.Geteuid() == 0
+
+	im, err := issue.NewIssueManager(issue.PENDING_ISSUE, annotation)
+	require.NoError(t, err)
+
+	_, err = im.Walk(issue.WalkParams{Root: root})
+	var fileErrs issue.FileErrors
+	require.ErrorAs(t, err, &fileErrs)
+
+	paths, titles := make([]string, 0), make([]string, 0)
+	for _, fileErr := range fileErrs {
+		paths = append(paths, fileErr.Path)
+	}
+	for _, is := range im.GetIssues() {
+		titles = append(titles, is.Title)
+	}
+
+	if readable {
+		require.Equal(t, []string{filepath.Join(root, "broken.c")}, paths)
+		require.ElementsMatch(t, []string{"main", "util", "secret"}, titles)
+		return
+	}
+
+	require.Equal(t, []string{filepath.Join(root, "broken.c"), secret}, paths)
+	require.ErrorIs(t, err, fs.ErrPermission)
+	require.ElementsMatch(t, []string{"main", "util"}, titles)
+}
+
+// should call Progress once for every file that is scanned, with the number of files that
+// were scanned so far
+func TestWalkProgress(t *testing.T) {
+	fsys := mapFS(".", map[string]string{
+		".gitignore":  "gen/\n",
+		"main.c":      "// @TEST_TODO main\n",
+		"lib/util.c":  "// @TEST_TODO util\n",
+		"lib/util.go": "package lib\n",
+		"gen/types.c": "// @TEST_TODO ignored\n",
+		"notes.txt":   "@TEST_TODO not a supported language\n",
+	})
+
+	im, err := issue.NewIssueManager(issue.PENDING_ISSUE, annotation)
+	require.NoError(t, err)
+
+	counts, paths := make([]int, 0), make([]string, 0)
+	stats := &issue.WalkStats{}
+	_, err = im.Walk(issue.WalkParams{
+		Root:  ".",
+		FS:    fsys,
+		Stats: stats,
+		Progress: func(processed int, path string) {
+			counts = append(counts, processed)
+			paths = append(paths, path)
+		},
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, 3, stats.Scanned)
+	require.Equal(t, []int{1, 2, 3}, counts)
+	require.ElementsMatch(t, []string{
+		"main.c",
+		"lib/util.c",
+		"lib/util.go",
+	}, paths)
+}
+
+// should report the progress of a walk at a bounded rate, with a final event that has the
+// counts of the whole walk, and never call OnProgress concurrently or after Walk returns
+func TestWalkOnProgress(t *testing.T) {
+	files := map[string]string{
+		".gitignore":  "gen/\n",
+		"gen/types.c": "// @TEST_TODO ignored\n",
+		"notes.txt":   "@TEST_TODO not a supported language\n",
+	}
+	for i := 0; i < 30; i++ {
+		files[fmt.Sprintf("pkg/p%02d.c", i)] = "// @TEST_TODO first\n// @TEST_TODO second\n"
+	}
+	fsys := mapFS(".", files)
+
+	walk := func(interval time.Duration, stats *issue.WalkStats) []issue.ProgressEvent {
+		im, err := issue.NewIssueManager(issue.PENDING_ISSUE, annotation)
+		require.NoError(t, err)
+
+		var calls, running atomic.Int32
+		events := make([]issue.ProgressEvent, 0)
+		_, err = im.Walk(issue.WalkParams{
+			Root:             ".",
+			FS:               fsys,
+			Workers:          8,
+			Stats:            stats,
+			ProgressInterval: interval,
+			OnProgress: func(event issue.ProgressEvent) {
+				calls.Add(1)
+				require.Equal(t, int32(1), running.Add(1), "called concurrently")
+				time.Sleep(time.Millisecond)
+				events = append(events, event)
+				running.Add(-1)
+			},
+		})
+		require.NoError(t, err)
+
+		returned := calls.Load()
+		time.Sleep(10 * time.Millisecond)
+		require.Equal(t, returned, calls.Load(), "called after Walk returned")
+		return events
+	}
+
+	// the workers finish the files in any order, so the path of the last event varies
+	final := issue.ProgressEvent{Scanned: 30, Skipped: 1, Issues: 60}
+	events := walk(time.Hour, nil)
+	require.Len(t, events, 2)
+	require.Equal(t, 1, events[0].Scanned)
+	require.Equal(t, final.Scanned, events[1].Scanned)
+	require.Equal(t, final.Skipped, events[1].Skipped)
+	require.Equal(t, final.Issues, events[1].Issues)
+
+	// the counts of an earlier walk that shared the stats are left out
+	stats := &issue.WalkStats{}
+	walk(time.Hour, stats)
+	events = walk(time.Nanosecond, stats)
+	require.NotEmpty(t, events)
+	for i := 1; i < len(events); i++ {
+		require.Greater(t, events[i].Scanned, events[i-1].Scanned)
+	}
+	last := events[len(events)-1]
+	require.Equal(t, final.Scanned, last.Scanned)
+	require.Equal(t, final.Skipped, last.Skipped)
+	require.Equal(t, final.Issues, last.Issues)
+}
+
+// should locate the same issues, in the same order, regardless of the number of workers.
+// Issues are ordered by path, one component at a time, and then by line.
+func TestWalkWorkers(t *testing.T) {
+	files := map[string]string{
+		"a.c":      "// @TEST_TODO a.c first\nint x;\n// @TEST_TODO a.c second\n",
+		"a/main.c": "// @TEST_TODO a/main.c\n",
+	}
+	for i := 0; i < 40; i++ {
+		files[fmt.Sprintf("pkg/p%02d/file.c", i)] = fmt.Sprintf("int x;\n// @TEST_TODO p%02d\n", i)
+	}
+	fsys := mapFS(".", files)
+
+	walk := func(workers int) []issue.Issue {
+		im, err := issue.NewIssueManager(issue.PENDING_ISSUE, annotation)
+		require.NoError(t, err)
+
+		stats := &issue.WalkStats{}
+		_, err = im.Walk(issue.WalkParams{Root: ".", FS: fsys, Workers: workers, Stats: stats})
+		require.NoError(t, err)
+		require.Equal(t, len(files), stats.Scanned)
+		return im.GetIssues()
+	}
+
+	issues := walk(1)
+	require.Len(t, issues, 43)
+	require.Equal(t, "a/main.c", issues[0].Title)
+	require.Equal(t, "a.c first", issues[1].Title)
+	require.Equal(t, "a.c second", issues[2].Title)
+	require.Equal(t, "p00", issues[3].Title)
+	require.Equal(t, "p39", issues[42].Title)
+
+	for _, workers := range []int{0, 8} {
+		require.Equal(t, issues, walk(workers), "%d workers", workers)
+	}
+}
+
+// should return the same issues, in the same order, every time a fixture tree is walked
+func TestWalkDeterministic(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"z.c":          "/* @ISSUE(#2) second */ /* @ISSUE(#1) first */\n",
+		"a.c":          "int x;\n// @ISSUE(#3) a.c\n",
+		"a/b/main.c":   "// @ISSUE(#4) a/b/main.c\n",
+		"pkg/util.go":  "package pkg\n\n// @ISSUE(#5) util\n",
+		"pkg/cmd/x.go": "package cmd\n\n// @ISSUE(#6) x\n// @ISSUE(#7) y\n",
+	})
+
+	walk := func() []issue.Issue {
+		im, err := issue.NewIssueManager(issue.PROCESSED_ISSUE, annotation)
+		require.NoError(t, err)
+		_, err = im.Walk(issue.WalkParams{Root: root, Workers: 8})
+		require.NoError(t, err)
+		return im.GetIssues()
+	}
+
+	issues := walk()
+	titles := make([]string, 0, len(issues))
+	for _, is := range issues {
+		titles = append(titles, is.Title)
+	}
+	require.Equal(t, []string{"a/b/main.c", "a.c", "x", "y", "util", "first", "second"}, titles)
+	require.Equal(t, issues, walk())
+}
+
+// should order issues by path, then by line and then by annotation
+func TestCompareIssues(t *testing.T) {
+	a := issue.Issue{FilePath: "a/main.c", LineNumber: 2, Annotation: "@TODO"}
+	for _, tc := range []struct {
+		b        issue.Issue
+		expected int
+	}{
+		{issue.Issue{FilePath: "a.c", LineNumber: 1, Annotation: "@TODO"}, -1},
+		{issue.Issue{FilePath: "a/main.c", LineNumber: 3, Annotation: "@FIXME"}, -1},
+		{issue.Issue{FilePath: "a/main.c", LineNumber: 1, Annotation: "@TODO"}, 1},
+		{issue.Issue{FilePath: "a/main.c", LineNumber: 2, Annotation: "@FIXME"}, 1},
+		{issue.Issue{FilePath: "a/main.c", LineNumber: 2, Annotation: "@TODO"}, 0},
+	} {
+		actual := issue.CompareIssues(a, tc.b)
+		require.Equal(t, tc.expected, sign(actual), "%+v", tc.b)
+		require.Equal(t, -tc.expected, sign(issue.CompareIssues(tc.b, a)), "%+v", tc.b)
+	}
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	default:
+		return 0
+	}
+}
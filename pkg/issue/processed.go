@@ -1,31 +1,110 @@
 package issue
 
-/*
-* @TODO implement the processed issues logic
-* The functions implementations should satisfy the IssueManager interface and allow the
-* user of the program to walk the project, and locate comments/issues that have
-* been reported. This will be helpful in later functionality where we can remove
-* comments once issues have been marked as resolved.
- */
+import (
+	"regexp"
+	"strings"
+)
 
+// ISSUE_ANNOTATION is the annotation that is written in place of the annotation of a
+// pending issue once it has been reported, followed by the reference to the issue in
+// parentheses, such as @ISSUE(#12)
+const ISSUE_ANNOTATION = "@ISSUE"
+
+// processed_pattern matches the annotations that were written back by WriteIssueRef and
+// captures their reference
+var processed_pattern = regexp.QuoteMeta(ISSUE_ANNOTATION) + `\(([^()\s]+)\)`
+
+var processed_re = regexp.MustCompile("^" + processed_pattern + "$")
+
+// issued_ref_pattern matches the reference to an issue that follows an annotation and
+// captures it, such as #42 in @TODO(#42), @TODO (#42) or @TODO[#42]. The reference is a
+// number that may be qualified by owner/repo, so @TODO(alice) still names an assignee.
+var issued_ref_pattern = `\s*[(\[]\s*((?:[\w.-]+/[\w.-]+)?#?\d+)\s*[)\]]`
+
+var (
+	issued_ref_re    = regexp.MustCompile("^" + issued_ref_pattern)
+	issued_suffix_re = regexp.MustCompile(issued_ref_pattern + "$")
+)
+
+// ProcessedIssue locates the comments whose annotation was replaced with @ISSUE(<ref>),
+// since the issue was reported, so they can be compared with the issues that are still
+// open. Annotation is the annotation of the pending issues, the annotations that are
+// followed by a reference by hand, such as @TODO(#42), are located as well when it's set.
+// The annotation of each processed issue is the annotation that was located, see IssueRef.
 type ProcessedIssue struct {
 	Annotation string
 	Issues     []Issue
 }
 
-func (pi *ProcessedIssue) Walk(root string) (int, error) {
-	n := 0
-	return n, nil
+// Walk locates the processed issues of the project, see PendingIssue.Walk. params.Cache is
+// not used, since it holds the issues of the pending annotation.
+func (pi *ProcessedIssue) Walk(params WalkParams) (int, error) {
+	params.Cache = nil
+	pending := pi.pending()
+	n, err := pending.Walk(params)
+	pi.Issues = pending.Issues
+	return n, err
 }
 
 func (pi *ProcessedIssue) Scan(src []byte, path string) error {
-	return nil
+	pending := pi.pending()
+	err := pending.Scan(src, path)
+	pi.Issues = pending.Issues
+	return err
 }
 
 func (pi *ProcessedIssue) GetIssues() []Issue {
 	return pi.Issues
 }
 
-func (pi *ProcessedIssue) WriteIssueID(id int64, issueIndex int) error {
+// WriteIssueRef is a no-op, processed issues already refer to the issue they were
+// reported in
+func (pi *ProcessedIssue) WriteIssueRef(ref string, issueIndex int) error {
 	return nil
 }
+
+// pending returns the issue manager that locates the processed annotations, it appends
+// to the issues that were located so far
+func (pi *ProcessedIssue) pending() *PendingIssue {
+	pattern := processed_pattern
+	if pi.Annotation != "" {
+		pattern += "|(?:" + pi.Annotation + ")" + issued_ref_pattern
+	}
+	return &PendingIssue{Annotation: pattern, Issues: pi.Issues}
+}
+
+// IssueRef returns the reference of a processed annotation, #12 for @ISSUE(#12) or
+// @TODO(#12). ok is false when annotation is not a processed annotation.
+func IssueRef(annotation string) (ref string, ok bool) {
+	annotation = strings.TrimSpace(annotation)
+	if match := processed_re.FindStringSubmatch(annotation); match != nil {
+		return match[1], true
+	}
+
+	if match := issued_suffix_re.FindStringSubmatch(annotation); match != nil {
+		return match[1], true
+	}
+	return "", false
+}
+
+// ParseAnnotation locates the first annotation token in line, token is a pattern like the
+// annotation of an IssueManager, and returns the reference to the issue that follows it.
+// issued is false when the annotation is not followed by a reference, such as @TODO, and
+// true for @TODO(#42), @TODO (#42) and @TODO[#42], whose ref is #42.
+func ParseAnnotation(line, token string) (ref string, issued bool) {
+	re, err := regexp.Compile(token)
+	if err != nil {
+		return "", false
+	}
+
+	loc := re.FindStringIndex(line)
+	if loc == nil {
+		return "", false
+	}
+
+	match := issued_ref_re.FindStringSubmatch(line[loc[1]:])
+	if match == nil {
+		return "", false
+	}
+	return match[1], true
+}
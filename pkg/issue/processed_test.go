@@ -0,0 +1,113 @@
+package issue_test
+
+import (
+	"testing"
+
+	"github.com/AntoninoAdornetto/issue-summoner/pkg/issue"
+	"github.com/stretchr/testify/require"
+)
+
+// should locate the annotations that were written back once their issue was reported
+func TestProcessedIssueScan(t *testing.T) {
+	src := []byte(`package main
+
+// @ISSUE(#12) add retries
+func main() {
+	// @TEST_TODO not reported yet
+	/* @ISSUE(my-group/project#7) cache the results */
+	println("// @ISSUE(#3) inside of a string")
+}
+`)
+
+	im, err := issue.NewIssueManager(issue.PROCESSED_ISSUE, annotation)
+	require.NoError(t, err)
+	require.NoError(t, im.Scan(src, "main.go"))
+
+	issues := im.GetIssues()
+	require.Len(t, issues, 2)
+	require.Equal(t, "@ISSUE(#12)", issues[0].Annotation)
+	require.Equal(t, "add retries", issues[0].Title)
+	require.Equal(t, "@ISSUE(my-group/project#7)", issues[1].Annotation)
+	require.Equal(t, "cache the results", issues[1].Title)
+}
+
+// should return the reference of a processed annotation
+func TestIssueRef(t *testing.T) {
+	for _, tc := range []struct {
+		annotation, expected string
+		ok                   bool
+	}{
+		{"@ISSUE(#12)", "#12", true},
+		{"@ISSUE(my-group/project#7)", "my-group/project#7", true},
+		{"@ISSUE(1999)", "1999", true},
+		{"@ISSUE()", "", false},
+		{"@TODO", "", false},
+		{"@TODO(#42)", "#42", true},
+		{"@TODO [#42]", "#42", true},
+	} {
+		ref, ok := issue.IssueRef(tc.annotation)
+		require.Equal(t, tc.ok, ok, tc.annotation)
+		require.Equal(t, tc.expected, ref, tc.annotation)
+	}
+}
+
+// should return the reference that follows the first annotation of the line
+func TestParseAnnotation(t *testing.T) {
+	for _, tc := range []struct {
+		line, ref string
+		issued    bool
+	}{
+		{"// @TODO fix the race", "", false},
+		{"// @TODO(#42) fix the race", "#42", true},
+		{"// @TODO (#42) fix the race", "#42", true},
+		{"// @TODO[#42] fix the race", "#42", true},
+		{"// @TODO( 42 ) fix the race", "42", true},
+		{"// @TODO(tech/debt#42) fix the race", "tech/debt#42", true},
+		{"// @TODO(alice) fix the race", "", false},
+		{"// @TODO fix the race, see @TODO(#42)", "", false},
+		{"// @FIXME(#42) fix the race", "", false},
+	} {
+		ref, issued := issue.ParseAnnotation(tc.line, "@TODO")
+		require.Equal(t, tc.issued, issued, tc.line)
+		require.Equal(t, tc.ref, ref, tc.line)
+	}
+
+	ref, issued := issue.ParseAnnotation("# @HACK[#7] remove", "@FIXME|@HACK")
+	require.True(t, issued)
+	require.Equal(t, "#7", ref)
+}
+
+// should only locate the annotations that are not followed by a reference in pending mode
+// and locate them in processed mode
+func TestScanIssuedAnnotations(t *testing.T) {
+	src := []byte(`package main
+
+// @TEST_TODO not reported yet
+// @TEST_TODO(#42) reported by hand
+/* @TEST_TODO [#43] reported in a block comment */
+// @TEST_TODO(alice) assigned rather than reported
+func main() {}
+`)
+
+	pending, err := issue.NewIssueManager(issue.PENDING_ISSUE, annotation)
+	require.NoError(t, err)
+	require.NoError(t, pending.Scan(src, "main.go"))
+
+	titles := make([]string, 0)
+	for _, is := range pending.GetIssues() {
+		titles = append(titles, is.Title)
+	}
+	require.Equal(t, []string{"not reported yet", "(alice) assigned rather than reported"}, titles)
+
+	processed, err := issue.NewIssueManager(issue.PROCESSED_ISSUE, annotation)
+	require.NoError(t, err)
+	require.NoError(t, processed.Scan(src, "main.go"))
+
+	refs := make([]string, 0)
+	for _, is := range processed.GetIssues() {
+		ref, ok := issue.IssueRef(is.Annotation)
+		require.True(t, ok, is.Annotation)
+		refs = append(refs, ref)
+	}
+	require.Equal(t, []string{"#42", "#43"}, refs)
+}
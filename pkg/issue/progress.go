@@ -0,0 +1,62 @@
+package issue
+
+import "time"
+
+// DEFAULT_PROGRESS_INTERVAL is the shortest time between two calls of OnProgress, unless
+// WalkParams.ProgressInterval is set
+const DEFAULT_PROGRESS_INTERVAL = 100 * time.Millisecond
+
+// ProgressEvent is the state of a walk that is passed to OnProgress. Path is the file that
+// was scanned last. Scanned and Skipped are the number of files that were scanned and
+// skipped by the walk so far, see WalkStats, and Issues is the number of issues that were
+// located so far.
+type ProgressEvent struct {
+	Path    string
+	Scanned int
+	Skipped int
+	Issues  int
+}
+
+// progressReporter passes the events of a walk to fn at a bounded rate. The first event is
+// reported right away, the events that follow are dropped until interval has passed since
+// the last report. The last event of the walk is reported by done.
+type progressReporter struct {
+	fn       func(ProgressEvent)
+	interval time.Duration
+	last     time.Time
+	event    ProgressEvent
+	pending  bool // event was not reported yet
+}
+
+func newProgressReporter(fn func(ProgressEvent), interval time.Duration) *progressReporter {
+	if interval <= 0 {
+		interval = DEFAULT_PROGRESS_INTERVAL
+	}
+	return &progressReporter{fn: fn, interval: interval}
+}
+
+// report passes event to fn unless an event was reported less than interval ago, r may be
+// nil
+func (r *progressReporter) report(event ProgressEvent) {
+	if r == nil {
+		return
+	}
+
+	r.event, r.pending = event, true
+	if now := time.Now(); r.last.IsZero() || now.Sub(r.last) >= r.interval {
+		r.last = now
+		r.flush()
+	}
+}
+
+// done reports the last event, unless it was reported already, r may be nil
+func (r *progressReporter) done() {
+	if r != nil && r.pending {
+		r.flush()
+	}
+}
+
+func (r *progressReporter) flush() {
+	r.pending = false
+	r.fn(r.event)
+}
@@ -0,0 +1,26 @@
+package issue
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/AntoninoAdornetto/issue-summoner/pkg/lexer"
+)
+
+// ScanReader reads the source code of a single file from r, such as the buffer of an
+// editor that was not saved yet, and scans it with im. name is the path that the issues
+// are reported with, its extension, or its name, selects the lexer. Unlike Walk, a language that is not
+// supported is an error.
+func ScanReader(im IssueManager, r io.Reader, name string) error {
+	ext := lexer.FileType(name)
+	if !lexer.IsSupported(ext) {
+		return fmt.Errorf("unsupported file type of %q. please open a feature request if you would like support.", ext)
+	}
+
+	src, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	return im.Scan(src, name)
+}
@@ -0,0 +1,56 @@
+package issue_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/AntoninoAdornetto/issue-summoner/pkg/issue"
+	"github.com/stretchr/testify/require"
+)
+
+// should scan source code that is read from a reader rather than a file
+func TestScanReader(t *testing.T) {
+	src := []byte(`package main
+
+// @TEST_TODO read from stdin
+func main() {
+	println("// @TEST_TODO inside of a string")
+	/* @TEST_TODO block comment */
+}
+`)
+
+	im, err := issue.NewIssueManager(issue.PENDING_ISSUE, annotation)
+	require.NoError(t, err)
+	require.NoError(t, issue.ScanReader(im, bytes.NewReader(src), "stdin.go"))
+
+	issues := im.GetIssues()
+	require.Len(t, issues, 2)
+	require.Equal(t, "read from stdin", issues[0].Title)
+	require.Equal(t, 3, issues[0].LineNumber)
+	require.Equal(t, "stdin.go", issues[0].FilePath)
+	require.Equal(t, "block comment", issues[1].Title)
+}
+
+// should return an error for languages that are not supported
+func TestScanReaderUnsupported(t *testing.T) {
+	im, err := issue.NewIssueManager(issue.PENDING_ISSUE, annotation)
+	require.NoError(t, err)
+	require.Error(t, issue.ScanReader(im, bytes.NewReader(nil), "stdin.unknown"))
+}
+
+// should scan the file that meta describes from a reader and skip binary content
+func TestScanFile(t *testing.T) {
+	pi := &issue.PendingIssue{Annotation: annotation}
+	meta := issue.FileMeta{Name: "lib/util.c", Size: 36}
+	err := pi.ScanFile(strings.NewReader("int x;\n// @TEST_TODO read from fs\n"), meta)
+	require.NoError(t, err)
+	require.Len(t, pi.Issues, 1)
+	require.Equal(t, "read from fs", pi.Issues[0].Title)
+	require.Equal(t, "lib/util.c", pi.Issues[0].FilePath)
+	require.Equal(t, 2, pi.Issues[0].LineNumber)
+
+	err = pi.ScanFile(strings.NewReader("\x00// @TEST_TODO binary\n"), issue.FileMeta{Name: "blob.c"})
+	require.NoError(t, err)
+	require.Len(t, pi.Issues, 1)
+}
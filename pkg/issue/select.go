@@ -0,0 +1,48 @@
+package issue
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+
+	"github.com/AntoninoAdornetto/issue-summoner/pkg/ui"
+)
+
+// SelectItems returns the items that let the user choose which issues to report with the
+// multi select model of the ui package. Only the issues that include returns true for are
+// listed, or every issue when include is nil. The ID of an item is the index of its issue,
+// since the ID of an issue is not unique across files that share a name, and the
+// description is the location of the issue relative to root.
+func SelectItems(issues []Issue, root string, include func(Issue) bool) []ui.Item {
+	items := make([]ui.Item, 0, len(issues))
+	for i, is := range issues {
+		if include != nil && !include(is) {
+			continue
+		}
+
+		path := is.FilePath
+		if rel, err := filepath.Rel(root, is.FilePath); err == nil {
+			path = rel
+		}
+
+		items = append(items, ui.Item{
+			ID:    strconv.Itoa(i),
+			Title: is.Title,
+			Desc:  fmt.Sprintf("%s:%d", path, is.LineNumber),
+		})
+	}
+
+	return items
+}
+
+// Selected returns the indices of the issues whose item, see SelectItems, was selected in
+// ascending order
+func Selected(issues []Issue, selection *ui.Selection) []int {
+	indices := make([]int, 0)
+	for i := range issues {
+		if selection.Options[strconv.Itoa(i)] {
+			indices = append(indices, i)
+		}
+	}
+	return indices
+}
@@ -0,0 +1,41 @@
+package issue_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/AntoninoAdornetto/issue-summoner/pkg/issue"
+	"github.com/AntoninoAdornetto/issue-summoner/pkg/ui"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/require"
+)
+
+// should list the issues that are included, with their location as the description, and map
+// the selected items back to their issues even when issues share an ID
+func TestSelectItems(t *testing.T) {
+	root := filepath.Join("home", "repo")
+	issues := []issue.Issue{
+		{ID: "main.go-0:20", Title: "first", FilePath: filepath.Join(root, "main.go"), LineNumber: 1},
+		{ID: "main.go-0:20", Title: "second", FilePath: filepath.Join(root, "cmd", "main.go"), LineNumber: 1},
+		{ID: "util.c-5:30", Title: "filtered", FilePath: filepath.Join(root, "util.c"), LineNumber: 2},
+		{ID: "lib.go-8:40", Title: "third", FilePath: filepath.Join(root, "lib.go"), LineNumber: 7},
+	}
+
+	items := issue.SelectItems(issues, root, func(is issue.Issue) bool { return is.Title != "filtered" })
+	require.Equal(t, []ui.Item{
+		{ID: "0", Title: "first", Desc: "main.go:1"},
+		{ID: "1", Title: "second", Desc: filepath.Join("cmd", "main.go") + ":1"},
+		{ID: "3", Title: "third", Desc: "lib.go:7"},
+	}, items)
+
+	quit := false
+	selection := &ui.Selection{Options: make(map[string]bool)}
+	var m tea.Model = ui.InitialModelMultiSelect(items, selection, "", &quit, 0)
+	// select the second and third items and confirm
+	for _, key := range []string{"j", " ", "j", " ", "y"} {
+		m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)})
+	}
+
+	require.False(t, quit)
+	require.Equal(t, []int{1, 3}, issue.Selected(issues, selection))
+}
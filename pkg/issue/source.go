@@ -0,0 +1,82 @@
+package issue
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// SNIPPET_CONTEXT_LINES is how many lines before and after an annotation are included in
+// the code snippet of an issue
+const SNIPPET_CONTEXT_LINES = 3
+
+// Source is the code surrounding an annotation, a permalink to it on the web ui of the
+// platform and the author of the annotation, that are rendered in the body of an issue. Fence is a code fence that is
+// longer than any run of backticks in the snippet, so the snippet can't close it.
+type Source struct {
+	Snippet   string
+	Language  string
+	Fence     string
+	StartLine int
+	EndLine   int
+	Permalink string
+	Author    string
+}
+
+// NewSource reads the lines of the annotation from src, along with context lines before
+// and after it. The line numbers are 1 based and are clamped to the lines of src.
+func NewSource(src []byte, path string, line, endLine, context int) Source {
+	lines := strings.Split(strings.TrimRight(string(bytes.ReplaceAll(src, []byte("\r\n"), []byte("\n"))), "\n"), "\n")
+	if endLine < line {
+		endLine = line
+	}
+
+	start := max(1, line-context)
+	end := min(len(lines), endLine+context)
+	if start > end {
+		return Source{}
+	}
+
+	snippet := strings.Join(lines[start-1:end], "\n")
+	return Source{
+		Snippet:   snippet,
+		Language:  strings.TrimPrefix(filepath.Ext(path), "."),
+		Fence:     fence(snippet),
+		StartLine: start,
+		EndLine:   end,
+	}
+}
+
+func fence(snippet string) string {
+	longest, run := 0, 0
+	for _, ch := range snippet {
+		if ch == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	return strings.Repeat("`", max(3, longest+1))
+}
+
+// Permalink links to the lines of a file on the web ui of GitHub. path is relative to the
+// root of the repository.
+func Permalink(webURL, owner, repo, ref, path string, start, end int) string {
+	link := fmt.Sprintf(
+		"%s/%s/%s/blob/%s/%s#L%d",
+		strings.TrimSuffix(webURL, "/"),
+		owner,
+		repo,
+		ref,
+		filepath.ToSlash(path),
+		start,
+	)
+
+	if end > start {
+		link += fmt.Sprintf("-L%d", end)
+	}
+
+	return link
+}
@@ -0,0 +1,62 @@
+package issue_test
+
+import (
+	"testing"
+
+	"github.com/AntoninoAdornetto/issue-summoner/pkg/issue"
+	"github.com/stretchr/testify/require"
+)
+
+const source = `package main
+
+import "fmt"
+
+// @TODO add a flag for the greeting
+// so that it can be customized
+func main() {
+	fmt.Println("hello")
+}
+`
+
+// should include the lines of the annotation and the context lines around it
+func TestNewSource(t *testing.T) {
+	src := issue.NewSource([]byte(source), "cmd/main.go", 5, 6, 2)
+	require.Equal(t, issue.Source{
+		Snippet:   "import \"fmt\"\n\n// @TODO add a flag for the greeting\n// so that it can be customized\nfunc main() {\n\tfmt.Println(\"hello\")",
+		Language:  "go",
+		Fence:     "```",
+		StartLine: 3,
+		EndLine:   8,
+	}, src)
+}
+
+// should clamp the context lines to the start and end of the file
+func TestNewSourceBounds(t *testing.T) {
+	src := issue.NewSource([]byte("// @TODO one\nx := 1\n"), "main.go", 1, 1, 3)
+	require.Equal(t, 1, src.StartLine)
+	require.Equal(t, 2, src.EndLine)
+	require.Equal(t, "// @TODO one\nx := 1", src.Snippet)
+
+	require.Equal(t, issue.Source{}, issue.NewSource([]byte("x\n"), "main.go", 10, 10, 3))
+}
+
+// should use a fence that is longer than the backticks in the snippet
+func TestNewSourceFence(t *testing.T) {
+	src := issue.NewSource([]byte("// @TODO document ```go blocks\n"), "README.md", 1, 1, 0)
+	require.Equal(t, "````", src.Fence)
+	require.Equal(t, "md", src.Language)
+}
+
+// should link to a single line or a range of lines
+func TestPermalink(t *testing.T) {
+	require.Equal(
+		t,
+		"https://github.com/tech/debt/blob/main/cmd/main.go#L5",
+		issue.Permalink("https://github.com/", "tech", "debt", "main", "cmd/main.go", 5, 5),
+	)
+	require.Equal(
+		t,
+		"https://github.com/tech/debt/blob/main/cmd/main.go#L5-L6",
+		issue.Permalink("https://github.com", "tech", "debt", "main", "cmd/main.go", 5, 6),
+	)
+}
@@ -0,0 +1,37 @@
+package issue
+
+import "fmt"
+
+const (
+	STATUS_PENDING  = "PENDING"
+	STATUS_REPORTED = "REPORTED"
+	STATUS_RESOLVED = "RESOLVED"
+)
+
+// Status describes whether an annotation has been reported and, if so, the state of the
+// issue that was created for it. Number is zero for annotations that are pending.
+type Status struct {
+	Number int
+	State  string
+	URL    string
+}
+
+// Kind returns STATUS_PENDING, STATUS_REPORTED for open issues, or STATUS_RESOLVED for
+// closed issues
+func (s Status) Kind() string {
+	switch {
+	case s.Number == 0:
+		return STATUS_PENDING
+	case s.State == "closed":
+		return STATUS_RESOLVED
+	default:
+		return STATUS_REPORTED
+	}
+}
+
+func (s Status) String() string {
+	if s.Number == 0 {
+		return STATUS_PENDING
+	}
+	return fmt.Sprintf("%s(#%d %s)", s.Kind(), s.Number, s.State)
+}
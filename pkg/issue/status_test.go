@@ -0,0 +1,16 @@
+package issue_test
+
+import (
+	"testing"
+
+	"github.com/AntoninoAdornetto/issue-summoner/pkg/issue"
+	"github.com/stretchr/testify/require"
+)
+
+// should describe pending, open and closed issues
+func TestStatusString(t *testing.T) {
+	require.Equal(t, "PENDING", issue.Status{}.String())
+	require.Equal(t, "REPORTED(#123 open)", issue.Status{Number: 123, State: "open"}.String())
+	require.Equal(t, "RESOLVED(#7 closed)", issue.Status{Number: 7, State: "closed"}.String())
+	require.Equal(t, issue.STATUS_RESOLVED, issue.Status{Number: 7, State: "closed"}.Kind())
+}
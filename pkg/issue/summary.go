@@ -0,0 +1,117 @@
+package issue
+
+import (
+	"path/filepath"
+	"sort"
+)
+
+// WalkStats counts the files that a walk visited. Scanned files were searched for
+// annotations, including the files whose issues were reused from the cache. Skipped files
+// were matched by an ignore file, did not match the include patterns or are written in a
+// language that is not supported. The files beneath an ignored directory are not counted
+// since the walk never visits them. SkippedFiles are the files that were skipped even
+// though they are not ignored, such as binary files. Hidden is the number of hidden files
+// and directories that were pruned, they are not counted as skipped.
+type WalkStats struct {
+	Scanned      int
+	Skipped      int
+	Hidden       int
+	SkippedFiles []SkippedFile
+}
+
+// The reasons that a file which is not ignored is skipped
+const (
+	SKIP_BINARY         = "binary"
+	SKIP_SIZE           = "larger than the max file size"
+	SKIP_SYMLINK        = "symlink"
+	SKIP_BROKEN_SYMLINK = "broken symlink"
+	SKIP_VISITED        = "already scanned through another path"
+)
+
+// SkippedFile is a file that was not scanned and the reason it was skipped
+type SkippedFile struct {
+	Path   string
+	Reason string
+}
+
+// scan counts a scanned file, stats may be nil
+func (stats *WalkStats) scan() {
+	if stats != nil {
+		stats.Scanned++
+	}
+}
+
+// skip counts a skipped file, stats may be nil
+func (stats *WalkStats) skip() {
+	if stats != nil {
+		stats.Skipped++
+	}
+}
+
+// hide counts a hidden file or directory that was pruned, stats may be nil
+func (stats *WalkStats) hide() {
+	if stats != nil {
+		stats.Hidden++
+	}
+}
+
+// skipFile counts a skipped file that is not ignored, stats may be nil
+func (stats *WalkStats) skipFile(path, reason string) {
+	if stats != nil {
+		stats.Skipped++
+		stats.SkippedFiles = append(stats.SkippedFiles, SkippedFile{Path: path, Reason: reason})
+	}
+}
+
+// Summary is a breakdown of the issues that were found during a walk
+type Summary struct {
+	Scanned     int            `json:"scanned"`
+	Skipped     int            `json:"skipped"`
+	Hidden      int            `json:"hidden"`
+	Total       int            `json:"total"`
+	Languages   map[string]int `json:"languages"`
+	Annotations map[string]int `json:"annotations"`
+}
+
+// Summarize groups the issues by the extension of their file, or the file name when it
+// doesn't have one, and by annotation
+func Summarize(issues []Issue, stats WalkStats) Summary {
+	summary := Summary{
+		Scanned:     stats.Scanned,
+		Skipped:     stats.Skipped,
+		Hidden:      stats.Hidden,
+		Total:       len(issues),
+		Languages:   make(map[string]int),
+		Annotations: make(map[string]int),
+	}
+
+	for _, is := range issues {
+		language := filepath.Ext(is.FileName)
+		if language == "" {
+			language = is.FileName
+		}
+
+		summary.Languages[language]++
+		summary.Annotations[is.Annotation]++
+	}
+
+	return summary
+}
+
+// SortedCounts returns the keys of counts ordered by count, from the highest to the
+// lowest. Keys with the same count are ordered alphabetically.
+func SortedCounts(counts map[string]int) []string {
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+
+	return keys
+}
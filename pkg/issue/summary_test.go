@@ -0,0 +1,49 @@
+package issue_test
+
+import (
+	"testing"
+
+	"github.com/AntoninoAdornetto/issue-summoner/pkg/issue"
+	"github.com/stretchr/testify/require"
+)
+
+// should count the files that were scanned and skipped and group the issues by language
+// and annotation
+func TestSummarize(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".gitignore":     "build/\n*.gen.go\n",
+		"main.go":        "// @TEST_TODO one\n// @TEST_TODO two\n",
+		"api.gen.go":     "// @TEST_TODO ignored\n",
+		"lib/util.c":     "/* @TEST_TODO three */\n",
+		"lib/notes.txt":  "@TEST_TODO not a supported language\n",
+		"script/run.sh":  "# @TEST_TODO four\n",
+		"build/out.c":    "// @TEST_TODO never visited\n",
+		"script/deploy":  "# @TEST_TODO no extension\n",
+		"script/util.sh": "echo no issues\n",
+	})
+
+	im, err := issue.NewIssueManager(issue.PENDING_ISSUE, annotation)
+	require.NoError(t, err)
+
+	stats := &issue.WalkStats{}
+	_, err = im.Walk(issue.WalkParams{Root: root, Stats: stats})
+	require.NoError(t, err)
+
+	summary := issue.Summarize(im.GetIssues(), *stats)
+	// main.go, util.c, run.sh and util.sh
+	require.Equal(t, 4, summary.Scanned)
+	// api.gen.go, notes.txt and deploy
+	require.Equal(t, 3, summary.Skipped)
+	// .gitignore
+	require.Equal(t, 1, summary.Hidden)
+	require.Equal(t, 4, summary.Total)
+	require.Equal(t, map[string]int{".go": 2, ".c": 1, ".sh": 1}, summary.Languages)
+	require.Equal(t, map[string]int{annotation: 4}, summary.Annotations)
+}
+
+// should order the keys by count and then alphabetically
+func TestSortedCounts(t *testing.T) {
+	counts := map[string]int{".c": 1, ".go": 3, ".sh": 1, ".rb": 2}
+	require.Equal(t, []string{".go", ".rb", ".c", ".sh"}, issue.SortedCounts(counts))
+}
@@ -0,0 +1,2 @@
+// @TEST_TODO text fixture
+int main() { return 0; }
@@ -0,0 +1,420 @@
+package lexer_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/AntoninoAdornetto/issue-summoner/pkg/lexer"
+	"github.com/stretchr/testify/require"
+)
+
+// scanFixture lexes the file in the testdata directory and returns the titles and line
+// numbers of the annotated comments
+func scanFixture(t *testing.T, name string) ([]string, []int) {
+	comments, lines := lexFixture(t, name)
+	return titles(comments), lines
+}
+
+// lexFixture lexes the file in the testdata directory and returns the annotated comments
+// along with their line numbers
+func lexFixture(t *testing.T, name string) ([]lexer.Comment, []int) {
+	src, err := os.ReadFile(filepath.Join("testdata", name))
+	require.NoError(t, err)
+	return lexSource(t, src, name)
+}
+
+// lexSource lexes src as the contents of a file named name, see lexFixture
+func lexSource(t *testing.T, src []byte, name string) ([]lexer.Comment, []int) {
+	lex, err := lexer.NewLexer(src, name)
+	require.NoError(t, err)
+	tokens, err := lex.AnalyzeTokens()
+	require.NoError(t, err)
+
+	comments, err := lex.Manager.ParseCommentTokens(lex, annotation)
+	require.NoError(t, err)
+
+	lines := make([]int, 0)
+	for _, c := range comments {
+		lines = append(lines, tokens[c.TokenIndex].Line)
+	}
+	return comments, lines
+}
+
+func titles(comments []lexer.Comment) []string {
+	titles := make([]string, 0)
+	for _, c := range comments {
+		titles = append(titles, string(c.Title))
+	}
+	return titles
+}
+
+// should locate # comments in elixir files and skip strings and heredocs
+func TestScanElixir(t *testing.T) {
+	for _, ext := range []string{".ex", ".exs"} {
+		require.True(t, lexer.IsSupported(ext), ext)
+	}
+
+	titles, lines := scanFixture(t, "main.ex")
+	require.Equal(t, []string{"single line comment", "trailing comment"}, titles)
+	require.Equal(t, []int{6, 8}, lines)
+}
+
+// should locate % comments in erlang files and skip strings
+func TestScanErlang(t *testing.T) {
+	for _, ext := range []string{".erl", ".hrl"} {
+		require.True(t, lexer.IsSupported(ext), ext)
+	}
+
+	titles, lines := scanFixture(t, "main.erl")
+	require.Equal(t, []string{"double percent comment", "trailing comment"}, titles)
+	require.Equal(t, []int{4, 6}, lines)
+}
+
+// should locate ; and #| |# comments in common lisp files
+func TestScanLisp(t *testing.T) {
+	for _, ext := range []string{".lisp", ".lsp", ".scm", ".ss", ".rkt"} {
+		require.True(t, lexer.IsSupported(ext), ext)
+	}
+
+	titles, lines := scanFixture(t, "main.lisp")
+	require.Equal(
+		t,
+		[]string{"file header comment", "indented comment", "block comment", "trailing comment"},
+		titles,
+	)
+	require.Equal(t, []int{1, 3, 6, 8}, lines)
+}
+
+// should locate ; and #| |# comments in scheme files
+func TestScanScheme(t *testing.T) {
+	titles, lines := scanFixture(t, "main.scm")
+	require.Equal(t, []string{"scheme comment", "scheme block comment"}, titles)
+	require.Equal(t, []int{2, 4}, lines)
+}
+
+// should locate ; comments in clojure files and skip strings and discarded forms
+func TestScanClojure(t *testing.T) {
+	for _, ext := range []string{".clj", ".cljs", ".cljc"} {
+		require.True(t, lexer.IsSupported(ext), ext)
+	}
+
+	titles, lines := scanFixture(t, "main.clj")
+	require.Equal(t, []string{"clojure comment", "trailing comment"}, titles)
+	require.Equal(t, []int{3, 5}, lines)
+}
+
+// should locate -- and --[[ ]] comments in lua files and skip strings and long strings.
+// a long bracket is only closed by a bracket of the same level.
+func TestScanLua(t *testing.T) {
+	titles, lines := scanFixture(t, "main.lua")
+	require.Equal(t, []string{
+		"single line comment",
+		"trailing comment",
+		"block comment",
+		"leveled block comment",
+		"after the leveled comment",
+	}, titles)
+	require.Equal(t, []int{3, 5, 8, 17, 22}, lines)
+}
+
+// should return an error when a block comment is only closed by a bracket of another level
+func TestScanLuaUnclosedComment(t *testing.T) {
+	lex, err := lexer.NewLexer([]byte("--[==[ @TEST_TODO closed by ]] and ]=]\n"), "main.lua")
+	require.NoError(t, err)
+
+	_, err = lex.AnalyzeTokens()
+	require.ErrorContains(t, err, "could not locate closing multi line comment")
+}
+
+// should locate -- and nested {- -} comments in haskell files and skip strings
+func TestScanHaskell(t *testing.T) {
+	titles, lines := scanFixture(t, "main.hs")
+	require.Equal(t, []string{
+		"single line comment",
+		"block comment",
+		"trailing comment",
+		"nested block comment",
+		"after the nested comment",
+	}, titles)
+	require.Equal(t, []int{3, 7, 10, 12, 16}, lines)
+}
+
+// should only locate the comments on the code lines of literate haskell files
+func TestScanLiterateHaskell(t *testing.T) {
+	titles, lines := scanFixture(t, "main.lhs")
+	require.Equal(t, []string{"single line comment", "block comment", "trailing comment"}, titles)
+	require.Equal(t, []int{6, 12, 14}, lines)
+}
+
+// should locate # and =begin =end comments in ruby files, the block notation only counts at
+// the start of a line
+func TestScanRuby(t *testing.T) {
+	titles, lines := scanFixture(t, "main.rb")
+	require.Equal(t, []string{
+		"single line comment",
+		"trailing comment",
+		"block comment",
+		"title after the opening notation",
+		"after the block comment",
+	}, titles)
+	require.Equal(t, []int{1, 6, 14, 19, 21}, lines)
+}
+
+// should strip the closing notation from the description of ruby block comments
+func TestScanRubyDescription(t *testing.T) {
+	comments, _ := lexSource(t, []byte("=begin\n@TEST_TODO title\n  spans lines\n=end\n"), "main.rb")
+	require.Equal(t, []string{"title"}, titles(comments))
+	require.Equal(t, "spans lines", string(comments[0].Description))
+}
+
+// should recognize ruby files without an extension by their name
+func TestScanGemfile(t *testing.T) {
+	titles, lines := scanFixture(t, "Gemfile")
+	require.Equal(t, []string{"pin the version of rake"}, titles)
+	require.Equal(t, []int{3}, lines)
+}
+
+// should return the name of the files that are recognized by their name
+func TestFileType(t *testing.T) {
+	require.Equal(t, "Gemfile", lexer.FileType("project/Gemfile"))
+	require.Equal(t, "Rakefile", lexer.FileType("Rakefile"))
+	require.Equal(t, ".rake", lexer.FileType("lib/tasks/db.rake"))
+	require.Equal(t, "Dockerfile", lexer.FileType("build/Dockerfile"))
+	require.Equal(t, "Makefile", lexer.FileType("Makefile"))
+	require.Equal(t, ".gitignore", lexer.FileType("web/.gitignore"))
+	require.Equal(t, "", lexer.FileType("LICENSE"))
+}
+
+// should locate " comments in vim script files and skip strings, a " in the position of
+// an expression starts a string rather than a comment
+func TestScanVim(t *testing.T) {
+	titles, lines := scanFixture(t, "main.vim")
+	require.Equal(t, []string{"single line comment", "indented comment", "trailing comment"}, titles)
+	require.Equal(t, []int{1, 7, 9}, lines)
+}
+
+// should locate // comments in zig files and skip strings and character literals
+func TestScanZig(t *testing.T) {
+	titles, lines := scanFixture(t, "main.zig")
+	require.Equal(t, []string{"single line comment", "trailing comment"}, titles)
+	require.Equal(t, []int{3, 6}, lines)
+}
+
+// should locate -- and /* */ comments in sql migrations and skip strings and identifiers
+func TestScanSQL(t *testing.T) {
+	titles, lines := scanFixture(t, "migration.sql")
+	require.Equal(t, []string{
+		"add an index on users.email",
+		"enforce lower case emails",
+		"backfill the created_at column",
+		"inline comment without whitespace",
+	}, titles)
+	require.Equal(t, []int{1, 4, 9, 16}, lines)
+}
+
+// should locate nested block comments in postgres files
+func TestScanPostgres(t *testing.T) {
+	titles, lines := scanFixture(t, "migration.psql")
+	require.Equal(t, []string{"drop the legacy table", "after the nested comment"}, titles)
+	require.Equal(t, []int{1, 4}, lines)
+}
+
+// should locate # comments at every indentation of yaml files, skipping quoted scalars and
+// a # that isn't preceded by whitespace
+func TestScanYaml(t *testing.T) {
+	titles, lines := scanFixture(t, "docker-compose.yml")
+	require.Equal(t, []string{
+		"pin the image versions",
+		"move the ports to an env file",
+		"trailing comment after a quoted scalar",
+		"after an apostrophe",
+		"deeply indented comment",
+	}, titles)
+	require.Equal(t, []int{1, 6, 8, 11, 15}, lines)
+}
+
+// should locate # comments in toml files and skip strings
+func TestScanToml(t *testing.T) {
+	titles, lines := scanFixture(t, "Cargo.toml")
+	require.Equal(t, []string{"rename the crate", "drop the unused feature"}, titles)
+	require.Equal(t, []int{2, 5}, lines)
+}
+
+// should only locate block comments in css files, # selectors and // in urls are not
+// comments
+func TestScanCSS(t *testing.T) {
+	titles, lines := scanFixture(t, "main.css")
+	require.Equal(t, []string{
+		"refactor this gradient",
+		"self host the image",
+		"merge with the footer styles",
+	}, titles)
+	require.Equal(t, []int{1, 4, 11}, lines)
+}
+
+// should locate // and /* */ comments in less files and skip escaped strings
+func TestScanLESS(t *testing.T) {
+	titles, lines := scanFixture(t, "main.less")
+	require.Equal(t, []string{
+		"replace the variables with css custom properties",
+		"check the contrast",
+		"drop the legacy",
+	}, titles)
+	require.Equal(t, []int{1, 6, 9}, lines)
+}
+
+// should only treat // as a comment in the stylesheets that support it
+func TestScanStylesheetSingleLine(t *testing.T) {
+	src := []byte("// @TEST_TODO not a comment in css\n/* @TEST_TODO block comment */\n")
+	expected := map[string][]string{
+		"main.css":  {"block comment"},
+		"main.scss": {"not a comment in css", "block comment"},
+		"main.less": {"not a comment in css", "block comment"},
+	}
+
+	for name, want := range expected {
+		comments, _ := lexSource(t, src, name)
+		require.Equal(t, want, titles(comments), name)
+	}
+}
+
+// should locate // and /* */ comments in scss and less files
+func TestScanSCSS(t *testing.T) {
+	src, err := os.ReadFile(filepath.Join("testdata", "main.scss"))
+	require.NoError(t, err)
+
+	for _, name := range []string{"main.scss", "main.less"} {
+		comments, _ := lexSource(t, src, name)
+		require.Equal(t, []string{
+			"move to the theme file",
+			"document the mixin",
+			"nested rule comment",
+		}, titles(comments), name)
+	}
+}
+
+// should skip the bytes that are escaped with a backslash inside of strings, except for
+// the strings of a syntax that has no escapes
+func TestScanStringEscapes(t *testing.T) {
+	cases := []struct {
+		name string
+		src  string
+		want []string
+	}{
+		{"main.rb", "x = 'it\\'s # @TEST_TODO fake'\n# @TEST_TODO real\n", []string{"real"}},
+		{"main.rb", "x = \"a \\\" # @TEST_TODO fake\"\n# @TEST_TODO real\n", []string{"real"}},
+		{"main.sh", "echo \"a \\\" # @TEST_TODO fake\"\n# @TEST_TODO real\n", []string{"real"}},
+		{"main.sh", "echo 'C:\\' # @TEST_TODO raw single quotes\n", []string{"raw single quotes"}},
+		{"main.R", "x <- 'it\\'s # @TEST_TODO fake'\n# @TEST_TODO real\n", []string{"real"}},
+		{"main.mysql", "SELECT 'it\\'s -- @TEST_TODO fake';\n-- @TEST_TODO real\n", []string{"real"}},
+		{"main.sql", "SELECT 'C:\\' -- @TEST_TODO standard sql\n", []string{"standard sql"}},
+		{"Cargo.toml", "path = 'C:\\' # @TEST_TODO literal string\n", []string{"literal string"}},
+		{"deploy.ps1", "$path = \"C:\\\" # @TEST_TODO backtick escapes\n", []string{"backtick escapes"}},
+	}
+
+	for _, tc := range cases {
+		comments, _ := lexSource(t, []byte(tc.src), tc.name)
+		require.Equal(t, tc.want, titles(comments), "%s: %s", tc.name, tc.src)
+	}
+}
+
+// should lex the template, script and style sections of vue files with their own syntax
+func TestScanVue(t *testing.T) {
+	titles, lines := scanFixture(t, "main.vue")
+	require.Equal(t, []string{
+		"split the component",
+		"translate the greeting",
+		"type the props",
+		"memoize",
+		"use the theme color",
+		"dark mode",
+	}, titles)
+	require.Equal(t, []int{1, 4, 10, 13, 19, 20}, lines)
+}
+
+// should lex the markup, script and style sections of svelte files with their own syntax
+func TestScanSvelte(t *testing.T) {
+	titles, lines := scanFixture(t, "main.svelte")
+	require.Equal(t, []string{
+		"load the count from the store",
+		"add an aria label",
+		"match the design tokens",
+	}, titles)
+	require.Equal(t, []int{2, 6, 10}, lines)
+}
+
+// should recognize dockerfiles by their name and locate # comments
+func TestScanDockerfile(t *testing.T) {
+	titles, lines := scanFixture(t, "Dockerfile")
+	require.Equal(t, []string{"pin the base image digest", "indented comment"}, titles)
+	require.Equal(t, []int{1, 5}, lines)
+}
+
+// should only treat quotes as strings on the recipe lines of makefiles, which start with
+// a tab, and skip escaped #
+func TestScanMakefile(t *testing.T) {
+	titles, lines := scanFixture(t, "Makefile")
+	require.Equal(t, []string{
+		"split the targets into included files",
+		"after an apostrophe",
+		"trailing recipe comment",
+	}, titles)
+	require.Equal(t, []int{1, 2, 7}, lines)
+}
+
+// should locate # comments in ignore files without treating quotes as strings
+func TestScanGitignore(t *testing.T) {
+	titles, lines := scanFixture(t, ".gitignore")
+	require.Equal(t, []string{"stop committing build artifacts", "after an apostrophe"}, titles)
+	require.Equal(t, []int{1, 4}, lines)
+}
+
+// should locate # comments and pod blocks in perl files, =cut is left out of the
+// description
+func TestScanPerl(t *testing.T) {
+	comments, lines := lexFixture(t, "main.pl")
+	require.Equal(t, []string{
+		"enable warnings",
+		"use scalar(@items) instead",
+		"document the options",
+		"after the pod",
+	}, titles(comments))
+	require.Equal(t, []int{3, 5, 10, 19}, lines)
+	require.Equal(t, "of the script", string(comments[2].Description))
+}
+
+// should end pod that is never closed by =cut at the end of the file, like perl does
+func TestScanPerlUnclosedPod(t *testing.T) {
+	src := []byte("# @TEST_TODO before the pod\nprint 1;\n\n=head1 NOTES\n\n@TEST_TODO unclosed pod\n")
+	comments, lines := lexSource(t, src, "main.pm")
+	require.Equal(t, []string{"before the pod", "unclosed pod"}, titles(comments))
+	require.Equal(t, []int{1, 4}, lines)
+}
+
+// should locate # comments and <# #> comment based help in powershell files, a #> at the
+// start of a line closes the block rather than starting a single line comment
+func TestScanPowerShell(t *testing.T) {
+	titles, lines := scanFixture(t, "deploy.ps1")
+	require.Equal(t, []string{
+		"support staging slots",
+		"validate the environment name",
+		"use Write-Information",
+		"inline block",
+	}, titles)
+	require.Equal(t, []int{1, 10, 12, 13}, lines)
+}
+
+// should support every language that is built in
+func TestIsSupportedBuiltin(t *testing.T) {
+	exts := []string{
+		".asm", ".sh", ".bash", ".r", ".R", ".rb", ".rake", "Gemfile", "Rakefile", ".hs", ".lhs",
+		".lua", ".ml", ".mli", ".vim", ".zig", ".sql", ".mysql", ".psql", ".yml", ".yaml", ".toml",
+		".css", ".scss", ".less", ".vue", ".svelte", "Dockerfile",
+		"Makefile", "GNUmakefile", ".mk", ".gitignore", ".pl", ".pm", ".t", ".ps1",
+		".psm1", ".psd1", ".jai", ".ch", ".cs",
+	}
+	for _, ext := range exts {
+		require.True(t, lexer.IsSupported(ext), ext)
+	}
+}
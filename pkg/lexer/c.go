@@ -22,6 +22,8 @@ var allowed = []string{
 	".rs",
 	".m",
 	".scala",
+	".jai",
+	".ch",
 }
 
 type CLexer struct{}
@@ -100,20 +102,7 @@ func (cl *CLexer) String(lex *Lexer, delim byte) error {
 }
 
 func (cl *CLexer) ParseCommentTokens(lex *Lexer, annotation []byte) ([]Comment, error) {
-	comments := make([]Comment, 0)
-	for i, token := range lex.Tokens {
-		switch token.TokenType {
-		case SINGLE_LINE_COMMENT:
-			comment := token.ParseSingleLineCommentToken(annotation, trimCommentC)
-			comment.Push(&comments, lex.FileName, i)
-		case MULTI_LINE_COMMENT:
-			comment := token.ParseMultiLineCommentToken(annotation, trimCommentC)
-			comment.Push(&comments, lex.FileName, i)
-		default:
-			continue
-		}
-	}
-	return comments, nil
+	return parseCommentTokens(lex, annotation, trimCommentC), nil
 }
 
 func findAnnotationLocations(annotation []byte, commentText []byte) []int {
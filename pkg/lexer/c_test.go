@@ -58,6 +58,7 @@ func TestAnalyzeTokenSingleLineCommentC(t *testing.T) {
 			TokenType:      lexer.SINGLE_LINE_COMMENT,
 			Lexeme:         []byte("// @TEST_TODO first single line comment"),
 			Line:           4,
+			EndLine:        4,
 			StartByteIndex: 48,
 			EndByteIndex:   86,
 		},
@@ -65,6 +66,7 @@ func TestAnalyzeTokenSingleLineCommentC(t *testing.T) {
 			TokenType:      lexer.SINGLE_LINE_COMMENT,
 			Lexeme:         []byte("// @TEST_TODO second single line comment"),
 			Line:           5,
+			EndLine:        5,
 			StartByteIndex: 101,
 			EndByteIndex:   140,
 		},
@@ -72,6 +74,7 @@ func TestAnalyzeTokenSingleLineCommentC(t *testing.T) {
 			TokenType:      lexer.SINGLE_LINE_COMMENT,
 			Lexeme:         []byte("// @TEST_TODO third single line comment"),
 			Line:           8,
+			EndLine:        8,
 			StartByteIndex: 179,
 			EndByteIndex:   217,
 		},
@@ -118,6 +121,7 @@ func TestAnalyzeTokenMultiLineCommentC(t *testing.T) {
 			TokenType:      lexer.MULTI_LINE_COMMENT,
 			Lexeme:         []byte("/* @TEST_TODO inline 1 */"),
 			Line:           5,
+			EndLine:        5,
 			StartByteIndex: 46,
 			EndByteIndex:   70,
 		},
@@ -125,6 +129,7 @@ func TestAnalyzeTokenMultiLineCommentC(t *testing.T) {
 			TokenType:      lexer.MULTI_LINE_COMMENT,
 			Lexeme:         []byte("/* @TEST_TODO inline 2 */"),
 			Line:           5,
+			EndLine:        5,
 			StartByteIndex: 74,
 			EndByteIndex:   98,
 		},
@@ -133,7 +138,8 @@ func TestAnalyzeTokenMultiLineCommentC(t *testing.T) {
 			Lexeme: []byte(
 				"/*\n\t * @TEST_TODO multi line comment\n\t * second line\n\t * third line\n\t * end line\n\t*/",
 			),
-			Line:           13,
+			Line:           8,
+			EndLine:        13,
 			StartByteIndex: 114,
 			EndByteIndex:   197,
 		},
@@ -185,23 +191,29 @@ func TestParseCommentTokensSingleLineC(t *testing.T) {
 
 	expectedComments := []lexer.Comment{
 		{
+			Annotation:     annotation,
 			Title:          []byte("first single line comment"),
 			Description:    []byte(nil),
 			TokenIndex:     0,
+			EndTokenIndex:  0,
 			Source:         tokens[0].Lexeme,
 			SourceFileName: "main.c",
 		},
 		{
+			Annotation:     annotation,
 			Title:          []byte("second single line comment"),
 			Description:    []byte(nil),
 			TokenIndex:     1,
+			EndTokenIndex:  1,
 			Source:         tokens[1].Lexeme,
 			SourceFileName: "main.c",
 		},
 		{
+			Annotation:     annotation,
 			Title:          []byte("third single line comment"),
 			Description:    []byte(nil),
 			TokenIndex:     2,
+			EndTokenIndex:  2,
 			Source:         tokens[2].Lexeme,
 			SourceFileName: "main.c",
 		},
@@ -220,23 +232,29 @@ func TestParseCommentTokensMultiLineC(t *testing.T) {
 
 	expectedComments := []lexer.Comment{
 		{
+			Annotation:     annotation,
 			Title:          []byte("inline 1"),
 			Description:    []byte(nil),
 			TokenIndex:     0,
+			EndTokenIndex:  0,
 			Source:         tokens[0].Lexeme,
 			SourceFileName: "main.c",
 		},
 		{
+			Annotation:     annotation,
 			Title:          []byte("inline 2"),
 			Description:    []byte(nil),
 			TokenIndex:     1,
+			EndTokenIndex:  1,
 			Source:         tokens[1].Lexeme,
 			SourceFileName: "main.c",
 		},
 		{
+			Annotation:     annotation,
 			Title:          []byte("multi line comment"),
-			Description:    []byte("second line third line end line"),
+			Description:    []byte("second line\nthird line\nend line"),
 			TokenIndex:     2,
+			EndTokenIndex:  2,
 			Source:         tokens[2].Lexeme,
 			SourceFileName: "main.c",
 		},
@@ -246,3 +264,71 @@ func TestParseCommentTokensMultiLineC(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, expectedComments, actualComments)
 }
+
+// should keep the spaces and tabs inside of the title and the indentation of the
+// description, such as a code snippet, while the comment notation is removed
+func TestParseCommentTokensWhitespaceC(t *testing.T) {
+	src := "int x; // @TEST_TODO keep  two spaces\tand a tab\n" +
+		"/*\n" +
+		" * @TEST_TODO  snippet   in description\n" +
+		" * call it like so:\n" +
+		" *\n" +
+		" *     if (x  == 1) {\n" +
+		" *     \treturn;\n" +
+		" *     }\n" +
+		" */\n" +
+		"/* @TEST_TODO no stars\n" +
+		"      indented\n" +
+		"        deeper\n" +
+		"*/\n"
+
+	lex, err := lexer.NewLexer([]byte(src), "main.c")
+	require.NoError(t, err)
+	_, err = lex.AnalyzeTokens()
+	require.NoError(t, err)
+
+	comments, err := lex.Manager.ParseCommentTokens(lex, annotation)
+	require.NoError(t, err)
+	require.Len(t, comments, 3)
+	require.Equal(t, "keep  two spaces\tand a tab", string(comments[0].Title))
+	require.Equal(t, "snippet   in description", string(comments[1].Title))
+	require.Equal(
+		t,
+		"call it like so:\n\n    if (x  == 1) {\n    \treturn;\n    }",
+		string(comments[1].Description),
+	)
+	require.Equal(t, "indented\n  deeper", string(comments[2].Description))
+}
+
+// should continue an annotated single line comment with the comments on the lines that
+// follow it, until a line that is not a comment, a comment after code or a new annotation
+func TestParseCommentTokensContinuedC(t *testing.T) {
+	src := "// @TEST_TODO retry failed requests\n" +
+		"// use an exponential backoff\n" +
+		"//   capped at 30 seconds\n" +
+		"int x = 0;\n" +
+		"// not part of any issue\n" +
+		"// @TEST_TODO second\n" +
+		"int y = 0; // after code\n" +
+		"// @TEST_TODO third\n" +
+		"// @TEST_TODO fourth\n"
+
+	lex, err := lexer.NewLexer([]byte(src), "main.c")
+	require.NoError(t, err)
+	_, err = lex.AnalyzeTokens()
+	require.NoError(t, err)
+
+	comments, err := lex.Manager.ParseCommentTokens(lex, annotation)
+	require.NoError(t, err)
+	require.Len(t, comments, 4)
+	require.Equal(t, "retry failed requests", string(comments[0].Title))
+	require.Equal(t, "use an exponential backoff\n  capped at 30 seconds", string(comments[0].Description))
+	require.Equal(t, 0, comments[0].TokenIndex)
+	require.Equal(t, 2, comments[0].EndTokenIndex)
+
+	for i, title := range []string{"second", "third", "fourth"} {
+		require.Equal(t, title, string(comments[i+1].Title))
+		require.Nil(t, comments[i+1].Description)
+		require.Equal(t, comments[i+1].TokenIndex, comments[i+1].EndTokenIndex)
+	}
+}
@@ -1,15 +1,23 @@
 package lexer
 
+// Comment is an annotated comment. TokenIndex is the index of the token that contains the
+// annotation and EndTokenIndex is the index of the last token of the comment, they differ
+// when a single line comment is continued by the comments on the lines that follow it.
+// Annotation is the text that matched the annotation, such as @ISSUE(#12) when the
+// annotation is a pattern.
 type Comment struct {
+	Annotation     []byte
 	Title          []byte
 	Description    []byte
 	TokenIndex     int
+	EndTokenIndex  int
 	Source         []byte
 	SourceFileName string
 }
 
 func (c *Comment) Prepare(fileName string, index int) {
 	c.TokenIndex = index
+	c.EndTokenIndex = max(c.EndTokenIndex, index)
 	c.SourceFileName = fileName
 }
 
@@ -0,0 +1,124 @@
+package lexer
+
+import (
+	"bytes"
+	"regexp"
+)
+
+const (
+	VUE_EXT    = ".vue"
+	SVELTE_EXT = ".svelte"
+)
+
+var (
+	// the markup of a component, outside of its script and style sections, only has html
+	// comments. Quotes are not strings in markup, they are mostly apostrophes in text.
+	markupSyntax = CommentSyntax{MultiLineStart: "<!--", MultiLineEnd: "-->"}
+	scriptSyntax = CommentSyntax{
+		SingleLine:     "//",
+		MultiLineStart: "/*",
+		MultiLineEnd:   "*/",
+		StringDelims:   "\"'`",
+	}
+)
+
+// lang_re captures the language of a section from the lang attribute of its opening tag,
+// such as scss in <style lang="scss">
+var lang_re = regexp.MustCompile(`(?i)\blang\s*=\s*["']?([\w-]+)`)
+
+// ComponentLexer tokenizes the comments of single file components, vue and svelte files.
+// A component is made of a script section, a style section and html markup, each one
+// with a comment syntax of its own. The markup is lexed until the opening tag of a
+// <script> or <style> section, whose contents are lexed with the syntax of the section
+// until its closing tag. The style section supports // comments when its lang is scss,
+// sass or less.
+type ComponentLexer struct {
+	section *SyntaxLexer
+	closing []byte
+}
+
+func (cl *ComponentLexer) AnalyzeToken(lex *Lexer) error {
+	if cl.section == nil {
+		cl.section = &SyntaxLexer{Syntax: markupSyntax}
+	}
+
+	switch {
+	case cl.closing != nil && hasPrefixFold(lex.Source[lex.Current:], cl.closing):
+		cl.section, cl.closing = &SyntaxLexer{Syntax: markupSyntax}, nil
+		return nil
+	case cl.closing == nil && isOpeningTag(lex.Source[lex.Current:], "script"):
+		cl.openSection(lex, "script")
+		return nil
+	case cl.closing == nil && isOpeningTag(lex.Source[lex.Current:], "style"):
+		cl.openSection(lex, "style")
+		return nil
+	default:
+		return cl.section.AnalyzeToken(lex)
+	}
+}
+
+func (cl *ComponentLexer) Comment(lex *Lexer) error {
+	return cl.section.Comment(lex)
+}
+
+func (cl *ComponentLexer) String(lex *Lexer, delim byte) error {
+	return cl.section.String(lex, delim)
+}
+
+func (cl *ComponentLexer) ParseCommentTokens(lex *Lexer, annotation []byte) ([]Comment, error) {
+	return parseCommentTokens(lex, annotation, trimCommentComponent), nil
+}
+
+// openSection moves to the end of the opening tag of the section that starts at the
+// current byte and lexes the rest of the section with its syntax
+func (cl *ComponentLexer) openSection(lex *Lexer, tag string) {
+	for !lex.isEnd() && lex.peek() != '>' {
+		if lex.next() == NEWLINE {
+			lex.Line++
+		}
+	}
+
+	syntax := scriptSyntax
+	if tag == "style" {
+		syntax = cssSyntax
+		if match := lang_re.FindSubmatch(lex.Source[lex.Start:lex.Current]); match != nil {
+			switch string(bytes.ToLower(match[1])) {
+			case "scss", "sass", "less":
+				syntax = scssSyntax
+			}
+		}
+	}
+
+	cl.section, cl.closing = &SyntaxLexer{Syntax: syntax}, []byte("</"+tag)
+}
+
+// isOpeningTag reports whether src starts with the opening tag of name, such as <script>
+// or <script lang="ts">, ignoring case
+func isOpeningTag(src []byte, name string) bool {
+	if !hasPrefixFold(src, []byte("<"+name)) {
+		return false
+	}
+
+	rest := src[len(name)+1:]
+	return len(rest) > 0 && bytes.IndexByte([]byte(" \t\r\n>/"), rest[0]) >= 0
+}
+
+func hasPrefixFold(src, prefix []byte) bool {
+	return len(src) >= len(prefix) && bytes.EqualFold(src[:len(prefix)], prefix)
+}
+
+func isComponent(ext string) bool {
+	return ext == VUE_EXT || ext == SVELTE_EXT
+}
+
+// trimCommentComponent trims the notation of every section, since the tokens of a
+// component are parsed together
+func trimCommentComponent(r rune) bool {
+	switch r {
+	case rune(WHITESPACE), rune(TAB), rune(NEWLINE), '\r', rune(ASTERISK), rune(FORWARD_SLASH),
+		'<', '!', '-', '>':
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,124 @@
+package lexer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// LanguagesFile is the document of a languages file, which declares the comment syntax of
+// languages that are not built in. For example:
+//
+//	{
+//	  "languages": [
+//	    {
+//	      "name": "Fictional",
+//	      "extensions": [".fict"],
+//	      "single_line": "%%",
+//	      "multi_line_start": "<#",
+//	      "multi_line_end": "#>",
+//	      "string_delims": "\""
+//	    }
+//	  ]
+//	}
+type LanguagesFile struct {
+	Languages []LanguageDefinition `json:"languages"`
+}
+
+// LanguageDefinition maps one or more file extensions to a comment syntax. Name is only
+// used to describe the definition in error messages.
+type LanguageDefinition struct {
+	Name            string   `json:"name"`
+	Extensions      []string `json:"extensions"`
+	SingleLine      string   `json:"single_line"`
+	MultiLineStart  string   `json:"multi_line_start"`
+	MultiLineEnd    string   `json:"multi_line_end"`
+	StringDelims    string   `json:"string_delims"`
+	RawStringDelims string   `json:"raw_string_delims"`
+	Nested          bool     `json:"nested"`
+	CodePrefix      string   `json:"code_prefix"`
+	LineStart       bool     `json:"line_start"`
+}
+
+func (ld LanguageDefinition) syntax() CommentSyntax {
+	return CommentSyntax{
+		SingleLine:      ld.SingleLine,
+		MultiLineStart:  ld.MultiLineStart,
+		MultiLineEnd:    ld.MultiLineEnd,
+		StringDelims:    ld.StringDelims,
+		RawStringDelims: ld.RawStringDelims,
+		Nested:          ld.Nested,
+		CodePrefix:      ld.CodePrefix,
+		LineStart:       ld.LineStart,
+	}
+}
+
+// ParseLanguages decodes a languages file and validates every definition. The returned
+// map is keyed by file extension. Unknown fields are rejected so that typos are reported
+// instead of silently ignored.
+func ParseLanguages(r io.Reader) (map[string]CommentSyntax, error) {
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+
+	doc := LanguagesFile{}
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid languages file: %w", err)
+	}
+
+	syntaxes := make(map[string]CommentSyntax)
+	for i, lang := range doc.Languages {
+		name := fmt.Sprintf("language %d", i+1)
+		if lang.Name != "" {
+			name = fmt.Sprintf("%s (%s)", name, lang.Name)
+		}
+
+		if len(lang.Extensions) == 0 {
+			return nil, fmt.Errorf("%s: at least one extension is required", name)
+		}
+
+		syntax := lang.syntax()
+		if err := syntax.validate(); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+
+		for _, ext := range lang.Extensions {
+			if !strings.HasPrefix(ext, ".") || len(ext) < 2 {
+				return nil, fmt.Errorf("%s: invalid extension %q. extensions must start with a .", name, ext)
+			}
+
+			if _, ok := syntaxes[ext]; ok {
+				return nil, fmt.Errorf("%s: extension %s is declared more than once", name, ext)
+			}
+
+			syntaxes[ext] = syntax
+		}
+	}
+
+	return syntaxes, nil
+}
+
+// LoadLanguagesFile registers the comment syntax of every language in the languages file
+// located at path, see ParseLanguages. Nothing is registered when the file is invalid. The
+// error returned satisfies os.IsNotExist when the file does not exist.
+func LoadLanguagesFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+	syntaxes, err := ParseLanguages(file)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	for ext, syntax := range syntaxes {
+		if err := RegisterCommentSyntax(ext, syntax); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	return nil
+}
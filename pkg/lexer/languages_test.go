@@ -0,0 +1,82 @@
+package lexer_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/AntoninoAdornetto/issue-summoner/pkg/lexer"
+	"github.com/stretchr/testify/require"
+)
+
+// should register every extension of the languages that are declared in the file
+func TestLoadLanguagesFile(t *testing.T) {
+	require.NoError(t, lexer.LoadLanguagesFile("testdata/languages.json"))
+
+	for _, ext := range []string{".fictl", ".fictl2", ".semi"} {
+		require.True(t, lexer.IsSupported(ext), ext)
+	}
+
+	lex, err := lexer.NewLexer([]byte("x = 1\n;; @TEST_TODO semicolons\n"), "main.semi")
+	require.NoError(t, err)
+	_, err = lex.AnalyzeTokens()
+	require.NoError(t, err)
+
+	comments, err := lex.Manager.ParseCommentTokens(lex, annotation)
+	require.NoError(t, err)
+	require.Len(t, comments, 1)
+	require.Equal(t, "semicolons", string(comments[0].Title))
+}
+
+// should return a not exist error when the file is missing
+func TestLoadLanguagesFileMissing(t *testing.T) {
+	err := lexer.LoadLanguagesFile(filepath.Join(t.TempDir(), "languages.json"))
+	require.True(t, os.IsNotExist(err))
+}
+
+// should describe the definition that is malformed
+func TestParseLanguagesErrors(t *testing.T) {
+	cases := []struct {
+		src string
+		msg string
+	}{
+		{src: `{"languages": [`, msg: "invalid languages file"},
+		{src: `{"languages": [{"extensions": [".a"], "single": "#"}]}`, msg: `unknown field "single"`},
+		{src: `{"languages": [{"name": "A", "single_line": "#"}]}`, msg: "language 1 (A): at least one extension is required"},
+		{src: `{"languages": [{"extensions": ["a"], "single_line": "#"}]}`, msg: `language 1: invalid extension "a"`},
+		{src: `{"languages": [{"extensions": [".a"]}]}`, msg: "at least one kind of comment must be set"},
+		{
+			src: `{"languages": [{"extensions": [".a"], "multi_line_start": "{-"}]}`,
+			msg: "both the start and end of multi line comments must be set",
+		},
+		{
+			src: `{"languages": [{"extensions": [".a"], "single_line": "#"}, {"name": "B", "extensions": [".a"], "single_line": "--"}]}`,
+			msg: "language 2 (B): extension .a is declared more than once",
+		},
+	}
+
+	for _, tc := range cases {
+		_, err := lexer.ParseLanguages(strings.NewReader(tc.src))
+		require.ErrorContains(t, err, tc.msg, tc.src)
+	}
+}
+
+// should not register any language when the file is invalid
+func TestLoadLanguagesFileInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "languages.json")
+	src := `{"languages": [{"extensions": [".valid"], "single_line": "#"}, {"extensions": ["bad"], "single_line": "#"}]}`
+	require.NoError(t, os.WriteFile(path, []byte(src), 0644))
+
+	err := lexer.LoadLanguagesFile(path)
+	require.ErrorContains(t, err, path)
+	require.False(t, lexer.IsSupported(".valid"))
+}
+
+// should map raw_string_delims to the delimiters of the strings without escapes
+func TestParseLanguagesRawStringDelims(t *testing.T) {
+	src := `{"languages": [{"extensions": [".a"], "single_line": "#", "string_delims": "\"'", "raw_string_delims": "'"}]}`
+	syntaxes, err := lexer.ParseLanguages(strings.NewReader(src))
+	require.NoError(t, err)
+	require.Equal(t, "'", syntaxes[".a"].RawStringDelims)
+}
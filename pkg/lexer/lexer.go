@@ -16,23 +16,22 @@ We don't want the lexer to create tokens for strings that may contain comment sy
 
 Each language that is supported will need to satisfy the LexingManager interface and support tokenizing
 methods for Comments and Strings. This will allow each implementation to utilize the comment notation that
-is specific to a language.
+is specific to a language. Languages that only differ in their comment notation can be added at runtime
+with RegisterCommentSyntax, which lexes files with a SyntaxLexer.
 */
 package lexer
 
-import (
-	"fmt"
-	"path/filepath"
-)
+import "fmt"
 
 type Lexer struct {
-	Source   []byte
-	FileName string
-	Tokens   []Token
-	Start    int
-	Current  int
-	Line     int
-	Manager  LexingManager
+	Source    []byte
+	FileName  string
+	Tokens    []Token
+	Start     int
+	Current   int
+	StartLine int
+	Line      int
+	Manager   LexingManager
 }
 
 type LexingManager interface {
@@ -43,27 +42,46 @@ type LexingManager interface {
 }
 
 func NewLexer(src []byte, fileName string) (*Lexer, error) {
-	ext := filepath.Ext(fileName)
-	manger, err := NewLexingManager(ext)
+	manger, err := NewLexingManager(FileType(fileName))
 	if err != nil {
 		return nil, err
 	}
 
 	return &Lexer{
-		Source:   src,
-		FileName: fileName,
-		Start:    0,
-		Current:  0,
-		Line:     1,
-		Manager:  manger,
-		Tokens:   make([]Token, 0),
+		Source:    src,
+		FileName:  fileName,
+		Start:     0,
+		Current:   0,
+		StartLine: 1,
+		Line:      1,
+		Manager:   manger,
+		Tokens:    make([]Token, 0),
 	}, nil
 }
 
+// NewLexingManager returns the lexer for files with the extension ext. Comment syntax that
+// was registered with RegisterCommentSyntax takes precedence over the languages that are
+// built in.
 func NewLexingManager(ext string) (LexingManager, error) {
+	if syntax, ok := lookupSyntax(ext); ok {
+		return &SyntaxLexer{Syntax: syntax}, nil
+	}
+
 	switch {
 	case IsAdoptedFromC(ext):
 		return &CLexer{}, nil
+	case ext == LUA_EXT:
+		return &LuaLexer{}, nil
+	case isYaml(ext):
+		return &YamlLexer{}, nil
+	case isComponent(ext):
+		return &ComponentLexer{}, nil
+	case isMakefile(ext):
+		return &MakefileLexer{}, nil
+	case isPerl(ext):
+		return &PerlLexer{SyntaxLexer{Syntax: perlSyntax}}, nil
+	case isVim(ext):
+		return &VimLexer{SyntaxLexer{Syntax: vimSyntax}}, nil
 	default:
 		return nil, fmt.Errorf(
 			"unsupported file type of %s. please open a feature request if you would like support.",
@@ -75,6 +93,7 @@ func NewLexingManager(ext string) (LexingManager, error) {
 func (l *Lexer) AnalyzeTokens() ([]Token, error) {
 	for range l.Source {
 		l.Start = l.Current
+		l.StartLine = l.Line
 		err := l.Manager.AnalyzeToken(l)
 		if err != nil {
 			return nil, err
@@ -115,7 +134,8 @@ func (l *Lexer) addToken(tokenType TokenType, value []byte) {
 	l.Tokens = append(l.Tokens, Token{
 		TokenType:      tokenType,
 		Lexeme:         value,
-		Line:           l.Line,
+		Line:           l.StartLine,
+		EndLine:        l.Line,
 		StartByteIndex: l.Start,
 		EndByteIndex:   l.Current,
 	})
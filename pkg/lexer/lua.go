@@ -0,0 +1,133 @@
+package lexer
+
+import (
+	"bytes"
+	"fmt"
+)
+
+const LUA_EXT = ".lua"
+
+// LuaLexer tokenizes the comments of lua files. Block comments, --[[ ]], and long strings,
+// [[ ]], are enclosed in long brackets, which have a level of = signs, such as --[==[ ]==].
+// A long bracket is only closed by a closing bracket of the same level, so ]] doesn't
+// close a comment that was opened with --[==[.
+type LuaLexer struct{}
+
+func (ll *LuaLexer) AnalyzeToken(lex *Lexer) error {
+	b := lex.peek()
+	switch {
+	case b == NEWLINE:
+		lex.Line++
+		return nil
+	case b == DOUBLE_QUOTE || b == QUOTE:
+		return ll.String(lex, b)
+	case b == '[' && longBracketLevel(lex.Source[lex.Current:]) >= 0:
+		// long strings are skipped like any other string
+		ll.longBracket(lex, longBracketLevel(lex.Source[lex.Current:]))
+		return nil
+	case lex.hasPrefix("--"):
+		return ll.Comment(lex)
+	default:
+		return nil
+	}
+}
+
+// Comment tokenizes the comment that starts at the current byte. It's a block comment when
+// the -- is followed by an opening long bracket and a single line comment otherwise.
+func (ll *LuaLexer) Comment(lex *Lexer) error {
+	if level := longBracketLevel(lex.Source[lex.Current+2:]); level >= 0 {
+		return ll.MultiLineComment(lex, level)
+	}
+	return ll.SingleLineComment(lex)
+}
+
+func (ll *LuaLexer) SingleLineComment(lex *Lexer) error {
+	for !lex.isEnd() && lex.peekNext() != NEWLINE {
+		lex.next()
+	}
+	comment := lex.Source[lex.Start : lex.Current+1]
+	lex.addToken(SINGLE_LINE_COMMENT, comment)
+	return nil
+}
+
+// MultiLineComment tokenizes a block comment whose long bracket has the given level
+func (ll *LuaLexer) MultiLineComment(lex *Lexer, level int) error {
+	lex.Current += len("--")
+	if !ll.longBracket(lex, level) {
+		src := lex.Source[lex.Start:]
+		return lex.report(fmt.Sprintf("could not locate closing multi line comment: %s", src))
+	}
+
+	comment := lex.Source[lex.Start : lex.Current+1]
+	lex.addToken(MULTI_LINE_COMMENT, comment)
+	return nil
+}
+
+// String skips a quoted string, a delimiter that is escaped with a backslash doesn't
+// close it
+func (ll *LuaLexer) String(lex *Lexer, delim byte) error {
+	for !lex.isEnd() && lex.peekNext() != delim {
+		b := lex.next()
+		switch {
+		case b == NEWLINE:
+			lex.Line++
+		case b == BACKWARD_SLASH && !lex.isEnd():
+			if lex.next() == NEWLINE {
+				lex.Line++
+			}
+		}
+	}
+	lex.next() // closing delimiter
+	return nil
+}
+
+func (ll *LuaLexer) ParseCommentTokens(lex *Lexer, annotation []byte) ([]Comment, error) {
+	return parseCommentTokens(lex, annotation, trimCommentLua), nil
+}
+
+// longBracket moves past the long bracket of the given level that opens at the current
+// byte, up to the last byte of the bracket that closes it. It reports whether the closing
+// bracket was found.
+func (ll *LuaLexer) longBracket(lex *Lexer, level int) bool {
+	closing := []byte("]" + string(bytes.Repeat([]byte{'='}, level)) + "]")
+	lex.Current += level + 1 // the last [ of the opening bracket
+	for !lex.isEnd() {
+		b := lex.next()
+		if b == NEWLINE {
+			lex.Line++
+		}
+
+		if bytes.HasPrefix(lex.Source[lex.Current:], closing) {
+			lex.Current += len(closing) - 1
+			return true
+		}
+	}
+	return false
+}
+
+// longBracketLevel returns the number of = signs of the opening long bracket, such as 2
+// for [==[, that src starts with. It's -1 when src doesn't start with a long bracket.
+func longBracketLevel(src []byte) int {
+	if len(src) == 0 || src[0] != '[' {
+		return -1
+	}
+
+	level := 1
+	for level < len(src) && src[level] == '=' {
+		level++
+	}
+
+	if level == len(src) || src[level] != '[' {
+		return -1
+	}
+	return level - 1
+}
+
+func trimCommentLua(r rune) bool {
+	switch r {
+	case rune(WHITESPACE), rune(TAB), rune(NEWLINE), '\r', '-', '[', ']', '=':
+		return true
+	default:
+		return false
+	}
+}
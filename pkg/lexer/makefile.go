@@ -0,0 +1,69 @@
+package lexer
+
+// MakefileLexer tokenizes the comments of makefiles. The recipe lines, which start with a
+// tab, are passed to the shell, so quotes enclose strings on them and a # inside of a
+// string is not a comment. Quotes are ordinary text on the other lines, such as
+// MSG = don't, and an escaped \# is a literal #.
+type MakefileLexer struct {
+	recipe bool
+}
+
+func (ml *MakefileLexer) AnalyzeToken(lex *Lexer) error {
+	b := lex.peek()
+	if lex.atLineStart() {
+		ml.recipe = b == TAB
+	}
+
+	switch {
+	case b == NEWLINE:
+		lex.Line++
+		return nil
+	case b == BACKWARD_SLASH && lex.peekNext() != NEWLINE:
+		lex.next() // escaped byte
+		return nil
+	case ml.recipe && (b == DOUBLE_QUOTE || b == QUOTE):
+		return ml.String(lex, b)
+	case b == HASH:
+		return ml.Comment(lex)
+	default:
+		return nil
+	}
+}
+
+func (ml *MakefileLexer) Comment(lex *Lexer) error {
+	for !lex.isEnd() && lex.peekNext() != NEWLINE {
+		lex.next()
+	}
+	comment := lex.Source[lex.Start : lex.Current+1]
+	lex.addToken(SINGLE_LINE_COMMENT, comment)
+	return nil
+}
+
+// String skips a quoted string of a recipe line. Strings don't span recipe lines, so an
+// unterminated quote ends with the line.
+func (ml *MakefileLexer) String(lex *Lexer, delim byte) error {
+	for !lex.isEnd() && lex.peekNext() != delim && lex.peekNext() != NEWLINE {
+		if lex.next() == BACKWARD_SLASH && delim == DOUBLE_QUOTE && lex.peekNext() != NEWLINE {
+			lex.next()
+		}
+	}
+
+	if lex.peekNext() == delim {
+		lex.next() // closing delimiter
+	}
+	return nil
+}
+
+func (ml *MakefileLexer) ParseCommentTokens(lex *Lexer, annotation []byte) ([]Comment, error) {
+	return parseCommentTokens(lex, annotation, trimCommentHash), nil
+}
+
+// isMakefile reports whether the file type, see FileType, is a makefile
+func isMakefile(fileType string) bool {
+	switch fileType {
+	case "Makefile", "makefile", "GNUmakefile", ".mk", ".mak":
+		return true
+	default:
+		return false
+	}
+}
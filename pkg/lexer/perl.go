@@ -0,0 +1,44 @@
+package lexer
+
+import "unicode"
+
+// perlSyntax describes the comments of perl. A line that starts with = and a command,
+// such as =pod or =head1, opens a block of pod documentation that is closed by =cut. Like
+// perl, pod without a =cut runs to the end of the file.
+var perlSyntax = CommentSyntax{
+	SingleLine:     "#",
+	MultiLineStart: "=",
+	MultiLineEnd:   "=cut",
+	StringDelims:   `"'`,
+	LineStart:      true,
+	EndsAtEOF:      true,
+}
+
+// PerlLexer tokenizes the comments of perl files with perlSyntax. The # of $#array, the
+// last index of an array, doesn't start a comment and neither does a line that starts with
+// = but not with a pod command, such as the = of a statement that spans lines.
+type PerlLexer struct {
+	SyntaxLexer
+}
+
+func (pl *PerlLexer) AnalyzeToken(lex *Lexer) error {
+	b := lex.peek()
+	switch {
+	case b == '$' && lex.peekNext() == HASH:
+		lex.next()
+		return nil
+	case b == '=' && lex.atLineStart() && !unicode.IsLetter(rune(lex.peekNext())):
+		return nil
+	default:
+		return pl.SyntaxLexer.AnalyzeToken(lex)
+	}
+}
+
+func isPerl(ext string) bool {
+	switch ext {
+	case ".pl", ".pm", ".t", ".pod":
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,409 @@
+package lexer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// CommentSyntax describes the comment notation of a language so that it can be lexed
+// without a lexer of its own. SingleLine is the prefix of a comment that runs to the end
+// of the line, such as # or --. MultiLineStart and MultiLineEnd enclose a comment that can
+// span multiple lines, such as {- and -}. Either kind of comment can be left out.
+// StringDelims contains the bytes that open and close a string, comment notation inside
+// of a string is not tokenized. A backslash escapes the byte that follows it inside of a
+// string, except for the strings whose delimiter is in RawStringDelims, such as the single
+// quoted strings of shell scripts. Multi line comments can contain other multi line comments
+// when Nested is set, such as {- outer {- inner -} still a comment -}, the comment only
+// ends once every comment inside of it was closed. Only the lines that start with
+// CodePrefix are lexed when it's set, such as > in literate haskell, the other lines are
+// prose. The notation of multi line comments must be at the start of a line when LineStart
+// is set, such as =begin and =end in ruby. A multi line comment that is never closed runs
+// to the end of the file when EndsAtEOF is set, such as pod in perl, otherwise it's an
+// error.
+type CommentSyntax struct {
+	SingleLine      string
+	MultiLineStart  string
+	MultiLineEnd    string
+	StringDelims    string
+	RawStringDelims string
+	Nested          bool
+	CodePrefix      string
+	LineStart       bool
+	EndsAtEOF       bool
+}
+
+func (cs CommentSyntax) validate() error {
+	if (cs.MultiLineStart == "") != (cs.MultiLineEnd == "") {
+		return errors.New("both the start and end of multi line comments must be set")
+	}
+
+	if cs.SingleLine == "" && cs.MultiLineStart == "" {
+		return errors.New("at least one kind of comment must be set")
+	}
+
+	return nil
+}
+
+// builtinSyntax is the comment syntax of the languages that are built in and only differ
+// in their comment notation, keyed by file extension. Files without an extension, such as
+// Gemfile, are keyed by their name, see FileType.
+var builtinSyntax = map[string]CommentSyntax{
+	".ex":           elixirSyntax,
+	".exs":          elixirSyntax,
+	".erl":          erlangSyntax,
+	".hrl":          erlangSyntax,
+	".lisp":         lispSyntax,
+	".lsp":          lispSyntax,
+	".scm":          lispSyntax,
+	".ss":           lispSyntax,
+	".rkt":          lispSyntax,
+	".clj":          clojureSyntax,
+	".cljs":         clojureSyntax,
+	".cljc":         clojureSyntax,
+	".asm":          asmSyntax,
+	".sh":           shellSyntax,
+	".bash":         shellSyntax,
+	".r":            rSyntax,
+	".R":            rSyntax,
+	".rb":           rubySyntax,
+	".rake":         rubySyntax,
+	"Gemfile":       rubySyntax,
+	"Rakefile":      rubySyntax,
+	"Dockerfile":    shellSyntax,
+	"Containerfile": shellSyntax,
+	".dockerignore": ignoreSyntax,
+	".gitignore":    ignoreSyntax,
+	".hs":           haskellSyntax,
+	".lhs":          literateHaskellSyntax,
+	".ml":           ocamlSyntax,
+	".mli":          ocamlSyntax,
+	".zig":          zigSyntax,
+	".sql":          sqlSyntax,
+	".mysql":        mysqlSyntax,
+	".psql":         postgresSyntax,
+	".toml":         tomlSyntax,
+	".ps1":          powershellSyntax,
+	".psm1":         powershellSyntax,
+	".psd1":         powershellSyntax,
+	".css":          cssSyntax,
+	".scss":         scssSyntax,
+	".less":         scssSyntax,
+}
+
+var (
+	// the docs of @moduledoc and @doc are strings, not comments, and are not scanned
+	elixirSyntax = CommentSyntax{SingleLine: "#", StringDelims: `"'`}
+	erlangSyntax = CommentSyntax{SingleLine: "%", StringDelims: `"'`}
+	// a single quote is the quote form in lisps, not the start of a string
+	lispSyntax = CommentSyntax{
+		SingleLine:     ";",
+		MultiLineStart: "#|",
+		MultiLineEnd:   "|#",
+		StringDelims:   `"`,
+		Nested:         true,
+	}
+	// #_ discards the next form, which is code rather than a comment, and is not scanned
+	clojureSyntax = CommentSyntax{SingleLine: ";", StringDelims: `"`}
+	asmSyntax     = CommentSyntax{SingleLine: ";", StringDelims: `"'`}
+	// shell scripts, and dockerfiles whose instructions are mostly shell commands. Single
+	// quoted strings have no escapes, so '\' is a complete string.
+	shellSyntax = CommentSyntax{SingleLine: "#", StringDelims: `"'`, RawStringDelims: "'"}
+	rSyntax     = CommentSyntax{SingleLine: "#", StringDelims: `"'`}
+	// ignore files are lists of patterns, quotes in them are part of file names
+	ignoreSyntax = CommentSyntax{SingleLine: "#"}
+	// =begin and =end only enclose a comment at the start of a line, so x = "=begin" or
+	// a ==begin don't open one
+	rubySyntax = CommentSyntax{
+		SingleLine:     "#",
+		MultiLineStart: "=begin",
+		MultiLineEnd:   "=end",
+		StringDelims:   `"'`,
+		LineStart:      true,
+	}
+	// a single quote is also part of identifiers in haskell, such as x'
+	haskellSyntax = CommentSyntax{
+		SingleLine:     "--",
+		MultiLineStart: "{-",
+		MultiLineEnd:   "-}",
+		StringDelims:   `"`,
+		Nested:         true,
+	}
+	// the code of bird style literate haskell is on the lines that start with >
+	literateHaskellSyntax = CommentSyntax{
+		SingleLine:     "--",
+		MultiLineStart: "{-",
+		MultiLineEnd:   "-}",
+		StringDelims:   `"`,
+		Nested:         true,
+		CodePrefix:     ">",
+	}
+	ocamlSyntax = CommentSyntax{
+		MultiLineStart: "(*",
+		MultiLineEnd:   "*)",
+		StringDelims:   `"`,
+		Nested:         true,
+	}
+	zigSyntax = CommentSyntax{SingleLine: "//", StringDelims: `"'`}
+	// a double quote encloses an identifier, which can contain comment notation just like
+	// a string. -- always starts a comment, even though mysql requires whitespace after it.
+	// A quote is escaped by doubling it in standard sql, a backslash is an ordinary byte.
+	sqlSyntax = CommentSyntax{
+		SingleLine:      "--",
+		MultiLineStart:  "/*",
+		MultiLineEnd:    "*/",
+		StringDelims:    `"'`,
+		RawStringDelims: `"'`,
+	}
+	// mysql also escapes quotes with a backslash
+	mysqlSyntax = CommentSyntax{
+		SingleLine:     "--",
+		MultiLineStart: "/*",
+		MultiLineEnd:   "*/",
+		StringDelims:   `"'`,
+	}
+	// values are always quoted in toml, so a # outside of a string always starts a comment.
+	// Single quoted strings are literal strings, without escapes.
+	tomlSyntax = CommentSyntax{SingleLine: "#", StringDelims: `"'`, RawStringDelims: "'"}
+	// css only has block comments, // is part of urls such as url(//cdn.example.com)
+	cssSyntax = CommentSyntax{MultiLineStart: "/*", MultiLineEnd: "*/", StringDelims: `"'`}
+	// scss and less add single line comments to css
+	scssSyntax = CommentSyntax{
+		SingleLine:     "//",
+		MultiLineStart: "/*",
+		MultiLineEnd:   "*/",
+		StringDelims:   `"'`,
+	}
+	// the #> that closes a block comment is found before a # could start a single line
+	// comment, since the block is lexed until it's closed. The escape character of
+	// powershell is a backtick, a backslash is an ordinary byte such as in "C:\".
+	powershellSyntax = CommentSyntax{
+		SingleLine:      "#",
+		MultiLineStart:  "<#",
+		MultiLineEnd:    "#>",
+		StringDelims:    `"'`,
+		RawStringDelims: `"'`,
+	}
+	// block comments can contain each other in postgres
+	postgresSyntax = CommentSyntax{
+		SingleLine:      "--",
+		MultiLineStart:  "/*",
+		MultiLineEnd:    "*/",
+		StringDelims:    `"'`,
+		RawStringDelims: `"'`,
+		Nested:          true,
+	}
+)
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]CommentSyntax)
+)
+
+// RegisterCommentSyntax adds support for files with the extension ext, such as ".foo".
+// Registered extensions take precedence over the lexers that are built in, so that the
+// comment syntax of a supported extension can also be replaced.
+func RegisterCommentSyntax(ext string, syntax CommentSyntax) error {
+	if !strings.HasPrefix(ext, ".") || len(ext) < 2 {
+		return fmt.Errorf("invalid file extension %q. extensions must start with a .", ext)
+	}
+
+	if err := syntax.validate(); err != nil {
+		return fmt.Errorf("invalid comment syntax for %s: %w", ext, err)
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[ext] = syntax
+	return nil
+}
+
+// lookupSyntax returns the comment syntax of the extension. A registered syntax takes
+// precedence over the syntax of the languages that are built in.
+func lookupSyntax(ext string) (CommentSyntax, bool) {
+	registryMu.RLock()
+	syntax, ok := registry[ext]
+	registryMu.RUnlock()
+	if ok {
+		return syntax, true
+	}
+
+	syntax, ok = builtinSyntax[ext]
+	return syntax, ok
+}
+
+// FileType returns the key that the lexer of the file name is looked up with. It's the
+// name of the file for the files that are recognized by their name, such as Gemfile or
+// Makefile, and the extension of the file otherwise. The name is consulted first, so a
+// file such as .gitignore is recognized by its name as well as its extension.
+func FileType(name string) string {
+	base := filepath.Base(name)
+	if _, ok := lookupSyntax(base); ok || isMakefile(base) {
+		return base
+	}
+	return filepath.Ext(base)
+}
+
+// IsSupported reports whether files with the extension ext, or the file type returned by
+// FileType, can be lexed, either by a lexer that is built in or by a comment syntax that
+// was registered
+func IsSupported(ext string) bool {
+	if _, ok := lookupSyntax(ext); ok {
+		return true
+	}
+	return IsAdoptedFromC(ext) || ext == LUA_EXT || isYaml(ext) || isComponent(ext) ||
+		isMakefile(ext) || isPerl(ext) || isVim(ext)
+}
+
+// SyntaxLexer tokenizes the comments of any language that is described by a CommentSyntax
+type SyntaxLexer struct {
+	Syntax CommentSyntax
+}
+
+func (sl *SyntaxLexer) AnalyzeToken(lex *Lexer) error {
+	b := lex.peek()
+	switch {
+	case b == 0:
+		return nil
+	case b == NEWLINE:
+		lex.Line++
+		return nil
+	case sl.Syntax.CodePrefix != "" && lex.atLineStart() && !lex.hasPrefix(sl.Syntax.CodePrefix):
+		return sl.Prose(lex)
+	case strings.IndexByte(sl.Syntax.StringDelims, b) >= 0:
+		return sl.String(lex, b)
+	default:
+		return sl.Comment(lex)
+	}
+}
+
+// Comment tokenizes the comment that starts at the current byte. Multi line comments are
+// checked first since their notation may begin with the notation of single line comments,
+// such as --[[ and -- in Lua.
+func (sl *SyntaxLexer) Comment(lex *Lexer) error {
+	switch {
+	case sl.Syntax.MultiLineStart != "" && sl.hasNotation(lex, sl.Syntax.MultiLineStart):
+		return sl.MultiLineComment(lex)
+	case sl.Syntax.SingleLine != "" && lex.hasPrefix(sl.Syntax.SingleLine):
+		return sl.SingleLineComment(lex)
+	default:
+		return nil
+	}
+}
+
+func (sl *SyntaxLexer) SingleLineComment(lex *Lexer) error {
+	for !lex.isEnd() && lex.peekNext() != NEWLINE {
+		lex.next()
+	}
+	comment := lex.Source[lex.Start : lex.Current+1]
+	lex.addToken(SINGLE_LINE_COMMENT, comment)
+	return nil
+}
+
+// MultiLineComment tokenizes a multi line comment. The comments that are nested inside of
+// it are part of the comment when the syntax is Nested, depth counts the comments that
+// are still open. The closing notation is left out of the lexeme when the syntax is
+// LineStart, since words such as =end can't be trimmed from the comment like symbols.
+func (sl *SyntaxLexer) MultiLineComment(lex *Lexer) error {
+	lex.Current += len(sl.Syntax.MultiLineStart) - 1
+	closed, depth, end := false, 1, 0
+	for !lex.isEnd() {
+		b := lex.next()
+		if b == NEWLINE {
+			lex.Line++
+		}
+
+		if sl.Syntax.Nested && sl.hasNotation(lex, sl.Syntax.MultiLineStart) {
+			lex.Current += len(sl.Syntax.MultiLineStart) - 1
+			depth++
+			continue
+		}
+
+		if sl.hasNotation(lex, sl.Syntax.MultiLineEnd) {
+			end = lex.Current
+			lex.Current += len(sl.Syntax.MultiLineEnd) - 1
+			if depth--; depth == 0 {
+				closed = true
+				break
+			}
+		}
+	}
+
+	if !closed && sl.Syntax.EndsAtEOF {
+		lex.addToken(MULTI_LINE_COMMENT, lex.Source[lex.Start:])
+		return nil
+	}
+
+	if !closed {
+		src := lex.Source[lex.Start:]
+		return lex.report(fmt.Sprintf("could not locate closing multi line comment: %s", src))
+	}
+
+	comment := lex.Source[lex.Start : lex.Current+1]
+	if sl.Syntax.LineStart {
+		comment = lex.Source[lex.Start:end]
+	}
+	lex.addToken(MULTI_LINE_COMMENT, comment)
+	return nil
+}
+
+// hasNotation reports whether the multi line comment notation starts at the current byte,
+// which must be the first byte of a line when the syntax is LineStart
+func (sl *SyntaxLexer) hasNotation(lex *Lexer, notation string) bool {
+	return lex.hasPrefix(notation) && (!sl.Syntax.LineStart || lex.atLineStart())
+}
+
+// Prose skips a line that does not start with the CodePrefix of the syntax
+func (sl *SyntaxLexer) Prose(lex *Lexer) error {
+	for !lex.isEnd() && lex.peekNext() != NEWLINE {
+		lex.next()
+	}
+	return nil
+}
+
+func (sl *SyntaxLexer) String(lex *Lexer, delim byte) error {
+	escapes := strings.IndexByte(sl.Syntax.RawStringDelims, delim) < 0
+	for !lex.isEnd() && lex.peekNext() != delim {
+		b := lex.next()
+		if b == BACKWARD_SLASH && escapes && !lex.isEnd() {
+			b = lex.next()
+		}
+		if b == NEWLINE {
+			lex.Line++
+		}
+	}
+	lex.next() // closing delimiter
+	return nil
+}
+
+func (sl *SyntaxLexer) ParseCommentTokens(lex *Lexer, annotation []byte) ([]Comment, error) {
+	return parseCommentTokens(lex, annotation, sl.trimComment), nil
+}
+
+// trimComment reports whether r is whitespace or a symbol of the comment notation. The
+// letters of notation such as =begin are not trimmed, they would be trimmed from the words
+// of the comment as well.
+func (sl *SyntaxLexer) trimComment(r rune) bool {
+	switch {
+	case r == rune(WHITESPACE), r == rune(TAB), r == rune(NEWLINE), r == '\r':
+		return true
+	case unicode.IsLetter(r) || unicode.IsDigit(r):
+		return false
+	}
+
+	notation := sl.Syntax.SingleLine + sl.Syntax.MultiLineStart + sl.Syntax.MultiLineEnd + sl.Syntax.CodePrefix
+	return strings.ContainsRune(notation, r)
+}
+
+// atLineStart reports whether the current byte is the first byte of a line
+func (l *Lexer) atLineStart() bool {
+	return l.Current == 0 || l.Source[l.Current-1] == NEWLINE
+}
+
+func (l *Lexer) hasPrefix(prefix string) bool {
+	return bytes.HasPrefix(l.Source[l.Current:], []byte(prefix))
+}
@@ -0,0 +1,71 @@
+package lexer_test
+
+import (
+	"testing"
+
+	"github.com/AntoninoAdornetto/issue-summoner/pkg/lexer"
+	"github.com/stretchr/testify/require"
+)
+
+var fictional = lexer.CommentSyntax{
+	SingleLine:     "%%",
+	MultiLineStart: "<#",
+	MultiLineEnd:   "#>",
+	StringDelims:   `"`,
+}
+
+const fictional_src_code = `
+let x = "%% @TEST_TODO inside of a string"
+%% @TEST_TODO single line comment
+<#
+  @TEST_TODO multi line comment
+  second line
+#>
+let y = 1 <# @TEST_TODO inline #>
+`
+
+// should lex files of a registered extension with the registered comment syntax
+func TestRegisterCommentSyntax(t *testing.T) {
+	require.False(t, lexer.IsSupported(".fict"))
+	require.NoError(t, lexer.RegisterCommentSyntax(".fict", fictional))
+	require.True(t, lexer.IsSupported(".fict"))
+
+	lex, err := lexer.NewLexer([]byte(fictional_src_code), "main.fict")
+	require.NoError(t, err)
+	require.IsType(t, &lexer.SyntaxLexer{}, lex.Manager)
+
+	tokens, err := lex.AnalyzeTokens()
+	require.NoError(t, err)
+	require.Len(t, tokens, 4)
+	require.Equal(t, "%% @TEST_TODO single line comment", string(tokens[0].Lexeme))
+	require.Equal(t, 3, tokens[0].Line)
+	require.Equal(t, 4, tokens[1].Line)
+	require.Equal(t, 7, tokens[1].EndLine)
+	require.Equal(t, "<# @TEST_TODO inline #>", string(tokens[2].Lexeme))
+
+	comments, err := lex.Manager.ParseCommentTokens(lex, annotation)
+	require.NoError(t, err)
+	require.Len(t, comments, 3)
+	require.Equal(t, "single line comment", string(comments[0].Title))
+	require.Equal(t, "multi line comment", string(comments[1].Title))
+	require.Equal(t, "second line", string(comments[1].Description))
+	require.Equal(t, "inline", string(comments[2].Title))
+}
+
+// should return an error when a multi line comment is not closed
+func TestSyntaxLexerUnclosedComment(t *testing.T) {
+	require.NoError(t, lexer.RegisterCommentSyntax(".fict2", fictional))
+	lex, err := lexer.NewLexer([]byte("<# @TEST_TODO no closing notation\n"), "main.fict2")
+	require.NoError(t, err)
+
+	_, err = lex.AnalyzeTokens()
+	require.ErrorContains(t, err, "could not locate closing multi line comment")
+}
+
+// should reject extensions without a leading dot and syntax without any comments
+func TestRegisterCommentSyntaxInvalid(t *testing.T) {
+	require.Error(t, lexer.RegisterCommentSyntax("fict", fictional))
+	require.Error(t, lexer.RegisterCommentSyntax(".fict3", lexer.CommentSyntax{}))
+	require.Error(t, lexer.RegisterCommentSyntax(".fict3", lexer.CommentSyntax{MultiLineStart: "<#"}))
+	require.False(t, lexer.IsSupported(".fict3"))
+}
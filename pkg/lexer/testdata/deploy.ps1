@@ -0,0 +1,13 @@
+<#
+.SYNOPSIS
+    Deploys the application.
+.NOTES
+    @TEST_TODO support staging slots
+    and rollbacks
+#>
+param([string]$Environment = "prod # @TEST_TODO inside of a string")
+
+# @TEST_TODO validate the environment name
+$message = 'it''s # @TEST_TODO inside of an escaped string'
+Write-Host $message # @TEST_TODO use Write-Information
+<# @TEST_TODO inline block #> Write-Host "done"
@@ -0,0 +1,7 @@
+(ns greeter.core)
+
+;; @TEST_TODO clojure comment
+(defn greet [name]
+  (str "Hello ; @TEST_TODO inside of a string" name)) ; @TEST_TODO trailing comment
+
+#_(greet "@TEST_TODO discarded form, not a comment")
@@ -0,0 +1,6 @@
+-module(greeter).
+-export([hello/1]).
+
+%% @TEST_TODO double percent comment
+hello(Name) ->
+    io:format("Hello ~s % @TEST_TODO inside of a string~n", [Name]). % @TEST_TODO trailing comment
@@ -0,0 +1,10 @@
+defmodule Greeter do
+  @moduledoc """
+  # @TEST_TODO not a comment, it's part of the moduledoc
+  """
+
+  # @TEST_TODO single line comment
+  def hello(name) do
+    "Hello #{name} # @TEST_TODO inside of a string" # @TEST_TODO trailing comment
+  end
+end
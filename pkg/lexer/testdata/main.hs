@@ -0,0 +1,16 @@
+module Main where
+
+-- @TEST_TODO single line comment
+main :: IO ()
+main = putStrLn "-- @TEST_TODO inside of a string"
+
+{- @TEST_TODO block comment
+   spans multiple lines -}
+greet :: String -> String
+greet name' = "hello " ++ name' -- @TEST_TODO trailing comment
+
+{- @TEST_TODO nested block comment
+   {- inner -} still a comment -- @TEST_TODO inside of the block comment
+-}
+answer :: Int
+answer = 42 -- @TEST_TODO after the nested comment
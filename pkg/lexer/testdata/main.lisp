@@ -0,0 +1,8 @@
+;;;; @TEST_TODO file header comment
+(defun greet (name)
+  ;; @TEST_TODO indented comment
+  (format t "Hello ~a ; @TEST_TODO inside of a string" name))
+
+#| @TEST_TODO block comment
+   second line |#
+(greet 'world) ; @TEST_TODO trailing comment
@@ -0,0 +1,22 @@
+local greeting = "hello -- @TEST_TODO inside of a string"
+
+-- @TEST_TODO single line comment
+local function greet(name)
+  return greeting .. name -- @TEST_TODO trailing comment
+end
+
+--[[
+  @TEST_TODO block comment
+  spans multiple lines
+]]
+
+local template = [[
+  -- @TEST_TODO inside of a long string
+]]
+
+--[==[
+  @TEST_TODO leveled block comment
+  t[i[j]] = "]]" doesn't close it
+]==]
+local escaped = "\" -- @TEST_TODO inside of an escaped string"
+return greet -- @TEST_TODO after the leveled comment
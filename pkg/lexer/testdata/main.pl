@@ -0,0 +1,19 @@
+#!/usr/bin/perl
+use strict;
+# @TEST_TODO enable warnings
+my @items = (1, 2, 3);
+my $last = $#items; # @TEST_TODO use scalar(@items) instead
+my $msg = "# @TEST_TODO inside of a string";
+my $total
+= 42;
+
+=pod
+
+=head1 NAME
+
+@TEST_TODO document the options
+of the script
+
+=cut
+
+print "done\n"; # @TEST_TODO after the pod
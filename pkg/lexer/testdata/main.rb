@@ -0,0 +1,21 @@
+# @TEST_TODO single line comment
+class Greeter
+  MARKER = "=begin # @TEST_TODO inside of a string"
+
+  def greet(name)
+    puts "hello #{name}" # @TEST_TODO trailing comment
+  end
+
+  def equal?(a, begin_at)
+    a ==begin_at
+  end
+end
+
+=begin
+@TEST_TODO block comment
+  spans multiple lines
+=end
+
+=begin @TEST_TODO title after the opening notation
+=end
+puts 'done' # @TEST_TODO after the block comment
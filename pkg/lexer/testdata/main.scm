@@ -0,0 +1,6 @@
+(define (greet name)
+  ; @TEST_TODO scheme comment
+  (display "Hello ; @TEST_TODO inside of a string"))
+#|
+  @TEST_TODO scheme block comment
+|#
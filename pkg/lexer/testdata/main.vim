@@ -0,0 +1,10 @@
+" @TEST_TODO single line comment
+let g:greeting = 'hello " @TEST_TODO inside of a string'
+let x = "@TEST_TODO not a comment"
+let y = "escaped \" @TEST_TODO still a string"
+
+function! Greet(name)
+  " @TEST_TODO indented comment
+  echo "hello " . a:name
+  return g:greeting . a:name " @TEST_TODO trailing comment
+endfunction
@@ -0,0 +1,8 @@
+const std = @import("std");
+
+// @TEST_TODO single line comment
+pub fn main() void {
+    std.debug.print("// @TEST_TODO inside of a string\n", .{});
+    const c = '"'; // @TEST_TODO trailing comment
+    _ = c;
+}
@@ -0,0 +1,16 @@
+-- @TEST_TODO add an index on users.email
+CREATE TABLE users (
+    id SERIAL PRIMARY KEY,
+    email TEXT NOT NULL, -- @TEST_TODO enforce lower case emails
+    note TEXT DEFAULT '-- @TEST_TODO inside of a string',
+    "weird--name /* @TEST_TODO inside of an identifier */" TEXT
+);
+
+/*
+ * @TEST_TODO backfill the created_at column
+ * of the existing users
+ */
+ALTER TABLE users ADD COLUMN created_at TIMESTAMP;
+
+INSERT INTO users (email, note) VALUES ('it''s@example.com', 'quoted');
+SELECT 1 --@TEST_TODO inline comment without whitespace
@@ -24,10 +24,14 @@ var (
 	WHITESPACE     byte = ' '
 )
 
+// Token represents a comment that was located in the source code. Line is the line
+// number the token starts on and EndLine is the line number that it ends on. Both
+// values will be the same for single line comments.
 type Token struct {
 	TokenType      TokenType
 	Lexeme         []byte
 	Line           int
+	EndLine        int
 	StartByteIndex int
 	EndByteIndex   int
 }
@@ -40,11 +44,18 @@ func (t *Token) ParseSingleLineCommentToken(annotation []byte, trim func(r rune)
 	end := loc[1]
 	title := bytes.TrimFunc(t.Lexeme[end:], trim)
 	return Comment{
-		Title:  title,
-		Source: t.Lexeme,
+		Annotation: t.Lexeme[loc[0]:end],
+		Title:      title,
+		Source:     t.Lexeme,
 	}
 }
 
+// ParseMultiLineCommentToken locates the annotation in a multi line comment. The rest of
+// the line that contains the annotation is the title and the lines that follow it are the
+// description. The description is sliced from the comment rather than rebuilt, so the line
+// breaks, the indentation and the spacing of code snippets are preserved. Only the comment
+// notation that decorates the start of each line, such as " * ", and the indentation that
+// every line shares are removed.
 func (t *Token) ParseMultiLineCommentToken(annotation []byte, trim func(r rune) bool) Comment {
 	loc := findAnnotationLocations(annotation, t.Lexeme)
 	if loc == nil {
@@ -55,19 +66,146 @@ func (t *Token) ParseMultiLineCommentToken(annotation []byte, trim func(r rune)
 	newLines := bytes.Split(content, []byte("\n"))
 
 	comment := Comment{
-		Title:  bytes.TrimFunc(newLines[0], trim),
-		Source: t.Lexeme,
+		Annotation: t.Lexeme[loc[0]:end],
+		Title:      bytes.TrimFunc(newLines[0], trim),
+		Source:     t.Lexeme,
 	}
 
-	for i := 1; i < len(newLines); i++ {
-		comment.Description = append(
-			comment.Description,
-			bytes.TrimLeftFunc(newLines[i], trim)...)
+	comment.Description = describe(newLines[1:], trim)
+	return comment
+}
+
+// parseCommentTokens locates the annotated comments in the tokens of lex. trim reports
+// whether a rune is whitespace or comment notation. An annotated single line comment is
+// continued by the single line comments on the lines that follow it, which become its
+// description, see continuesComment.
+func parseCommentTokens(lex *Lexer, annotation []byte, trim func(r rune) bool) []Comment {
+	comments := make([]Comment, 0)
+	for i, token := range lex.Tokens {
+		switch token.TokenType {
+		case SINGLE_LINE_COMMENT:
+			comment := token.ParseSingleLineCommentToken(annotation, trim)
+			if !comment.Validate() {
+				continue
+			}
 
-		if i != len(newLines)-1 {
-			comment.Description = append(comment.Description, ' ')
+			end := i
+			lines := make([][]byte, 0)
+			for end+1 < len(lex.Tokens) && continuesComment(lex, end, annotation) {
+				end++
+				lines = append(lines, lex.Tokens[end].Lexeme)
+			}
+
+			comment.Description = describe(lines, trim)
+			comment.EndTokenIndex = end
+			comment.Push(&comments, lex.FileName, i)
+		case MULTI_LINE_COMMENT:
+			comment := token.ParseMultiLineCommentToken(annotation, trim)
+			comment.Push(&comments, lex.FileName, i)
+		default:
+			continue
 		}
 	}
+	return comments
+}
 
-	return comment
+// continuesComment reports whether the token that follows the token at index i continues
+// it. It does when both are single line comments on consecutive lines, only whitespace is
+// between them, so the next comment is not preceded by code, and the next comment is not
+// annotated itself.
+func continuesComment(lex *Lexer, i int, annotation []byte) bool {
+	prev, next := lex.Tokens[i], lex.Tokens[i+1]
+	if next.TokenType != SINGLE_LINE_COMMENT || next.Line != prev.EndLine+1 {
+		return false
+	}
+
+	between := lex.Source[prev.EndByteIndex+1 : next.StartByteIndex]
+	if len(bytes.TrimSpace(between)) > 0 {
+		return false
+	}
+
+	return findAnnotationLocations(annotation, next.Lexeme) == nil
+}
+
+// describe builds a description from the lines of a comment that follow its title. The
+// comment notation that decorates each line is removed, along with the indentation that
+// the lines share and the blank lines at the start and the end. It's nil when there are
+// no lines left.
+func describe(newLines [][]byte, trim func(r rune) bool) []byte {
+	lines := make([][]byte, 0, len(newLines))
+	for _, line := range newLines {
+		lines = append(lines, bytes.TrimRight(stripDecoration(line, trim), " \t\r"))
+	}
+
+	for len(lines) > 0 && len(lines[0]) == 0 {
+		lines = lines[1:]
+	}
+
+	for len(lines) > 0 && len(lines[len(lines)-1]) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+
+	if len(lines) == 0 {
+		return nil
+	}
+	return bytes.Join(dedent(lines), []byte("\n"))
+}
+
+// isSpace reports whether b is whitespace within a line
+func isSpace(b byte) bool {
+	return b == WHITESPACE || b == TAB || b == '\r'
+}
+
+// stripDecoration removes the comment notation, and the whitespace before it, from the
+// start of line. The whitespace that follows the notation is kept. A line without notation
+// is returned unchanged, so its indentation is kept too.
+func stripDecoration(line []byte, trim func(r rune) bool) []byte {
+	i := 0
+	for i < len(line) && isSpace(line[i]) {
+		i++
+	}
+
+	j := i
+	for j < len(line) && !isSpace(line[j]) && trim(rune(line[j])) {
+		j++
+	}
+
+	if j == i {
+		return line
+	}
+	return line[j:]
+}
+
+// dedent removes the leading whitespace that every line, other than blank lines, starts with
+func dedent(lines [][]byte) [][]byte {
+	var prefix []byte
+	for _, line := range lines {
+		if len(line) == 0 {
+			continue
+		}
+
+		indent := line[:len(line)-len(bytes.TrimLeft(line, " \t"))]
+		if prefix == nil || !bytes.HasPrefix(indent, prefix) {
+			prefix = commonPrefix(prefix, indent)
+		}
+	}
+
+	for i, line := range lines {
+		lines[i] = bytes.TrimPrefix(line, prefix)
+	}
+	return lines
+}
+
+// commonPrefix returns the longest prefix that a and b share. a is nil before the first
+// line, the prefix is then b.
+func commonPrefix(a, b []byte) []byte {
+	if a == nil {
+		return b
+	}
+
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+	return a[:n]
 }
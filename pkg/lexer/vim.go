@@ -0,0 +1,86 @@
+package lexer
+
+import "bytes"
+
+const VIM_EXT = ".vim"
+
+// vimSyntax describes the comments of vim script, a double quote starts a comment and
+// single quotes enclose a string without escapes
+var vimSyntax = CommentSyntax{SingleLine: `"`, StringDelims: "'", RawStringDelims: "'"}
+
+// vimExpressionKeywords are the commands that are followed by an expression, so a double
+// quote after them starts a string, such as echo "hello"
+var vimExpressionKeywords = map[string]bool{
+	"echo":    true,
+	"echon":   true,
+	"echom":   true,
+	"echomsg": true,
+	"echoe":   true,
+	"echoerr": true,
+	"exe":     true,
+	"exec":    true,
+	"execute": true,
+	"return":  true,
+	"if":      true,
+	"elseif":  true,
+	"while":   true,
+	"throw":   true,
+	"in":      true,
+}
+
+// VimLexer tokenizes the comments of vim script. A double quote is either a comment or a
+// string: it starts a comment at the start of a line and after a complete command, such
+// as the " of return a:name " comment, and it starts a string in the position of an
+// expression, such as let msg = "hello" or echo "hello". Backslashes escape the next byte
+// of a double quoted string, single quoted strings have no escapes.
+type VimLexer struct {
+	SyntaxLexer
+}
+
+func (vl *VimLexer) AnalyzeToken(lex *Lexer) error {
+	b := lex.peek()
+	switch {
+	case b == DOUBLE_QUOTE && isVimExpression(lex):
+		return vl.String(lex, b)
+	default:
+		return vl.SyntaxLexer.AnalyzeToken(lex)
+	}
+}
+
+// String skips a string, the double quoted strings of vim script support backslash
+// escapes. A string ends with its line, since vim strings can't span lines.
+func (vl *VimLexer) String(lex *Lexer, delim byte) error {
+	for !lex.isEnd() && lex.peekNext() != delim && lex.peekNext() != NEWLINE {
+		if lex.next() == BACKWARD_SLASH && delim == DOUBLE_QUOTE && lex.peekNext() != NEWLINE {
+			lex.next()
+		}
+	}
+
+	if lex.peekNext() == delim {
+		lex.next() // closing delimiter
+	}
+	return nil
+}
+
+// isVimExpression reports whether the double quote at the current byte is in the position
+// of an expression. It is when it follows an operator, an opening bracket or a command
+// that takes an expression, see vimExpressionKeywords.
+func isVimExpression(lex *Lexer) bool {
+	start := bytes.LastIndexByte(lex.Source[:lex.Current], NEWLINE) + 1
+	before := bytes.TrimRight(lex.Source[start:lex.Current], " \t")
+	if len(bytes.TrimLeft(before, " \t:")) == 0 {
+		return false
+	}
+
+	last := before[len(before)-1]
+	if bytes.IndexByte([]byte("=(,.[{+-*/%?!<>&|~:"), last) >= 0 {
+		return true
+	}
+
+	word := before[bytes.LastIndexAny(before, " \t:|")+1:]
+	return vimExpressionKeywords[string(word)]
+}
+
+func isVim(ext string) bool {
+	return ext == VIM_EXT
+}
@@ -0,0 +1,90 @@
+package lexer
+
+import "bytes"
+
+const (
+	YAML_EXT = ".yaml"
+	YML_EXT  = ".yml"
+)
+
+// YamlLexer tokenizes the comments of yaml files. A # only starts a comment at the start of
+// a line or after whitespace, so url: http://host/#anchor is not a comment. Quotes only
+// start a string at the start of a scalar, so the apostrophe of a plain scalar such as
+// title: don't panic doesn't swallow the comments that follow it. Comments inside of
+// block scalars, such as the shell script of a ci step, are located as well.
+type YamlLexer struct{}
+
+func (yl *YamlLexer) AnalyzeToken(lex *Lexer) error {
+	b := lex.peek()
+	switch {
+	case b == NEWLINE:
+		lex.Line++
+		return nil
+	case (b == DOUBLE_QUOTE || b == QUOTE) && startsScalar(lex):
+		return yl.String(lex, b)
+	case b == HASH && (lex.atLineStart() || isSpace(lex.Source[lex.Current-1])):
+		return yl.Comment(lex)
+	default:
+		return nil
+	}
+}
+
+func (yl *YamlLexer) Comment(lex *Lexer) error {
+	for !lex.isEnd() && lex.peekNext() != NEWLINE {
+		lex.next()
+	}
+	comment := lex.Source[lex.Start : lex.Current+1]
+	lex.addToken(SINGLE_LINE_COMMENT, comment)
+	return nil
+}
+
+// String skips a quoted scalar, which can span multiple lines. A double quote that is
+// escaped with a backslash doesn't close a double quoted scalar and a single quote is
+// escaped by doubling it.
+func (yl *YamlLexer) String(lex *Lexer, delim byte) error {
+	for !lex.isEnd() {
+		b := lex.next()
+		switch {
+		case b == NEWLINE:
+			lex.Line++
+		case b == BACKWARD_SLASH && delim == DOUBLE_QUOTE && !lex.isEnd():
+			if lex.next() == NEWLINE {
+				lex.Line++
+			}
+		case b == delim && delim == QUOTE && lex.peekNext() == QUOTE:
+			lex.next()
+		case b == delim:
+			return nil
+		}
+	}
+	return nil
+}
+
+func (yl *YamlLexer) ParseCommentTokens(lex *Lexer, annotation []byte) ([]Comment, error) {
+	return parseCommentTokens(lex, annotation, trimCommentHash), nil
+}
+
+// startsScalar reports whether the current byte is the first byte of a scalar. It is when
+// it's the first byte of the line, other than the indentation, or when it follows the
+// indicator of a mapping value, a sequence entry or a flow collection.
+func startsScalar(lex *Lexer) bool {
+	start := bytes.LastIndexByte(lex.Source[:lex.Current], NEWLINE) + 1
+	before := bytes.TrimRight(lex.Source[start:lex.Current], " \t")
+	if len(before) == 0 {
+		return true
+	}
+	return bytes.IndexByte([]byte(":-[{,?"), before[len(before)-1]) >= 0
+}
+
+func isYaml(ext string) bool {
+	return ext == YAML_EXT || ext == YML_EXT
+}
+
+func trimCommentHash(r rune) bool {
+	switch r {
+	case rune(WHITESPACE), rune(TAB), rune(NEWLINE), '\r', rune(HASH):
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,87 @@
+/*
+Package preset provides named bundles of command line options. A preset maps flag names
+to the values that should be used when the flag is not explicitly set, which means that
+flags passed on the command line always win over the values of a preset.
+
+Presets are resolved from the built-in presets and the presets section of the config
+file. A configured preset with the same name as a built-in preset overrides the values of
+the built-in preset, one flag at a time:
+
+	"presets": {"security": {"annotation": "@SECURITY|@VULN|@CVE|@UNSAFE|@AUTH"}}
+*/
+package preset
+
+import (
+	"fmt"
+	"maps"
+	"sort"
+
+	"github.com/spf13/pflag"
+)
+
+const SECURITY = "security"
+
+// Preset maps flag names to flag values
+type Preset map[string]string
+
+// The annotation flag is a regular expression, an alternation will match any of the
+// security relevant annotations in a single pass
+var builtins = map[string]Preset{
+	SECURITY: {
+		"annotation": "@SECURITY|@VULN|@CVE|@UNSAFE",
+		"verbose":    "true",
+	},
+}
+
+// Resolve returns the preset with the given name. The values of a configured preset
+// take precedence over the built-in preset with the same name.
+func Resolve(name string, configured map[string]Preset) (Preset, error) {
+	builtin, isBuiltin := builtins[name]
+	custom, isCustom := configured[name]
+	if !isBuiltin && !isCustom {
+		return nil, fmt.Errorf("unknown preset %s. available presets: %v", name, Names(configured))
+	}
+
+	p := Preset{}
+	maps.Copy(p, builtin)
+	maps.Copy(p, custom)
+	return p, nil
+}
+
+// Names returns the sorted names of the built-in and configured presets
+func Names(configured map[string]Preset) []string {
+	names := make([]string, 0, len(builtins)+len(configured))
+	for name := range builtins {
+		names = append(names, name)
+	}
+
+	for name := range configured {
+		if _, ok := builtins[name]; !ok {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// Apply sets the value of every flag in the preset that was not explicitly set by the
+// user. An error is returned when the preset contains a flag that flags does not define.
+func (p Preset) Apply(flags *pflag.FlagSet) error {
+	for name, value := range p {
+		flag := flags.Lookup(name)
+		if flag == nil {
+			return fmt.Errorf("preset contains unknown flag %s", name)
+		}
+
+		if flag.Changed {
+			continue
+		}
+
+		if err := flag.Value.Set(value); err != nil {
+			return fmt.Errorf("preset value %s for flag %s: %w", value, name, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package preset_test
+
+import (
+	"testing"
+
+	"github.com/AntoninoAdornetto/issue-summoner/pkg/preset"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/require"
+)
+
+func newFlagSet() *pflag.FlagSet {
+	flags := pflag.NewFlagSet("scan", pflag.ContinueOnError)
+	flags.StringP("annotation", "a", "@TODO", "")
+	flags.BoolP("verbose", "v", false, "")
+	flags.StringP("mode", "m", "pending", "")
+	return flags
+}
+
+// should produce the option set of the built-in security preset
+func TestSecurityPreset(t *testing.T) {
+	p, err := preset.Resolve(preset.SECURITY, nil)
+	require.NoError(t, err)
+
+	flags := newFlagSet()
+	require.NoError(t, flags.Parse([]string{}))
+	require.NoError(t, p.Apply(flags))
+
+	annotation, err := flags.GetString("annotation")
+	require.NoError(t, err)
+	require.Equal(t, "@SECURITY|@VULN|@CVE|@UNSAFE", annotation)
+
+	verbose, err := flags.GetBool("verbose")
+	require.NoError(t, err)
+	require.True(t, verbose)
+
+	mode, err := flags.GetString("mode")
+	require.NoError(t, err)
+	require.Equal(t, "pending", mode)
+}
+
+// should keep the values of flags that were explicitly set
+func TestPresetExplicitFlagsWin(t *testing.T) {
+	p, err := preset.Resolve(preset.SECURITY, nil)
+	require.NoError(t, err)
+
+	flags := newFlagSet()
+	require.NoError(t, flags.Parse([]string{"-a", "@FIXME", "--verbose=false"}))
+	require.NoError(t, p.Apply(flags))
+
+	annotation, err := flags.GetString("annotation")
+	require.NoError(t, err)
+	require.Equal(t, "@FIXME", annotation)
+
+	verbose, err := flags.GetBool("verbose")
+	require.NoError(t, err)
+	require.False(t, verbose)
+}
+
+// should override the built-in values with configured values and resolve custom presets
+func TestPresetConfigured(t *testing.T) {
+	configured := map[string]preset.Preset{
+		preset.SECURITY: {"annotation": "@SECURITY|@AUTH"},
+		"processed":     {"mode": "processed"},
+	}
+
+	p, err := preset.Resolve(preset.SECURITY, configured)
+	require.NoError(t, err)
+	require.Equal(t, preset.Preset{"annotation": "@SECURITY|@AUTH", "verbose": "true"}, p)
+
+	p, err = preset.Resolve("processed", configured)
+	require.NoError(t, err)
+	require.Equal(t, preset.Preset{"mode": "processed"}, p)
+
+	require.Equal(t, []string{"processed", "security"}, preset.Names(configured))
+}
+
+// should return an error for unknown presets and unknown flags
+func TestPresetErrors(t *testing.T) {
+	_, err := preset.Resolve("nope", nil)
+	require.ErrorContains(t, err, "unknown preset nope")
+
+	flags := newFlagSet()
+	err = preset.Preset{"redact": "true"}.Apply(flags)
+	require.ErrorContains(t, err, "unknown flag redact")
+}
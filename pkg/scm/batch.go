@@ -0,0 +1,46 @@
+package scm
+
+import "errors"
+
+// errNotReported is the error of an issue that Report never sent a Reporter for
+var errNotReported = errors.New("the issue was not reported")
+
+// ReportResult is the outcome of reporting a single issue of a batch. Issue is the issue
+// that was passed to ReportBatch. ID, Number and URL describe the issue that was created
+// and Err is the error of an issue that wasn't.
+type ReportResult struct {
+	Issue  GitIssue
+	ID     int64
+	Number int
+	URL    string
+	Err    error
+}
+
+// ReportBatch reports issues with gitManager and waits for every issue to be created or to
+// fail. The results are in the same order as issues, regardless of the order in which the
+// issues were created. The error joins the errors of the issues that failed and is nil
+// when every issue was created. Use Report directly to show the progress of each issue.
+func ReportBatch(gitManager GitConfigManager, issues []GitIssue) ([]ReportResult, error) {
+	queue := make([]GitIssue, len(issues))
+	results := make([]ReportResult, len(issues))
+	for i, is := range issues {
+		// the queue index locates the result of the issue, the issue of the caller is
+		// kept as it is in the result
+		queue[i] = is
+		queue[i].QueueIndex = i
+		results[i] = ReportResult{Issue: is, Err: errNotReported}
+	}
+
+	for r := range gitManager.Report(queue) {
+		result := &results[r.QueueIndex]
+		result.ID, result.Number, result.URL, result.Err = r.ID, r.Number, r.URL, r.Err
+	}
+
+	errs := make([]error, 0)
+	for _, result := range results {
+		if result.Err != nil {
+			errs = append(errs, result.Err)
+		}
+	}
+	return results, errors.Join(errs...)
+}
@@ -0,0 +1,97 @@
+package scm
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// should return a result for every issue in the order of the input, with the error of
+// each issue that failed
+func TestReportBatch(t *testing.T) {
+	gh := newTestGitHub(t, func(w http.ResponseWriter, r *http.Request) {
+		body := map[string]any{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+
+		switch body["title"] {
+		case "add retries":
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id":7,"number":3,"html_url":"https://github.com/tech/debt/issues/3"}`))
+		case "cache the results":
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			w.Write([]byte(`{"message":"Validation Failed"}`))
+		case "drop the legacy api":
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id":8,"number":4,"html_url":"https://github.com/tech/debt/issues/4"}`))
+		default:
+			t.Errorf("unexpected issue %v", body["title"])
+		}
+	})
+
+	issues := []GitIssue{
+		{Title: "add retries", QueueIndex: 5},
+		{Title: "cache the results", QueueIndex: 6},
+		{Title: "   "},
+		{Title: "drop the legacy api", QueueIndex: 7},
+	}
+
+	results, err := ReportBatch(gh, issues)
+	require.Len(t, results, len(issues))
+	for i, result := range results {
+		require.Equal(t, issues[i], result.Issue, "the issue of the caller is kept")
+	}
+
+	require.NoError(t, results[0].Err)
+	require.Equal(t, int64(7), results[0].ID)
+	require.Equal(t, 3, results[0].Number)
+	require.Equal(t, "https://github.com/tech/debt/issues/3", results[0].URL)
+
+	require.ErrorContains(t, results[1].Err, "Validation Failed")
+	require.Zero(t, results[1].Number)
+
+	require.ErrorIs(t, results[2].Err, ErrEmptyTitle)
+
+	require.NoError(t, results[3].Err)
+	require.Equal(t, 4, results[3].Number)
+
+	require.ErrorIs(t, err, ErrEmptyTitle)
+	require.ErrorContains(t, err, "Validation Failed")
+}
+
+// should return no error when every issue was created
+func TestReportBatchSuccess(t *testing.T) {
+	var number atomic.Int64
+	gh := newTestGitHub(t, func(w http.ResponseWriter, r *http.Request) {
+		n := number.Add(1)
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"id":%d,"number":%d}`, n, n)
+	})
+
+	results, err := ReportBatch(gh, []GitIssue{{Title: "add retries"}, {Title: "cache the results"}})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	require.NotZero(t, results[0].Number)
+	require.NotZero(t, results[1].Number)
+	require.NotEqual(t, results[0].Number, results[1].Number)
+}
+
+// should fail every issue of a gitea batch when a label does not exist
+func TestReportBatchGiteaUnknownLabel(t *testing.T) {
+	gt := newTestGitea(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[]`))
+	})
+
+	results, err := ReportBatch(gt, []GitIssue{
+		{Title: "add retries", Labels: []string{"tech-debt"}},
+		{Title: "cache the results"},
+	})
+	require.ErrorContains(t, err, "label tech-debt does not exist")
+	require.Len(t, results, 2)
+	for _, result := range results {
+		require.Error(t, result.Err)
+	}
+}
@@ -0,0 +1,80 @@
+package scm
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// not_committed_hash is the commit that git blame attributes to lines that have not been
+// committed yet
+const not_committed_hash = "0000000000000000000000000000000000000000"
+
+// BlameAuthor is the author of the commit that last changed a line. Committed is false
+// when the line has uncommitted changes, in which case Name and Email are empty.
+type BlameAuthor struct {
+	Name      string
+	Email     string
+	Committed bool
+}
+
+func (a BlameAuthor) String() string {
+	if !a.Committed {
+		return ""
+	}
+
+	if a.Email == "" {
+		return a.Name
+	}
+
+	return fmt.Sprintf("%s <%s>", a.Name, a.Email)
+}
+
+// Blame runs git blame on a single line of the file located at path, in the repository
+// located at dir, and returns the author of the commit that last changed it
+func Blame(runner GitRunner, dir, path string, line int) (BlameAuthor, error) {
+	lines := strconv.Itoa(line) + "," + strconv.Itoa(line)
+	out, err := runner.Run(dir, "blame", "--porcelain", "-L", lines, "--", path)
+	if err != nil {
+		return BlameAuthor{}, err
+	}
+
+	return parseBlame(out)
+}
+
+// parseBlame reads the author of the first commit in the output of git blame --porcelain
+func parseBlame(out []byte) (BlameAuthor, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	if !scanner.Scan() {
+		return BlameAuthor{}, fmt.Errorf("unexpected git blame output: %q", out)
+	}
+
+	hash, _, _ := strings.Cut(scanner.Text(), " ")
+	if hash == not_committed_hash {
+		return BlameAuthor{}, nil
+	}
+
+	author := BlameAuthor{Committed: true}
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "\t") {
+			break
+		}
+
+		key, value, _ := strings.Cut(line, " ")
+		switch key {
+		case "author":
+			author.Name = value
+		case "author-mail":
+			author.Email = strings.TrimSuffix(strings.TrimPrefix(value, "<"), ">")
+		}
+	}
+
+	if author.Name == "" {
+		return BlameAuthor{}, fmt.Errorf("unexpected git blame output: %q", out)
+	}
+
+	return author, scanner.Err()
+}
@@ -0,0 +1,430 @@
+package scm
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/AntoninoAdornetto/issue-summoner/pkg/preset"
+)
+
+const (
+	CONFIG_DIR_ENV   = "ISSUE_SUMMONER_CONFIG_DIR"
+	XDG_CONFIG_ENV   = "XDG_CONFIG_HOME"
+	config_dir_name  = "issue-summoner"
+	config_file_name = "config.json"
+	cache_dir_name   = "cache"
+	languages_file   = "languages.json"
+	config_dir_mode  = 0700
+	config_file_mode = 0600
+)
+
+// ScmTokenConfig stores the access token for a source code management platform.
+// Either AccessToken or Encrypted will be set, depending on if the user opted
+// into encrypting their access token. Repositories holds tokens that only apply to a
+// single repository, such as fine grained tokens, keyed by owner/repo in lower case.
+// The platform token is used for repositories without a token of their own.
+type ScmTokenConfig struct {
+	AccessToken  string                    `json:",omitempty"`
+	Encrypted    *EncryptedToken           `json:",omitempty"`
+	Repositories map[string]ScmTokenConfig `json:"repositories,omitempty"`
+}
+
+// repositoryKey is the key of the token of repo in ScmTokenConfig.Repositories
+func repositoryKey(repo RemoteRepository) string {
+	return strings.ToLower(repo.String())
+}
+
+type IssueSummonerConfig = map[string]ScmTokenConfig
+
+// HooksConfig contains user supplied commands that are executed after specific
+// events take place. IssueCreated is executed once for every issue that is
+// successfully created during the report command.
+type HooksConfig struct {
+	IssueCreated string `json:"issue_created,omitempty"`
+}
+
+// Config is the in memory representation of config.json. The document is versioned
+// so that older config files can be migrated as new fields are introduced. Config
+// files that were written before versioning was introduced contain a bare map of
+// access tokens, keyed by source code management platform, with an optional hooks key.
+// Owner and Repo, when set, override the repository that issues are reported to.
+// BaseURL is the api url of a GitHub Enterprise Server installation, api.github.com is
+// used when it's empty. GiteaURL is the url of a self-hosted Gitea instance.
+// AnnotationLabels are the labels that are added to the issues of each annotation.
+type Config struct {
+	Version          int                      `json:"version"`
+	Tokens           IssueSummonerConfig      `json:"tokens"`
+	Hooks            HooksConfig              `json:"hooks"`
+	Presets          map[string]preset.Preset `json:"presets,omitempty"`
+	Owner            string                   `json:"owner,omitempty"`
+	Repo             string                   `json:"repo,omitempty"`
+	BaseURL          string                   `json:"base_url,omitempty"`
+	GiteaURL         string                   `json:"gitea_url,omitempty"`
+	AnnotationLabels AnnotationLabels         `json:"annotation_labels,omitempty"`
+}
+
+// PlatformURL returns the url of the self-hosted installation of the platform. An empty
+// string is returned when the default url of the platform should be used.
+func (c Config) PlatformURL(scm string) string {
+	if scm == GITEA {
+		return c.GiteaURL
+	}
+	return c.BaseURL
+}
+
+const (
+	CONFIG_VERSION     = 1
+	config_key_version = "version"
+	config_key_hooks   = "hooks"
+)
+
+var (
+	ErrNewerConfigVersion = errors.New("config written by a newer issue-summoner")
+	// ErrConfigNotFound is returned when the config file has not been created yet, which
+	// means that issue-summoner was never authorized
+	ErrConfigNotFound = errors.New("config file does not exist")
+	// ErrTokenMissing is returned when the config file exists but doesn't hold an access
+	// token for the platform
+	ErrTokenMissing = errors.New("access token does not exist")
+)
+
+// configDocument prevents infinite recursion when (un)marshaling Config
+type configDocument Config
+
+func (c *Config) UnmarshalJSON(data []byte) error {
+	doc := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+
+	rawVersion, ok := doc[config_key_version]
+	if !ok {
+		return c.migrateLegacy(doc)
+	}
+
+	version := 0
+	if err := json.Unmarshal(rawVersion, &version); err != nil {
+		return err
+	}
+
+	if version > CONFIG_VERSION {
+		return fmt.Errorf(
+			"%w (version %d). the latest version supported is %d, please upgrade issue-summoner",
+			ErrNewerConfigVersion,
+			version,
+			CONFIG_VERSION,
+		)
+	}
+
+	cfg := configDocument{}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return err
+	}
+
+	*c = Config(cfg)
+	if c.Tokens == nil {
+		c.Tokens = make(IssueSummonerConfig)
+	}
+
+	return nil
+}
+
+// migrateLegacy converts the versionless config document into the latest version
+func (c *Config) migrateLegacy(doc map[string]json.RawMessage) error {
+	c.Version = CONFIG_VERSION
+	c.Tokens = make(IssueSummonerConfig)
+	for key, val := range doc {
+		if key == config_key_hooks {
+			if err := json.Unmarshal(val, &c.Hooks); err != nil {
+				return err
+			}
+			continue
+		}
+
+		token := ScmTokenConfig{}
+		if err := json.Unmarshal(val, &token); err != nil {
+			return err
+		}
+		c.Tokens[key] = token
+	}
+
+	return nil
+}
+
+// MarshalJSON always emits the latest version of the config document
+func (c Config) MarshalJSON() ([]byte, error) {
+	cfg := configDocument(c)
+	cfg.Version = CONFIG_VERSION
+	if cfg.Tokens == nil {
+		cfg.Tokens = make(IssueSummonerConfig)
+	}
+	return json.Marshal(cfg)
+}
+
+// ReadConfig decodes the config file. The error returned matches ErrConfigNotFound, with
+// errors.Is, when the config file has not been created yet. A malformed config file
+// returns a ConfigError that locates the malformed value.
+func ReadConfig() (Config, error) {
+	config := Config{Tokens: make(IssueSummonerConfig)}
+	path, data, err := readConfigFile()
+	if err != nil {
+		return config, err
+	}
+
+	if err := json.Unmarshal(data, &config); err != nil {
+		if errors.Is(err, ErrNewerConfigVersion) {
+			return config, err
+		}
+		return config, fmt.Errorf("invalid config file %s: %w", path, decodeError(data, err))
+	}
+
+	return config, nil
+}
+
+// readConfigFile returns the path and the contents of the config file. The error wraps
+// ErrConfigNotFound, along with the error of the file system, when the file doesn't exist.
+func readConfigFile() (string, []byte, error) {
+	path, err := findConfigFilePath()
+	if err != nil {
+		return "", nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return path, nil, fmt.Errorf("%w: %w", ErrConfigNotFound, err)
+		}
+		return path, nil, fmt.Errorf("unable to read the config file %s: %w", path, err)
+	}
+
+	return path, data, nil
+}
+
+// WriteConfig writes the config to the config file, creating the config directory
+// when it does not exist. The config holds access tokens, so only the owner may read or
+// write the file and its directory, the mode of a file from an older version is
+// restricted when it is written.
+func WriteConfig(config Config) error {
+	path, err := configDir()
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(path, config_dir_mode)
+	if err != nil {
+		return err
+	}
+
+	configFilePath, err := getConfigFilePath()
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(configFilePath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, config_file_mode)
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	if err := file.Chmod(config_file_mode); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.Write(data); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// WriteToken accepts an access token and the source code management platform
+// (GitHub, GitLab etc...) and will write the token to a configuration file.
+// This will be used to authorize future requests for reporting issues. The token is
+// only used for repo when the owner and name of repo are set, otherwise it is used for
+// every repository of the platform. Any other settings that exist in the config file
+// are preserved.
+func WriteToken(token string, scm string, repo RemoteRepository) error {
+	config, err := ReadConfig()
+	if err != nil && !errors.Is(err, ErrConfigNotFound) {
+		return err
+	}
+
+	// @TODO add remaining source code management platforms once other adapters are implemented
+	switch scm {
+	case GITEA:
+	default:
+		scm = GITHUB
+	}
+
+	tokenConfig := config.Tokens[scm]
+	if repo.Owner == "" || repo.Name == "" {
+		tokenConfig.AccessToken, tokenConfig.Encrypted = token, nil
+	} else {
+		if tokenConfig.Repositories == nil {
+			tokenConfig.Repositories = make(map[string]ScmTokenConfig)
+		}
+		tokenConfig.Repositories[repositoryKey(repo)] = ScmTokenConfig{AccessToken: token}
+	}
+
+	config.Tokens[scm] = tokenConfig
+	return WriteConfig(config)
+}
+
+// TokenEnvVars returns the environment variables that can hold an access token for the
+// platform, in the order that they are checked. For GitHub these are
+// ISSUE_SUMMONER_GITHUB_TOKEN and GITHUB_TOKEN.
+func TokenEnvVars(scm string) []string {
+	name := strings.ToUpper(scm) + "_TOKEN"
+	return []string{"ISSUE_SUMMONER_" + name, name}
+}
+
+// ReadAccessToken returns the access token for repo. An access token that is set in one
+// of the environment variables of the platform, see TokenEnvVars, takes precedence over
+// the config file. Otherwise the token of repo is read from the config file, falling back
+// to the token of the platform when repo doesn't have a token of its own. The error
+// matches ErrConfigNotFound when the config file doesn't exist and ErrTokenMissing when
+// it doesn't hold a token for repo, so that the two can be told apart with errors.Is.
+func ReadAccessToken(scm string, repo RemoteRepository) (string, error) {
+	for _, env := range TokenEnvVars(scm) {
+		if token := os.Getenv(env); token != "" {
+			return token, nil
+		}
+	}
+
+	config, err := ReadConfig()
+	if err != nil {
+		return "", err
+	}
+
+	tokenConfig := config.Tokens[scm]
+	if repoConfig, ok := tokenConfig.Repositories[repositoryKey(repo)]; ok {
+		tokenConfig = repoConfig
+	}
+
+	accessToken, err := decryptAccessToken(tokenConfig)
+	if err != nil {
+		return "", err
+	}
+
+	if accessToken == "" {
+		return "", missingTokenError()
+	}
+
+	return accessToken, nil
+}
+
+// missingTokenError wraps ErrTokenMissing. The problems of the config file, see
+// ValidateConfig, are included since a malformed token config is skipped when the file
+// is read.
+func missingTokenError() error {
+	err := ErrTokenMissing
+	path, data, readErr := readConfigFile()
+	if readErr != nil {
+		return err
+	}
+
+	if problems := ValidateConfig(data); problems != nil {
+		return fmt.Errorf("%w. the config file %s may be malformed:\n%w", err, path, problems)
+	}
+	return err
+}
+
+// configDir resolves the directory that stores the issue-summoner config file.
+// ISSUE_SUMMONER_CONFIG_DIR takes precedence over everything else and is mostly
+// useful for tests. Otherwise, XDG_CONFIG_HOME is preferred when it is set to an
+// absolute path, and we fall back to the platform default from os.UserConfigDir.
+// (~/.config on linux, ~/Library/Application Support on macOS, %AppData% on windows)
+func configDir() (string, error) {
+	if dir := os.Getenv(CONFIG_DIR_ENV); dir != "" {
+		return dir, nil
+	}
+
+	if xdg := os.Getenv(XDG_CONFIG_ENV); filepath.IsAbs(xdg) {
+		return filepath.Join(xdg, config_dir_name), nil
+	}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, config_dir_name), nil
+}
+
+// ScanCachePath returns the path of the scan cache file for the project located at
+// root. Each project has its own cache file in the cache directory of the config dir.
+func ScanCachePath(root string) (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256([]byte(filepath.Clean(root)))
+	name := fmt.Sprintf("scan-%s.json", hex.EncodeToString(sum[:8]))
+	return filepath.Join(dir, cache_dir_name, name), nil
+}
+
+// LanguagesPath returns the path of the languages file in the config dir, which declares
+// the comment syntax of languages that are not built in
+func LanguagesPath() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, languages_file), nil
+}
+
+func getConfigFilePath() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, config_file_name), nil
+}
+
+// findConfigFilePath returns the config file path that should be used for reads.
+// Versions prior to respecting XDG_CONFIG_HOME and os.UserConfigDir always wrote
+// the config file to ~/.config/issue-summoner. If the config file does not exist
+// at the new location, but does exist at the legacy location, the legacy path is
+// returned so users are not logged out after upgrading.
+func findConfigFilePath() (string, error) {
+	path, err := getConfigFilePath()
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := os.Stat(path); err == nil || os.Getenv(CONFIG_DIR_ENV) != "" {
+		return path, nil
+	}
+
+	legacyPath, err := legacyConfigFilePath()
+	if err != nil {
+		return path, nil
+	}
+
+	if _, err := os.Stat(legacyPath); err == nil {
+		return legacyPath, nil
+	}
+
+	return path, nil
+}
+
+func legacyConfigFilePath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", config_dir_name, config_file_name), nil
+}
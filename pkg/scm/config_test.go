@@ -0,0 +1,306 @@
+package scm_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/AntoninoAdornetto/issue-summoner/pkg/scm"
+	"github.com/stretchr/testify/require"
+)
+
+// should write and read the access token from the directory specified
+// by the ISSUE_SUMMONER_CONFIG_DIR environment variable
+func TestConfigDirOverride(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(scm.CONFIG_DIR_ENV, dir)
+
+	require.NoError(t, scm.WriteToken("test-token", scm.GITHUB, scm.RemoteRepository{}))
+	require.FileExists(t, filepath.Join(dir, "config.json"))
+
+	token, err := scm.ReadAccessToken(scm.GITHUB, scm.RemoteRepository{})
+	require.NoError(t, err)
+	require.Equal(t, "test-token", token)
+}
+
+// should prefer XDG_CONFIG_HOME when the override is not set
+func TestConfigDirXDG(t *testing.T) {
+	xdg := t.TempDir()
+	t.Setenv(scm.CONFIG_DIR_ENV, "")
+	t.Setenv(scm.XDG_CONFIG_ENV, xdg)
+	t.Setenv("HOME", t.TempDir())
+
+	require.NoError(t, scm.WriteToken("xdg-token", scm.GITHUB, scm.RemoteRepository{}))
+	require.FileExists(t, filepath.Join(xdg, "issue-summoner", "config.json"))
+
+	token, err := scm.ReadAccessToken(scm.GITHUB, scm.RemoteRepository{})
+	require.NoError(t, err)
+	require.Equal(t, "xdg-token", token)
+}
+
+// should read the config file from the legacy ~/.config/issue-summoner location
+// when the config file does not exist in the new location
+func TestConfigDirLegacyFallback(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv(scm.CONFIG_DIR_ENV, "")
+	t.Setenv(scm.XDG_CONFIG_ENV, t.TempDir())
+	t.Setenv("HOME", home)
+
+	legacyDir := filepath.Join(home, ".config", "issue-summoner")
+	require.NoError(t, os.MkdirAll(legacyDir, 0755))
+	err := os.WriteFile(
+		filepath.Join(legacyDir, "config.json"),
+		[]byte(`{"github":{"AccessToken":"legacy-token"}}`),
+		0666,
+	)
+	require.NoError(t, err)
+
+	token, err := scm.ReadAccessToken(scm.GITHUB, scm.RemoteRepository{})
+	require.NoError(t, err)
+	require.Equal(t, "legacy-token", token)
+}
+
+// should return ErrConfigNotFound when no config file has been written
+func TestReadAccessTokenNoConfig(t *testing.T) {
+	t.Setenv(scm.CONFIG_DIR_ENV, t.TempDir())
+	token, err := scm.ReadAccessToken(scm.GITHUB, scm.RemoteRepository{})
+	require.Empty(t, token)
+	require.ErrorIs(t, err, scm.ErrConfigNotFound)
+	require.ErrorIs(t, err, os.ErrNotExist)
+	require.NotErrorIs(t, err, scm.ErrTokenMissing)
+}
+
+// should return ErrTokenMissing when the config file doesn't hold a token for the platform
+func TestReadAccessTokenMissing(t *testing.T) {
+	t.Setenv(scm.CONFIG_DIR_ENV, t.TempDir())
+	require.NoError(t, scm.WriteToken("gitea-token", scm.GITEA, scm.RemoteRepository{}))
+
+	token, err := scm.ReadAccessToken(scm.GITHUB, scm.RemoteRepository{})
+	require.Empty(t, token)
+	require.ErrorIs(t, err, scm.ErrTokenMissing)
+	require.NotErrorIs(t, err, scm.ErrConfigNotFound)
+}
+
+// should return an error that is neither sentinel when the config file can't be read
+func TestReadConfigUnreadable(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(scm.CONFIG_DIR_ENV, dir)
+	// a directory in place of the config file can't be read as a file
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "config.json"), 0755))
+
+	_, err := scm.ReadConfig()
+	require.ErrorContains(t, err, "unable to read the config file")
+	require.NotErrorIs(t, err, scm.ErrConfigNotFound)
+
+	_, err = scm.ReadAccessToken(scm.GITHUB, scm.RemoteRepository{})
+	require.NotErrorIs(t, err, scm.ErrConfigNotFound)
+	require.NotErrorIs(t, err, scm.ErrTokenMissing)
+}
+
+// should prefer the access token of the environment over the config file, checking the
+// variable of issue-summoner before the variable of the platform
+func TestReadAccessTokenEnv(t *testing.T) {
+	t.Setenv(scm.CONFIG_DIR_ENV, t.TempDir())
+	t.Setenv("ISSUE_SUMMONER_GITHUB_TOKEN", "")
+	t.Setenv("GITHUB_TOKEN", "")
+	require.NoError(t, scm.WriteToken("file-token", scm.GITHUB, scm.RemoteRepository{}))
+
+	token, err := scm.ReadAccessToken(scm.GITHUB, scm.RemoteRepository{})
+	require.NoError(t, err)
+	require.Equal(t, "file-token", token)
+
+	t.Setenv("GITHUB_TOKEN", "platform-token")
+	token, err = scm.ReadAccessToken(scm.GITHUB, scm.RemoteRepository{})
+	require.NoError(t, err)
+	require.Equal(t, "platform-token", token)
+
+	t.Setenv("ISSUE_SUMMONER_GITHUB_TOKEN", "summoner-token")
+	token, err = scm.ReadAccessToken(scm.GITHUB, scm.RemoteRepository{})
+	require.NoError(t, err)
+	require.Equal(t, "summoner-token", token)
+
+	token, err = scm.ReadAccessToken(scm.GITEA, scm.RemoteRepository{})
+	require.Error(t, err, "the variables of other platforms are not used")
+	require.Empty(t, token)
+}
+
+// should read the access token from the environment when no config file exists
+func TestReadAccessTokenEnvNoConfig(t *testing.T) {
+	t.Setenv(scm.CONFIG_DIR_ENV, t.TempDir())
+	t.Setenv("ISSUE_SUMMONER_GITEA_TOKEN", "")
+	t.Setenv("GITEA_TOKEN", "gitea-token")
+
+	token, err := scm.ReadAccessToken(scm.GITEA, scm.RemoteRepository{Owner: "tech", Name: "debt"})
+	require.NoError(t, err)
+	require.Equal(t, "gitea-token", token)
+}
+
+// should preserve the hooks section of the config file when writing a new token
+func TestWriteTokenPreservesHooks(t *testing.T) {
+	t.Setenv(scm.CONFIG_DIR_ENV, t.TempDir())
+	err := scm.WriteConfig(scm.Config{
+		Tokens: scm.IssueSummonerConfig{},
+		Hooks:  scm.HooksConfig{IssueCreated: "echo $ISSUE_SUMMONER_ISSUE_URL"},
+	})
+	require.NoError(t, err)
+	require.NoError(t, scm.WriteToken("test-token", scm.GITHUB, scm.RemoteRepository{}))
+
+	config, err := scm.ReadConfig()
+	require.NoError(t, err)
+	require.Equal(t, "echo $ISSUE_SUMMONER_ISSUE_URL", config.Hooks.IssueCreated)
+	require.Equal(t, "test-token", config.Tokens[scm.GITHUB].AccessToken)
+}
+
+func writeRawConfig(t *testing.T, data string) string {
+	dir := t.TempDir()
+	t.Setenv(scm.CONFIG_DIR_ENV, dir)
+	path := filepath.Join(dir, "config.json")
+	require.NoError(t, os.WriteFile(path, []byte(data), 0666))
+	return path
+}
+
+// should migrate the versionless config document to the latest version
+func TestReadConfigLegacyMigration(t *testing.T) {
+	writeRawConfig(
+		t,
+		`{"github":{"AccessToken":"legacy-token"},"hooks":{"issue_created":"echo hi"}}`,
+	)
+
+	config, err := scm.ReadConfig()
+	require.NoError(t, err)
+	require.Equal(t, scm.CONFIG_VERSION, config.Version)
+	require.Equal(t, "legacy-token", config.Tokens[scm.GITHUB].AccessToken)
+	require.Equal(t, "echo hi", config.Hooks.IssueCreated)
+}
+
+// should always write the latest version of the config document
+func TestWriteConfigLatestVersion(t *testing.T) {
+	path := writeRawConfig(t, `{"github":{"AccessToken":"legacy-token"}}`)
+	require.NoError(t, scm.WriteToken("new-token", scm.GITHUB, scm.RemoteRepository{}))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.JSONEq(
+		t,
+		`{"version":1,"tokens":{"github":{"AccessToken":"new-token"}},"hooks":{}}`,
+		string(data),
+	)
+}
+
+// should only let the owner read the config file and its directory, including a config
+// file that an older version created
+func TestWriteConfigPermissions(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "issue-summoner")
+	t.Setenv(scm.CONFIG_DIR_ENV, dir)
+
+	require.NoError(t, scm.WriteToken("test-token", scm.GITHUB, scm.RemoteRepository{}))
+	info, err := os.Stat(dir)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0700), info.Mode().Perm())
+
+	path := filepath.Join(dir, "config.json")
+	info, err = os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	require.NoError(t, os.Chmod(path, 0666))
+	require.NoError(t, scm.WriteToken("new-token", scm.GITHUB, scm.RemoteRepository{}))
+	info, err = os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+// should return a descriptive error when the config was written by a newer version
+func TestReadConfigNewerVersion(t *testing.T) {
+	writeRawConfig(t, `{"version":99,"tokens":{},"unknown":{"field":true}}`)
+
+	_, err := scm.ReadConfig()
+	require.ErrorIs(t, err, scm.ErrNewerConfigVersion)
+	require.ErrorContains(t, err, "version 99")
+}
+
+// should read the presets section of the config file
+func TestReadConfigPresets(t *testing.T) {
+	writeRawConfig(
+		t,
+		`{"version":1,"tokens":{},"presets":{"security":{"annotation":"@SECURITY|@AUTH"}}}`,
+	)
+
+	config, err := scm.ReadConfig()
+	require.NoError(t, err)
+	require.Equal(t, "@SECURITY|@AUTH", config.Presets["security"]["annotation"])
+}
+
+// should read the labels of each annotation from the config file
+func TestReadConfigAnnotationLabels(t *testing.T) {
+	writeRawConfig(
+		t,
+		`{"version":1,"tokens":{},"annotation_labels":{"@BUG":["bug"],"@HACK":["tech-debt","cleanup"]}}`,
+	)
+
+	config, err := scm.ReadConfig()
+	require.NoError(t, err)
+	require.Equal(t, scm.AnnotationLabels{
+		"@BUG":  {"bug"},
+		"@HACK": {"tech-debt", "cleanup"},
+	}, config.AnnotationLabels)
+}
+
+// should prefer the token of the repository and fall back to the token of the platform
+func TestReadAccessTokenRepository(t *testing.T) {
+	t.Setenv(scm.CONFIG_DIR_ENV, t.TempDir())
+	api := scm.RemoteRepository{Owner: "tech", Name: "api"}
+	web := scm.RemoteRepository{Owner: "tech", Name: "web"}
+
+	require.NoError(t, scm.WriteToken("api-token", scm.GITHUB, api))
+	_, err := scm.ReadAccessToken(scm.GITHUB, web)
+	require.Error(t, err)
+
+	require.NoError(t, scm.WriteToken("platform-token", scm.GITHUB, scm.RemoteRepository{}))
+	require.NoError(t, scm.WriteToken("web-token", scm.GITHUB, web))
+
+	cases := map[scm.RemoteRepository]string{
+		api:                          "api-token",
+		web:                          "web-token",
+		{Owner: "TECH", Name: "Web"}: "web-token",
+		{Owner: "tech", Name: "cli"}: "platform-token",
+		{}:                           "platform-token",
+	}
+
+	for repo, expected := range cases {
+		token, err := scm.ReadAccessToken(scm.GITHUB, repo)
+		require.NoError(t, err)
+		require.Equal(t, expected, token, repo.String())
+	}
+}
+
+// should read the platform token of config files that were written before repository
+// tokens were introduced, and keep them readable by older versions once a repository
+// token is added
+func TestReadAccessTokenRepositoryMigration(t *testing.T) {
+	path := writeRawConfig(t, `{"version":1,"tokens":{"github":{"AccessToken":"old-token"}},"hooks":{}}`)
+
+	repo := scm.RemoteRepository{Owner: "tech", Name: "api"}
+	token, err := scm.ReadAccessToken(scm.GITHUB, repo)
+	require.NoError(t, err)
+	require.Equal(t, "old-token", token)
+
+	require.NoError(t, scm.WriteToken("api-token", scm.GITHUB, repo))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.JSONEq(
+		t,
+		`{"version":1,"tokens":{"github":{"AccessToken":"old-token","repositories":{"tech/api":{"AccessToken":"api-token"}}}},"hooks":{}}`,
+		string(data),
+	)
+}
+
+// should locate the languages file in the config dir
+func TestLanguagesPath(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(scm.CONFIG_DIR_ENV, dir)
+
+	path, err := scm.LanguagesPath()
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(dir, "languages.json"), path)
+}
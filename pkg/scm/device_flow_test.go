@@ -0,0 +1,245 @@
+package scm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/AntoninoAdornetto/issue-summoner/pkg/clock"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	epoch      = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	testDevice = requestDeviceVerificationResponse{
+		DeviceCode: "device-code",
+		ExpiresIn:  900,
+		Interval:   5,
+	}
+)
+
+type pollResult struct {
+	token createTokenResponse
+	err   error
+}
+
+// tokenSequence returns a fetch func that responds with errs, in order, and then
+// succeeds. The fake time of each request is recorded in calls.
+func tokenSequence(
+	clk clock.Clock,
+	calls *[]time.Duration,
+	errs ...error,
+) func(string) (createTokenResponse, error) {
+	return func(deviceCode string) (createTokenResponse, error) {
+		*calls = append(*calls, clk.Now().Sub(epoch))
+		if len(errs) > 0 {
+			err := errs[0]
+			errs = errs[1:]
+			return createTokenResponse{}, err
+		}
+		return createTokenResponse{AccessToken: "token"}, nil
+	}
+}
+
+func startPolling(
+	ctx context.Context,
+	device requestDeviceVerificationResponse,
+	opts DeviceFlowOptions,
+	fetch func(string) (createTokenResponse, error),
+) <-chan pollResult {
+	res := make(chan pollResult, 1)
+	go func() {
+		token, err := pollTokenService(ctx, device, opts, fetch)
+		res <- pollResult{token: token, err: err}
+	}()
+	return res
+}
+
+// advance waits for the poll and expiry timers to be armed before moving the clock
+func advance(clk *clock.Fake, d time.Duration) {
+	clk.BlockUntil(2)
+	clk.Advance(d)
+}
+
+// should keep polling, at the interval returned by the server, while authorization
+// is pending and return the access token
+func TestPollTokenServicePending(t *testing.T) {
+	clk := clock.NewFake(epoch)
+	calls := []time.Duration{}
+	fetch := tokenSequence(
+		clk,
+		&calls,
+		&createTokenError{Code: "authorization_pending"},
+		&createTokenError{Code: "authorization_pending"},
+	)
+
+	res := startPolling(context.Background(), testDevice, DeviceFlowOptions{Clock: clk}, fetch)
+	for i := 0; i < 3; i++ {
+		advance(clk, 5*time.Second)
+	}
+
+	result := <-res
+	require.NoError(t, result.err)
+	require.Equal(t, "token", result.token.AccessToken)
+	require.Equal(t, []time.Duration{5 * time.Second, 10 * time.Second, 15 * time.Second}, calls)
+}
+
+// should increase the polling interval by 5 seconds when the server responds with slow_down
+func TestPollTokenServiceSlowDown(t *testing.T) {
+	clk := clock.NewFake(epoch)
+	calls := []time.Duration{}
+	fetch := tokenSequence(clk, &calls, &createTokenError{Code: "slow_down"})
+
+	res := startPolling(context.Background(), testDevice, DeviceFlowOptions{Clock: clk}, fetch)
+	advance(clk, 5*time.Second)
+	advance(clk, 5*time.Second)
+	advance(clk, 5*time.Second)
+
+	result := <-res
+	require.NoError(t, result.err)
+	require.Equal(t, []time.Duration{5 * time.Second, 15 * time.Second}, calls)
+}
+
+// should give up with ErrDeviceCodeExpired once expires_in elapses
+func TestPollTokenServiceExpiresIn(t *testing.T) {
+	clk := clock.NewFake(epoch)
+	calls := []time.Duration{}
+	pending := []error{
+		&createTokenError{Code: "authorization_pending"},
+		&createTokenError{Code: "authorization_pending"},
+	}
+	fetch := tokenSequence(clk, &calls, pending...)
+	device := testDevice
+	device.ExpiresIn = 12
+
+	res := startPolling(context.Background(), device, DeviceFlowOptions{Clock: clk}, fetch)
+	advance(clk, 5*time.Second)
+	advance(clk, 5*time.Second)
+	advance(clk, 2*time.Second)
+
+	result := <-res
+	require.ErrorIs(t, result.err, ErrDeviceCodeExpired)
+	require.Len(t, calls, 2)
+}
+
+// should fall back to the default expiry when the server leaves out expires_in
+func TestPollTokenServiceMissingExpiresIn(t *testing.T) {
+	clk := clock.NewFake(epoch)
+	calls := []time.Duration{}
+	fetch := tokenSequence(clk, &calls, &createTokenError{Code: "authorization_pending"})
+	device := testDevice
+	device.ExpiresIn = 0
+
+	res := startPolling(context.Background(), device, DeviceFlowOptions{Clock: clk}, fetch)
+	advance(clk, 5*time.Second)
+	advance(clk, 5*time.Second)
+
+	result := <-res
+	require.NoError(t, result.err)
+	require.Equal(t, "token", result.token.AccessToken)
+	require.Equal(t, []time.Duration{5 * time.Second, 10 * time.Second}, calls)
+}
+
+// should prefer the timeout option over expires_in
+func TestPollTokenServiceTimeoutOption(t *testing.T) {
+	clk := clock.NewFake(epoch)
+	calls := []time.Duration{}
+	fetch := tokenSequence(clk, &calls)
+
+	opts := DeviceFlowOptions{Clock: clk, Timeout: 3 * time.Second}
+	res := startPolling(context.Background(), testDevice, opts, fetch)
+	advance(clk, 3*time.Second)
+
+	result := <-res
+	require.ErrorIs(t, result.err, ErrDeviceCodeExpired)
+	require.Empty(t, calls)
+}
+
+// should return ErrDeviceCodeExpired when the server reports the code has expired
+func TestPollTokenServiceExpiredToken(t *testing.T) {
+	clk := clock.NewFake(epoch)
+	calls := []time.Duration{}
+	fetch := tokenSequence(clk, &calls, &createTokenError{Code: "expired_token"})
+
+	res := startPolling(context.Background(), testDevice, DeviceFlowOptions{Clock: clk}, fetch)
+	advance(clk, 5*time.Second)
+
+	result := <-res
+	require.ErrorIs(t, result.err, ErrDeviceCodeExpired)
+	require.Len(t, calls, 1)
+}
+
+// should stop polling when the user denies access
+func TestPollTokenServiceAccessDenied(t *testing.T) {
+	clk := clock.NewFake(epoch)
+	calls := []time.Duration{}
+	denied := &createTokenError{Code: "access_denied", ErrorDesc: "user denied access"}
+	fetch := tokenSequence(clk, &calls, denied)
+
+	res := startPolling(context.Background(), testDevice, DeviceFlowOptions{Clock: clk}, fetch)
+	advance(clk, 5*time.Second)
+
+	result := <-res
+	require.ErrorContains(t, result.err, "user denied access")
+}
+
+// should stop polling immediately when the context is cancelled
+func TestPollTokenServiceCancel(t *testing.T) {
+	clk := clock.NewFake(epoch)
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := []time.Duration{}
+	fetch := tokenSequence(clk, &calls)
+
+	res := startPolling(ctx, testDevice, DeviceFlowOptions{Clock: clk}, fetch)
+	clk.BlockUntil(2)
+	cancel()
+
+	result := <-res
+	require.ErrorIs(t, result.err, context.Canceled)
+	require.Empty(t, calls)
+}
+
+// should show the user code after trying to open the verification url in the browser
+func TestPresentUserCode(t *testing.T) {
+	device := requestDeviceVerificationResponse{
+		UserCode:        "ABCD-1234",
+		VerificationUri: "https://github.com/login/device",
+	}
+
+	for _, openErr := range []error{nil, errors.New("no display")} {
+		visited := ""
+		shown := []string{}
+		opts := DeviceFlowOptions{
+			OpenBrowser: func(url string) error {
+				visited = url
+				return openErr
+			},
+			ShowUserCode: func(userCode, verificationURI string, opened bool) {
+				shown = append(shown, userCode, verificationURI, fmt.Sprint(opened))
+			},
+		}
+
+		presentUserCode(device, opts)
+		require.Equal(t, device.VerificationUri, visited)
+		require.Equal(
+			t,
+			[]string{device.UserCode, device.VerificationUri, fmt.Sprint(openErr == nil)},
+			shown,
+		)
+	}
+}
+
+// should not open a browser when an opener is not provided, I.E --no-browser
+func TestPresentUserCodeNoBrowser(t *testing.T) {
+	opened := true
+	opts := DeviceFlowOptions{
+		ShowUserCode: func(userCode, verificationURI string, o bool) {
+			opened = o
+		},
+	}
+
+	presentUserCode(requestDeviceVerificationResponse{UserCode: "ABCD-1234"}, opts)
+	require.False(t, opened)
+}
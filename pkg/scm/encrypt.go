@@ -0,0 +1,170 @@
+package scm
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"os"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	PASSPHRASE_ENV = "ISSUE_SUMMONER_PASSPHRASE"
+	scrypt_n       = 1 << 15
+	scrypt_r       = 8
+	scrypt_p       = 1
+	key_len        = 32
+	salt_len       = 16
+)
+
+var (
+	ErrWrongPassphrase    = errors.New("incorrect passphrase for the encrypted access token")
+	ErrPassphraseRequired = errors.New("access token is encrypted but no passphrase was provided")
+)
+
+// EncryptedToken is an access token that has been encrypted with AES-GCM. The key is
+// derived from a user supplied passphrase using scrypt. The salt and nonce are not secret
+// and are stored alongside the cipher text so the token can be decrypted later on.
+type EncryptedToken struct {
+	Salt       []byte
+	Nonce      []byte
+	CipherText []byte
+}
+
+// PassphraseProvider is called when an encrypted access token needs to be decrypted.
+// By default, the passphrase is read from the ISSUE_SUMMONER_PASSPHRASE environment
+// variable, which is useful for CI. Interactive programs can replace the provider with
+// a function that prompts the user.
+var PassphraseProvider = func() (string, error) {
+	if passphrase := os.Getenv(PASSPHRASE_ENV); passphrase != "" {
+		return passphrase, nil
+	}
+	return "", ErrPassphraseRequired
+}
+
+// EncryptAccessToken encrypts the plain text access tokens, for the given source code
+// management platform, that are stored in the config file. This includes the tokens of
+// individual repositories. This is for environments where a keyring is not available
+// and storing the token in plain text is not acceptable.
+func EncryptAccessToken(scm, passphrase string) error {
+	if passphrase == "" {
+		return ErrPassphraseRequired
+	}
+
+	config, err := ReadConfig()
+	if err != nil {
+		return err
+	}
+
+	tokenConfig, ok := config.Tokens[scm]
+	if !ok {
+		return fmt.Errorf("a plain text access token for %s does not exist", scm)
+	}
+
+	encrypted := 0
+	if tokenConfig.AccessToken != "" {
+		if tokenConfig.Encrypted, err = encryptToken(tokenConfig.AccessToken, passphrase); err != nil {
+			return err
+		}
+		tokenConfig.AccessToken = ""
+		encrypted++
+	}
+
+	for key, repoConfig := range tokenConfig.Repositories {
+		if repoConfig.AccessToken == "" {
+			continue
+		}
+
+		if repoConfig.Encrypted, err = encryptToken(repoConfig.AccessToken, passphrase); err != nil {
+			return err
+		}
+		repoConfig.AccessToken = ""
+		tokenConfig.Repositories[key] = repoConfig
+		encrypted++
+	}
+
+	if encrypted == 0 {
+		return fmt.Errorf("a plain text access token for %s does not exist", scm)
+	}
+
+	config.Tokens[scm] = tokenConfig
+	return WriteConfig(config)
+}
+
+// decryptAccessToken resolves the plain text access token for the config entry and
+// only asks for the passphrase when the token has been encrypted
+func decryptAccessToken(tokenConfig ScmTokenConfig) (string, error) {
+	if tokenConfig.Encrypted == nil {
+		return tokenConfig.AccessToken, nil
+	}
+
+	passphrase, err := PassphraseProvider()
+	if err != nil {
+		return "", err
+	}
+
+	if passphrase == "" {
+		return "", ErrPassphraseRequired
+	}
+
+	return decryptToken(tokenConfig.Encrypted, passphrase)
+}
+
+func encryptToken(token, passphrase string) (*EncryptedToken, error) {
+	salt := make([]byte, salt_len)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	gcm, err := newGCM(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return &EncryptedToken{
+		Salt:       salt,
+		Nonce:      nonce,
+		CipherText: gcm.Seal(nil, nonce, []byte(token), nil),
+	}, nil
+}
+
+func decryptToken(encrypted *EncryptedToken, passphrase string) (string, error) {
+	gcm, err := newGCM(passphrase, encrypted.Salt)
+	if err != nil {
+		return "", err
+	}
+
+	if len(encrypted.Nonce) != gcm.NonceSize() {
+		return "", errors.New("encrypted access token has an invalid nonce")
+	}
+
+	// GCM authentication fails when the key is derived from the wrong passphrase
+	token, err := gcm.Open(nil, encrypted.Nonce, encrypted.CipherText, nil)
+	if err != nil {
+		return "", ErrWrongPassphrase
+	}
+
+	return string(token), nil
+}
+
+func newGCM(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, scrypt_n, scrypt_r, scrypt_p, key_len)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
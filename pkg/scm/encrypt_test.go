@@ -0,0 +1,101 @@
+package scm_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/AntoninoAdornetto/issue-summoner/pkg/scm"
+	"github.com/stretchr/testify/require"
+)
+
+func setPassphrase(t *testing.T, passphrase string) {
+	original := scm.PassphraseProvider
+	scm.PassphraseProvider = func() (string, error) {
+		return passphrase, nil
+	}
+	t.Cleanup(func() {
+		scm.PassphraseProvider = original
+	})
+}
+
+// should encrypt the access token at rest and decrypt it with the correct passphrase
+func TestEncryptAccessToken(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(scm.CONFIG_DIR_ENV, dir)
+	require.NoError(t, scm.WriteToken("secret-token", scm.GITHUB, scm.RemoteRepository{}))
+	require.NoError(t, scm.EncryptAccessToken(scm.GITHUB, "correct horse"))
+
+	data, err := os.ReadFile(filepath.Join(dir, "config.json"))
+	require.NoError(t, err)
+	require.False(t, strings.Contains(string(data), "secret-token"))
+
+	setPassphrase(t, "correct horse")
+	token, err := scm.ReadAccessToken(scm.GITHUB, scm.RemoteRepository{})
+	require.NoError(t, err)
+	require.Equal(t, "secret-token", token)
+}
+
+// should return ErrWrongPassphrase when the passphrase does not decrypt the token
+func TestEncryptAccessTokenWrongPassphrase(t *testing.T) {
+	t.Setenv(scm.CONFIG_DIR_ENV, t.TempDir())
+	require.NoError(t, scm.WriteToken("secret-token", scm.GITHUB, scm.RemoteRepository{}))
+	require.NoError(t, scm.EncryptAccessToken(scm.GITHUB, "correct horse"))
+
+	setPassphrase(t, "battery staple")
+	token, err := scm.ReadAccessToken(scm.GITHUB, scm.RemoteRepository{})
+	require.Empty(t, token)
+	require.ErrorIs(t, err, scm.ErrWrongPassphrase)
+}
+
+// should read the passphrase from the environment by default
+func TestEncryptAccessTokenEnvPassphrase(t *testing.T) {
+	t.Setenv(scm.CONFIG_DIR_ENV, t.TempDir())
+	require.NoError(t, scm.WriteToken("secret-token", scm.GITHUB, scm.RemoteRepository{}))
+	require.NoError(t, scm.EncryptAccessToken(scm.GITHUB, "from-env"))
+
+	t.Setenv(scm.PASSPHRASE_ENV, "")
+	_, err := scm.ReadAccessToken(scm.GITHUB, scm.RemoteRepository{})
+	require.ErrorIs(t, err, scm.ErrPassphraseRequired)
+
+	t.Setenv(scm.PASSPHRASE_ENV, "from-env")
+	token, err := scm.ReadAccessToken(scm.GITHUB, scm.RemoteRepository{})
+	require.NoError(t, err)
+	require.Equal(t, "secret-token", token)
+}
+
+// should not prompt for a passphrase when the token is stored in plain text
+func TestReadAccessTokenPlainText(t *testing.T) {
+	t.Setenv(scm.CONFIG_DIR_ENV, t.TempDir())
+	require.NoError(t, scm.WriteToken("plain-token", scm.GITHUB, scm.RemoteRepository{}))
+
+	original := scm.PassphraseProvider
+	defer func() { scm.PassphraseProvider = original }()
+	scm.PassphraseProvider = func() (string, error) {
+		t.Fatal("passphrase should not be requested for a plain text token")
+		return "", nil
+	}
+
+	token, err := scm.ReadAccessToken(scm.GITHUB, scm.RemoteRepository{})
+	require.NoError(t, err)
+	require.Equal(t, "plain-token", token)
+}
+
+// should encrypt the tokens of repositories along with the token of the platform
+func TestEncryptAccessTokenRepositories(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(scm.CONFIG_DIR_ENV, dir)
+	repo := scm.RemoteRepository{Owner: "tech", Name: "api"}
+	require.NoError(t, scm.WriteToken("repo-secret", scm.GITHUB, repo))
+	require.NoError(t, scm.EncryptAccessToken(scm.GITHUB, "correct horse"))
+
+	data, err := os.ReadFile(filepath.Join(dir, "config.json"))
+	require.NoError(t, err)
+	require.False(t, strings.Contains(string(data), "repo-secret"))
+
+	setPassphrase(t, "correct horse")
+	token, err := scm.ReadAccessToken(scm.GITHUB, repo)
+	require.NoError(t, err)
+	require.Equal(t, "repo-secret", token)
+}
@@ -0,0 +1,73 @@
+package scm
+
+import "github.com/AntoninoAdornetto/issue-summoner/pkg/issue"
+
+// OpenIssues indexes the open issues of a repository so that annotations that have
+// already been reported are not reported twice. Issues that contain a marker are matched
+// by the fingerprint of the annotation. Issues without a marker, such as issues that were
+// created by hand, are matched by the similarity of their title.
+type OpenIssues struct {
+	fingerprints map[string]RemoteIssue
+	unmarked     []RemoteIssue
+	titles       []string
+	threshold    float64
+}
+
+// LoadOpenIssues lists the open issues of the repository. threshold is the minimum
+// similarity, see issue.TitleSimilarity, at which a title is considered a match. Titles
+// are not compared when threshold is 0.
+func LoadOpenIssues(gitManager GitConfigManager, threshold float64) (OpenIssues, error) {
+	issues, err := gitManager.ListIssues(ISSUE_STATE_OPEN)
+	if err != nil {
+		return OpenIssues{}, err
+	}
+	return NewOpenIssues(issues, threshold), nil
+}
+
+func NewOpenIssues(issues []RemoteIssue, threshold float64) OpenIssues {
+	open := OpenIssues{fingerprints: make(map[string]RemoteIssue), threshold: threshold}
+	for _, is := range issues {
+		if marker, ok := issue.ParseMarker(is.Body); ok {
+			open.fingerprints[marker.Fingerprint] = is
+			continue
+		}
+		open.unmarked = append(open.unmarked, is)
+		open.titles = append(open.titles, is.Title)
+	}
+	return open
+}
+
+// Find returns the open issue that the annotation, with the given fingerprint and title,
+// has already been reported in
+func (o OpenIssues) Find(fingerprint, title string) (RemoteIssue, bool) {
+	if is, ok := o.fingerprints[fingerprint]; ok {
+		return is, true
+	}
+
+	if o.threshold <= 0 {
+		return RemoteIssue{}, false
+	}
+
+	i, score := issue.MostSimilar(title, o.titles)
+	if i == -1 || score < o.threshold {
+		return RemoteIssue{}, false
+	}
+
+	return o.unmarked[i], true
+}
+
+// ResolvedIssues returns the issues, of the open issues, that were created by
+// issue-summoner and whose number is not referenced by an annotation anymore. The
+// annotation they were reported for has been removed from the source code, so they can
+// be closed. Issues without a marker were not created for an annotation and are never
+// returned.
+func ResolvedIssues(open []RemoteIssue, referenced map[int64]bool) []RemoteIssue {
+	resolved := make([]RemoteIssue, 0)
+	for _, is := range open {
+		if _, ok := issue.ParseMarker(is.Body); !ok || referenced[int64(is.Number)] {
+			continue
+		}
+		resolved = append(resolved, is)
+	}
+	return resolved
+}
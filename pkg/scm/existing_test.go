@@ -0,0 +1,65 @@
+package scm
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/AntoninoAdornetto/issue-summoner/pkg/issue"
+	"github.com/stretchr/testify/require"
+)
+
+// should match annotations against the open issues by fingerprint, or by title for
+// issues without a marker
+func TestLoadOpenIssues(t *testing.T) {
+	marked := issue.AppendMarker("body", issue.Marker{Fingerprint: "abc123", Version: "1"})
+	gh := newTestGitHub(t, func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "open", r.URL.Query().Get("state"))
+
+		page := make([]map[string]any, 0)
+		if r.URL.Query().Get("page") == "1" {
+			page = append(page,
+				map[string]any{"number": 1, "title": "Add retries to the client", "body": "by hand"},
+				map[string]any{"number": 2, "title": "reported by a scan", "body": marked},
+			)
+		}
+		require.NoError(t, json.NewEncoder(w).Encode(page))
+	})
+
+	open, err := LoadOpenIssues(gh, issue.DEFAULT_SIMILARITY)
+	require.NoError(t, err)
+
+	existing, ok := open.Find("abc123", "a different title")
+	require.True(t, ok)
+	require.Equal(t, 2, existing.Number)
+
+	existing, ok = open.Find("def456", "add retries to the client.")
+	require.True(t, ok)
+	require.Equal(t, 1, existing.Number)
+
+	_, ok = open.Find("def456", "reported by a scan")
+	require.False(t, ok, "issues with a marker are only matched by fingerprint")
+
+	_, ok = open.Find("def456", "remove the cache")
+	require.False(t, ok)
+}
+
+// should only match by fingerprint when the threshold is 0
+func TestOpenIssuesNoThreshold(t *testing.T) {
+	open := NewOpenIssues([]RemoteIssue{{Number: 1, Title: "add retries"}}, 0)
+	_, ok := open.Find("def456", "add retries")
+	require.False(t, ok)
+}
+
+// should return the marked issues that are no longer referenced by an annotation
+func TestResolvedIssues(t *testing.T) {
+	marked := issue.AppendMarker("body", issue.Marker{Fingerprint: "abc123", Version: "1"})
+	open := []RemoteIssue{
+		{Number: 1, Title: "referenced", Body: marked},
+		{Number: 2, Title: "removed", Body: marked},
+		{Number: 3, Title: "created by hand", Body: "by hand"},
+	}
+
+	resolved := ResolvedIssues(open, map[int64]bool{1: true})
+	require.Equal(t, []RemoteIssue{open[1]}, resolved)
+}
@@ -0,0 +1,51 @@
+package scm
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// ChangedFiles returns the files of the repository located at dir that changed since ref,
+// a branch, tag or commit, relative to dir. The changes of the working tree that have not
+// been committed are included. Files that were deleted and files that git doesn't track yet
+// are not.
+func ChangedFiles(runner GitRunner, dir, ref string) ([]string, error) {
+	if _, err := runner.Run(dir, "rev-parse", "--verify", "--quiet", ref+"^{commit}"); err != nil {
+		return nil, fmt.Errorf("invalid ref %q, expected a branch, tag or commit: %w", ref, err)
+	}
+
+	out, err := runner.Run(dir, "diff", "--name-only", "-z", "--relative", "--diff-filter=d", ref)
+	if err != nil {
+		return nil, err
+	}
+
+	return splitNames(out), nil
+}
+
+// TrackedFiles returns the files beneath dir that git tracks, relative to dir. Files that
+// are ignored or not added to the repository yet are not included.
+func TrackedFiles(runner GitRunner, dir string) ([]string, error) {
+	out, err := runner.Run(dir, "rev-parse", "--is-inside-work-tree")
+	if err != nil || strings.TrimSpace(string(out)) != "true" {
+		return nil, fmt.Errorf("%s is not inside of a git work tree", dir)
+	}
+
+	out, err = runner.Run(dir, "ls-files", "-z")
+	if err != nil {
+		return nil, err
+	}
+
+	return splitNames(out), nil
+}
+
+// splitNames splits the NUL separated file names of a git command that was run with -z
+func splitNames(out []byte) []string {
+	files := make([]string, 0)
+	for _, name := range bytes.Split(out, []byte{0}) {
+		if len(name) > 0 {
+			files = append(files, string(name))
+		}
+	}
+	return files
+}
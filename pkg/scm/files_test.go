@@ -0,0 +1,55 @@
+package scm_test
+
+import (
+	"testing"
+
+	"github.com/AntoninoAdornetto/issue-summoner/pkg/scm"
+	"github.com/stretchr/testify/require"
+)
+
+// should resolve the ref and list the files that changed since it
+func TestChangedFiles(t *testing.T) {
+	runner := &scm.FakeGitRunner{Outputs: map[string]string{
+		"rev-parse --verify --quiet main^{commit}":            "1f0c2a\n",
+		"diff --name-only -z --relative --diff-filter=d main": "cmd/scan.go\x00pkg/issue/with space.go\x00",
+	}}
+
+	files, err := scm.ChangedFiles(runner, "/repo", "main")
+	require.NoError(t, err)
+	require.Equal(t, []string{"cmd/scan.go", "pkg/issue/with space.go"}, files)
+	require.Len(t, runner.Calls, 2)
+	require.Equal(t, "/repo", runner.Calls[1].Dir)
+}
+
+// should return an error that names the ref when it can't be resolved
+func TestChangedFilesInvalidRef(t *testing.T) {
+	runner := &scm.FakeGitRunner{}
+	_, err := scm.ChangedFiles(runner, "/repo", "nope")
+	require.ErrorContains(t, err, `invalid ref "nope"`)
+	require.Len(t, runner.Calls, 1)
+}
+
+// should list the files that git tracks
+func TestTrackedFiles(t *testing.T) {
+	runner := &scm.FakeGitRunner{Outputs: map[string]string{
+		"rev-parse --is-inside-work-tree": "true\n",
+		"ls-files -z":                     "main.go\x00cmd/scan.go\x00",
+	}}
+
+	files, err := scm.TrackedFiles(runner, "/repo")
+	require.NoError(t, err)
+	require.Equal(t, []string{"main.go", "cmd/scan.go"}, files)
+}
+
+// should return a clear error when the directory is not inside of a git work tree
+func TestTrackedFilesOutsideWorkTree(t *testing.T) {
+	_, err := scm.TrackedFiles(&scm.FakeGitRunner{}, "/tmp/project")
+	require.ErrorContains(t, err, "/tmp/project is not inside of a git work tree")
+
+	runner := &scm.FakeGitRunner{Outputs: map[string]string{
+		"rev-parse --is-inside-work-tree": "false\n",
+	}}
+	_, err = scm.TrackedFiles(runner, "/repo/.git")
+	require.Error(t, err)
+	require.Len(t, runner.Calls, 1)
+}
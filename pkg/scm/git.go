@@ -2,138 +2,216 @@ package scm
 
 import (
 	"bytes"
-	"encoding/json"
+	"context"
 	"errors"
 	"fmt"
-	"os"
-	"os/user"
-	"path/filepath"
+	"strings"
+	"unicode/utf8"
 )
 
 const (
 	GITHUB    = "github"
 	GITLAB    = "gitlab"
 	BITBUCKET = "bitbucket"
+	GITEA     = "gitea"
 )
 
+const (
+	MAX_TITLE_LENGTH       = 256
+	GITEA_MAX_TITLE_LENGTH = 255
+	title_ellipsis         = "…"
+)
+
+var (
+	ErrEmptyTitle   = errors.New("issue title can not be empty")
+	ErrTitleTooLong = fmt.Errorf("issue title can not be longer than %d characters", MAX_TITLE_LENGTH)
+)
+
+// GitIssue is the payload that is sent to a source code management platform when an
+// issue is created. Optional fields are omitted when they are empty, so platforms that
+// don't support a field never receive it. Metadata and QueueIndex are never serialized,
+// they are available to adapters and to the report command.
 type GitIssue struct {
-	Title      string `json:"title"`
-	Body       string `json:"body"`
-	QueueIndex int
+	Title      string            `json:"title"`
+	Body       string            `json:"body,omitempty"`
+	Labels     []string          `json:"labels,omitempty"`
+	Assignees  []string          `json:"assignees,omitempty"`
+	Milestone  int               `json:"milestone,omitempty"`
+	Metadata   map[string]string `json:"-"`
+	QueueIndex int               `json:"-"`
+}
+
+// Validate rejects issues that would be refused by the platform, so that the user gets
+// a clear error before an api call is made
+func (is GitIssue) Validate() error {
+	title := strings.TrimSpace(is.Title)
+	if title == "" {
+		return ErrEmptyTitle
+	}
+
+	if n := utf8.RuneCountInString(title); n > MAX_TITLE_LENGTH {
+		return fmt.Errorf("%w, got %d: %s...", ErrTitleTooLong, n, string([]rune(title)[:64]))
+	}
+
+	return nil
+}
+
+// Normalize prepares the issue for a platform that accepts titles of up to maxLength
+// characters. The title is trimmed and every run of whitespace, including newlines, is
+// collapsed into a single space. A title that is still too long is truncated with an
+// ellipsis and the rest of it is moved to the start of the body, so that nothing is lost.
+// ErrEmptyTitle is returned when nothing is left of the title.
+func (is GitIssue) Normalize(maxLength int) (GitIssue, error) {
+	title := strings.Join(strings.Fields(is.Title), " ")
+	if title == "" {
+		return is, ErrEmptyTitle
+	}
+
+	runes := []rune(title)
+	if len(runes) > maxLength {
+		cut := maxLength - utf8.RuneCountInString(title_ellipsis)
+		title = strings.TrimSpace(string(runes[:cut])) + title_ellipsis
+
+		body := title_ellipsis + strings.TrimSpace(string(runes[cut:]))
+		if is.Body != "" {
+			body += "\n\n" + is.Body
+		}
+		is.Body = body
+	}
+
+	is.Title = title
+	return is, nil
+}
+
+// RemoteRepository identifies a repository, on a source code management platform, by
+// the name of its owner and its own name
+type RemoteRepository struct {
+	Owner string
+	Name  string
+}
+
+func (r RemoteRepository) String() string {
+	return r.Owner + "/" + r.Name
+}
+
+const (
+	ISSUE_STATE_OPEN   = "open"
+	ISSUE_STATE_CLOSED = "closed"
+	ISSUE_STATE_ALL    = "all"
+)
+
+// RemoteIssue is an issue, that already exists, on a source code management platform
+type RemoteIssue struct {
+	Number int
+	Title  string
+	Body   string
+	State  string
+	URL    string
 }
 
 // GitConfigManager provides flexibility to have different implementations
-// of Authorize and Report for each source code management platform supported
+// of Authorize, Report and CloseIssue for each source code management platform supported
 type GitConfigManager interface {
-	Authorize() error
+	Authorize(ctx context.Context, opts DeviceFlowOptions) error
+	VerifyRepository() (RemoteRepository, error)
+	ListIssues(state string) ([]RemoteIssue, error)
+	ReportedIssues() (map[string]RemoteIssue, error)
 	Report(issues []GitIssue) <-chan Reporter
+	CloseIssue(number int64) error
 }
 
 // @TODO add other source code management structs to NewGitManager once their implementations are created
-func NewGitManager(scm, userName, repoName string) (GitConfigManager, error) {
-	switch scm {
+func NewGitManager(config GitConfig) (GitConfigManager, error) {
+	switch config.Scm {
 	case GITHUB:
-		return &GitHubManager{repoName: repoName, userName: userName}, nil
+		baseURL := config.BaseURL
+		if baseURL == "" {
+			baseURL = GITHUB_BASE_URL
+		}
+
+		return &GitHubManager{
+			repoName: config.RepositoryName,
+			userName: config.UserName,
+			baseURL:  baseURL,
+			token:    config.Token,
+			limiter:  newLimiter(config.RateLimit),
+		}, nil
+	case GITEA:
+		baseURL := config.BaseURL
+		if baseURL == "" {
+			baseURL = GITEA_BASE_URL
+		}
+
+		return &GiteaManager{
+			repoName: config.RepositoryName,
+			userName: config.UserName,
+			baseURL:  baseURL,
+			token:    config.Token,
+			limiter:  newLimiter(config.RateLimit),
+		}, nil
 	default:
 		return nil, fmt.Errorf(
-			"expected to receive scm with value of %s, %s, or %s but got %s",
+			"expected to receive scm with value of %s, %s, %s, or %s but got %s",
 			GITHUB,
 			GITLAB,
 			BITBUCKET,
-			scm,
+			GITEA,
+			config.Scm,
 		)
 	}
 }
 
-type ScmTokenConfig struct {
-	AccessToken string
-}
-
-type IssueSummonerConfig = map[string]ScmTokenConfig
-
-// WriteToken accepts an access token and the source code management platform
-// (GitHub, GitLab etc...) and will write the token to a configuration file.
-// This will be used to authorize future requests for reporting issues.
-func WriteToken(token string, scm string) error {
-	config := make(map[string]ScmTokenConfig)
-	path, err := getConfigDirPath()
-	if err != nil {
-		return err
-	}
-
-	err = os.MkdirAll(path, 0755)
-	if err != nil {
-		return err
-	}
-
-	configFilePath, err := getConfigFilePath()
-	if err != nil {
-		return err
-	}
-
-	file, err := os.OpenFile(configFilePath, os.O_RDWR|os.O_CREATE, 0666)
+// ExtractUserRepoName takes the output from <git remote --verbose> command
+// as input and attempts to extract the user name and repository name from out
+func ExtractUserRepoName(out []byte) (string, string, error) {
+	url, err := remoteURL(out)
 	if err != nil {
-		return err
+		return "", "", err
 	}
 
-	defer file.Close()
-
-	// @TODO add remaining source code management platforms once other adapters are implemented
-	switch scm {
-	default:
-		config[GITHUB] = ScmTokenConfig{
-			AccessToken: token,
-		}
-	}
-
-	data, err := json.Marshal(config)
-	if err != nil {
-		return err
+	if bytes.HasPrefix(url, []byte("https")) {
+		userName, repoName := extractFromHTTPS(url)
+		return userName, repoName, nil
 	}
 
-	if _, err := file.Write(data); err != nil {
-		return err
+	if bytes.HasPrefix(url, []byte("git")) {
+		userName, repoName := extractFromSSH(url)
+		return userName, repoName, nil
 	}
 
-	return nil
+	return "", "", fmt.Errorf("expected a https or ssh url but got %s", string(url))
 }
 
-func ReadAccessToken(scm string) (string, error) {
-	config := make(map[string]ScmTokenConfig)
-	path, err := getConfigFilePath()
+// ExtractHost takes the output from <git remote --verbose> command as input and
+// returns the host name of the remote url, such as github.com
+func ExtractHost(out []byte) (string, error) {
+	url, err := remoteURL(out)
 	if err != nil {
 		return "", err
 	}
 
-	file, err := os.OpenFile(path, os.O_RDONLY, 0666)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return "", err
-		} else {
-			return "", errors.New("Error opening file")
-		}
-	}
-
-	defer file.Close()
-
-	decoder := json.NewDecoder(file)
-	if err := decoder.Decode(&config); err != nil {
-		return "", errors.New("Error decoding config file")
+	var host []byte
+	switch {
+	case bytes.HasPrefix(url, []byte("https")):
+		host = bytes.SplitN(bytes.SplitAfter(url, []byte("https://"))[1], []byte("/"), 2)[0]
+	case bytes.HasPrefix(url, []byte("git")):
+		host = bytes.SplitN(url, []byte(":"), 2)[0]
+	default:
+		return "", fmt.Errorf("expected a https or ssh url but got %s", string(url))
 	}
 
-	accessToken := config[scm].AccessToken
-	if accessToken == "" {
-		return "", errors.New("Access token does not exist")
+	// drop the credentials of https urls or the user of ssh urls, user@host
+	if i := bytes.LastIndexByte(host, '@'); i >= 0 {
+		host = host[i+1:]
 	}
 
-	return accessToken, nil
+	return string(host), nil
 }
 
-// ExtractUserRepoName takes the output from <git remote --verbose> command
-// as input and attempts to extract the user name and repository name from out
-func ExtractUserRepoName(out []byte) (string, string, error) {
-	if len(out) == 0 {
-		return "", "", errors.New(
+// remoteURL returns the url of the first remote in the output of <git remote --verbose>
+func remoteURL(out []byte) ([]byte, error) {
+	if len(bytes.TrimSpace(out)) == 0 {
+		return nil, errors.New(
 			"expected to receive the output from <git remote -v> but got empty byte slice",
 		)
 	}
@@ -143,24 +221,13 @@ func ExtractUserRepoName(out []byte) (string, string, error) {
 	// we only care about the url since it contains both the username and repo name
 	fields := bytes.Fields(line)
 	if len(fields) < 2 {
-		return "", "", fmt.Errorf(
+		return nil, fmt.Errorf(
 			"expected to receive the origin and url but got %s",
 			string(fields[0]),
 		)
 	}
 
-	url := fields[1]
-	if bytes.HasPrefix(url, []byte("https")) {
-		userName, repoName := extractFromHTTPS(url)
-		return userName, repoName, nil
-	}
-
-	if bytes.HasPrefix(url, []byte("git")) {
-		userName, repoName := extractFromSSH(url)
-		return userName, repoName, nil
-	}
-
-	return "", "", fmt.Errorf("expected a https or ssh url but got %s", string(url))
+	return fields[1], nil
 }
 
 func extractFromHTTPS(url []byte) (string, string) {
@@ -176,21 +243,3 @@ func extractFromSSH(url []byte) (string, string) {
 	userName, repoName := sep[0], sep[1]
 	return string(userName), string(bytes.TrimSuffix(repoName, []byte(".git")))
 }
-
-func getConfigDirPath() (string, error) {
-	usr, err := user.Current()
-	if err != nil {
-		return "", err
-	}
-
-	homeDir := usr.HomeDir
-	return filepath.Join(homeDir, ".config", "issue-summoner"), nil
-}
-
-func getConfigFilePath() (string, error) {
-	configDir, err := getConfigDirPath()
-	if err != nil {
-		return "", err
-	}
-	return filepath.Join(configDir, "config.json"), nil
-}
@@ -1,7 +1,11 @@
 package scm_test
 
 import (
+	"encoding/json"
+	"os"
+	"strings"
 	"testing"
+	"unicode/utf8"
 
 	"github.com/AntoninoAdornetto/issue-summoner/pkg/scm"
 	"github.com/stretchr/testify/require"
@@ -17,14 +21,22 @@ const (
 
 // should create a new GitHubManager struct
 func TestNewGitManagerGitHub(t *testing.T) {
-	gm, err := scm.NewGitManager(scm.GITHUB, "AntoninoAdornetto", "issue-summoner")
+	gm, err := scm.NewGitManager(scm.GitConfig{
+		Scm:            scm.GITHUB,
+		UserName:       "AntoninoAdornetto",
+		RepositoryName: "issue-summoner",
+	})
 	require.NoError(t, err)
 	require.IsType(t, &scm.GitHubManager{}, gm)
 }
 
 // should return an error when provided an unsupported source code management platform
 func TestNewGitManagerUnsupported(t *testing.T) {
-	gm, err := scm.NewGitManager("unsupported", "AntoninoAdornetto", "issue-summoner")
+	gm, err := scm.NewGitManager(scm.GitConfig{
+		Scm:            "unsupported",
+		UserName:       "AntoninoAdornetto",
+		RepositoryName: "issue-summoner",
+	})
 	require.Error(t, err)
 	require.Empty(t, gm)
 }
@@ -67,3 +79,65 @@ func TestExtractUserRepoNameUnknownProtocol(t *testing.T) {
 	require.Empty(t, repoName)
 	require.Error(t, err)
 }
+
+// should serialize the optional fields only when they are set
+func TestGitIssueJSON(t *testing.T) {
+	cases := map[string]scm.GitIssue{
+		"testdata/git_issue_minimal.json": {
+			Title:      "add retries to the github client",
+			QueueIndex: 4,
+		},
+		"testdata/git_issue_full.json": {
+			Title:      "add retries to the github client",
+			Body:       "requests fail on flaky networks",
+			Labels:     []string{"tech-debt", "networking"},
+			Assignees:  []string{"AntoninoAdornetto"},
+			Milestone:  3,
+			Metadata:   map[string]string{"fingerprint": "abc123"},
+			QueueIndex: 1,
+		},
+	}
+
+	for golden, is := range cases {
+		expected, err := os.ReadFile(golden)
+		require.NoError(t, err)
+
+		actual, err := json.Marshal(is)
+		require.NoError(t, err)
+		require.JSONEq(t, string(expected), string(actual), golden)
+	}
+}
+
+// should reject empty titles and titles that are longer than GitHub allows
+func TestGitIssueValidate(t *testing.T) {
+	require.NoError(t, scm.GitIssue{Title: "valid"}.Validate())
+	require.NoError(t, scm.GitIssue{Title: strings.Repeat("é", scm.MAX_TITLE_LENGTH)}.Validate())
+	require.ErrorIs(t, scm.GitIssue{Title: " \t"}.Validate(), scm.ErrEmptyTitle)
+
+	err := scm.GitIssue{Title: strings.Repeat("a", scm.MAX_TITLE_LENGTH+1)}.Validate()
+	require.ErrorIs(t, err, scm.ErrTitleTooLong)
+}
+
+// should collapse whitespace, move the overflow of long titles to the body and reject
+// titles that are empty
+func TestGitIssueNormalize(t *testing.T) {
+	is, err := scm.GitIssue{Title: "  fix the\r\n   retry\tloop \n", Body: "body"}.Normalize(scm.MAX_TITLE_LENGTH)
+	require.NoError(t, err)
+	require.Equal(t, scm.GitIssue{Title: "fix the retry loop", Body: "body"}, is)
+
+	for _, title := range []string{"", " \t", "\n\r\n"} {
+		_, err := scm.GitIssue{Title: title}.Normalize(scm.MAX_TITLE_LENGTH)
+		require.ErrorIs(t, err, scm.ErrEmptyTitle, title)
+	}
+
+	is, err = scm.GitIssue{Title: "the title is too long", Body: "body"}.Normalize(12)
+	require.NoError(t, err)
+	require.Equal(t, "the title i…", is.Title)
+	require.Equal(t, "…s too long\n\nbody", is.Body)
+
+	is, err = scm.GitIssue{Title: strings.Repeat("é", scm.MAX_TITLE_LENGTH+10)}.Normalize(scm.MAX_TITLE_LENGTH)
+	require.NoError(t, err)
+	require.NoError(t, is.Validate())
+	require.Equal(t, scm.MAX_TITLE_LENGTH, utf8.RuneCountInString(is.Title))
+	require.Equal(t, "…"+strings.Repeat("é", 11), is.Body)
+}
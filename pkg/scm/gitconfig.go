@@ -0,0 +1,124 @@
+package scm
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+var (
+	ErrNoRemote        = errors.New("the repository does not have a remote")
+	ErrUnknownPlatform = errors.New("unable to detect the source code management platform")
+)
+
+// GitConfig is everything that is needed to report issues for a local repository: the
+// owner and name of the remote repository, the platform it's hosted on, and the access
+// token for that platform. BaseURL is the api url of a GitHub Enterprise Server installation
+// or the url of a Gitea instance, the default url of the platform is used when it's empty.
+// RateLimit controls how fast issues are created, the zero value uses the defaults.
+type GitConfig struct {
+	UserName       string
+	RepositoryName string
+	Token          string
+	Scm            string
+	BaseURL        string
+	RateLimit      RateLimit
+}
+
+// LoadGitConfig assembles the GitConfig of the repository located at path. <git remote -v>
+// is executed with Git in path, rather than the working directory of the process, and the
+// platform is detected from the host of the remote url. The returned error describes
+// which step failed.
+func LoadGitConfig(path string) (GitConfig, error) {
+	repo, err := FindRepository(path)
+	if err != nil {
+		return GitConfig{}, fmt.Errorf("failed to find a git repository in %s: %w", path, err)
+	}
+
+	out, err := Git.Run(repo.WorkTree, "remote", "-v")
+	if err != nil {
+		return GitConfig{}, fmt.Errorf("failed to read the remotes of %s: %w", repo.WorkTree, err)
+	}
+
+	if len(bytes.TrimSpace(out)) == 0 {
+		return GitConfig{}, fmt.Errorf("%w: %s. add one with <git remote add>", ErrNoRemote, repo.WorkTree)
+	}
+
+	userName, repoName, err := ExtractUserRepoName(out)
+	if err != nil {
+		return GitConfig{}, fmt.Errorf("failed to parse the remote url of %s: %w", repo.WorkTree, err)
+	}
+
+	host, err := ExtractHost(out)
+	if err != nil {
+		return GitConfig{}, fmt.Errorf("failed to parse the remote url of %s: %w", repo.WorkTree, err)
+	}
+
+	config, err := ReadConfig()
+	if err != nil && !errors.Is(err, ErrConfigNotFound) {
+		return GitConfig{}, fmt.Errorf("failed to read the config file: %w", err)
+	}
+
+	scm, err := PlatformFromHost(host)
+	switch {
+	case err == nil:
+	case isSelfHosted(config.BaseURL, host):
+		scm = GITHUB
+	case isSelfHosted(config.GiteaURL, host):
+		scm = GITEA
+	default:
+		return GitConfig{}, err
+	}
+
+	token, err := ReadAccessToken(scm, RemoteRepository{Owner: userName, Name: repoName})
+	if err != nil {
+		return GitConfig{}, fmt.Errorf(
+			"failed to read the access token for %s, please run <issue-summoner authorize>: %w",
+			scm,
+			err,
+		)
+	}
+
+	return GitConfig{
+		UserName:       userName,
+		RepositoryName: repoName,
+		Token:          token,
+		Scm:            scm,
+		BaseURL:        config.PlatformURL(scm),
+	}, nil
+}
+
+// WebURL returns the url of the web ui of the platform, such as https://github.com. An
+// empty string is returned for platforms that are not supported yet.
+func (c GitConfig) WebURL() string {
+	switch c.Scm {
+	case GITHUB:
+		return githubWebURL(c.BaseURL)
+	default:
+		return ""
+	}
+}
+
+// isSelfHosted reports whether the remote host is the self-hosted installation, such as
+// GitHub Enterprise Server or a Gitea instance, that baseURL points to
+func isSelfHosted(baseURL, host string) bool {
+	if baseURL == "" {
+		return false
+	}
+
+	u, err := url.Parse(baseURL)
+	return err == nil && strings.EqualFold(u.Hostname(), host)
+}
+
+// PlatformFromHost returns the source code management platform that hosts the remote
+func PlatformFromHost(host string) (string, error) {
+	host = strings.ToLower(host)
+	for _, scm := range []string{GITHUB, GITLAB, BITBUCKET, GITEA} {
+		if host == scm+".com" || host == scm+".org" || strings.HasSuffix(host, "."+scm+".com") {
+			return scm, nil
+		}
+	}
+	return "", fmt.Errorf("%w from the host %s", ErrUnknownPlatform, host)
+}
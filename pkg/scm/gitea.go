@@ -0,0 +1,446 @@
+package scm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/AntoninoAdornetto/issue-summoner/pkg/issue"
+)
+
+const (
+	GITEA_BASE_URL       = "https://gitea.com"
+	GITEA_TOKEN_SCOPES   = "write:issue, read:repository, read:user"
+	gitea_api_path       = "api/v1"
+	gitea_labels_limit   = 50
+	err_gitea_token      = "failed to verify the gitea access token with status code: %d\terror: %s"
+	err_gitea_label      = "label %s does not exist in %s/%s"
+	err_gitea_list_label = "failed to list labels with status code: %d\terror: %s"
+)
+
+var ErrTokenPromptRequired = errors.New("an access token prompt is required to authorize gitea")
+
+// GiteaManager reports issues to a repository of a Gitea instance. baseURL is the url of
+// the instance, such as https://gitea.example.com, the rest api is served from
+// <baseURL>/api/v1. token is read from the config file, on the first request, when it's
+// not provided by the GitConfig. limiter paces the requests that create issues.
+type GiteaManager struct {
+	repoName  string
+	userName  string
+	baseURL   string
+	token     string
+	tokenOnce sync.Once
+	tokenErr  error
+	limiter   *limiter
+}
+
+// apiURL joins paths to the url of the rest api
+func (gt *GiteaManager) apiURL(paths ...string) (string, error) {
+	return url.JoinPath(gt.baseURL, append([]string{gitea_api_path}, paths...)...)
+}
+
+// newAPIRequest creates a request, for Giteas rest api, that is authorized with the
+// access token of the repository
+func (gt *GiteaManager) newAPIRequest(method, uri string, body io.Reader) (*http.Request, error) {
+	gt.tokenOnce.Do(func() {
+		if gt.token == "" {
+			repo := RemoteRepository{Owner: gt.userName, Name: gt.repoName}
+			gt.token, gt.tokenErr = ReadAccessToken(GITEA, repo)
+		}
+	})
+
+	if gt.tokenErr != nil {
+		return nil, gt.tokenErr
+	}
+
+	req, err := http.NewRequest(method, uri, body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("Accept", ACCEPT_JSON)
+	req.Header.Add("Content-Type", ACCEPT_JSON)
+	req.Header.Add("Authorization", fmt.Sprintf("token %s", gt.token))
+
+	return req, nil
+}
+
+// do sends the request and returns the body of the response along with its status code
+func (gt *GiteaManager) do(req *http.Request) ([]byte, int, error) {
+	client := http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	return data, resp.StatusCode, err
+}
+
+// Authorize satisfies the GitConfigManager interface. Gitea does not support the device
+// flow that is used for GitHub, so the user creates an access token in the settings of
+// their account and enters it with opts.ReadToken. The token is verified against the
+// user api before it's written to the config file.
+func (gt *GiteaManager) Authorize(ctx context.Context, opts DeviceFlowOptions) error {
+	if opts.ReadToken == nil {
+		return ErrTokenPromptRequired
+	}
+
+	tokenURL, err := url.JoinPath(gt.baseURL, "user", "settings", "applications")
+	if err != nil {
+		return err
+	}
+
+	if opts.OpenBrowser != nil {
+		_ = opts.OpenBrowser(tokenURL)
+	}
+
+	token, err := opts.ReadToken(tokenURL)
+	if err != nil {
+		return err
+	}
+
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return errors.New("the access token can not be empty")
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	gt.token = token
+	gt.tokenOnce.Do(func() {})
+	uri, err := gt.apiURL("user")
+	if err != nil {
+		return err
+	}
+
+	req, err := gt.newAPIRequest("GET", uri, nil)
+	if err != nil {
+		return err
+	}
+
+	data, status, err := gt.do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+
+	if status != http.StatusOK {
+		return fmt.Errorf(err_gitea_token, status, errorMessage(data))
+	}
+
+	return WriteToken(token, GITEA, RemoteRepository{Owner: gt.userName, Name: gt.repoName})
+}
+
+// VerifyRepository makes a GET request to the repos api to make sure that the repository
+// exists, and that the access token can see it, before any issues are created. The owner
+// and name in the response are used for the rest of the run, in case they differ in case.
+func (gt *GiteaManager) VerifyRepository() (RemoteRepository, error) {
+	repo := RemoteRepository{Owner: gt.userName, Name: gt.repoName}
+	uri, err := gt.apiURL("repos", gt.userName, gt.repoName)
+	if err != nil {
+		return repo, err
+	}
+
+	req, err := gt.newAPIRequest("GET", uri, nil)
+	if err != nil {
+		return repo, err
+	}
+
+	data, status, err := gt.do(req)
+	if err != nil {
+		return repo, err
+	}
+
+	switch status {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return repo, fmt.Errorf(err_repo_not_found, gt.userName, gt.repoName)
+	default:
+		return repo, fmt.Errorf(err_verify_repo, gt.userName, gt.repoName, status, errorMessage(data))
+	}
+
+	res := repositoryResponse{}
+	if err := json.Unmarshal(data, &res); err != nil {
+		return repo, err
+	}
+
+	if res.Owner.Login != "" && res.Name != "" {
+		repo = RemoteRepository{Owner: res.Owner.Login, Name: res.Name}
+		gt.userName, gt.repoName = repo.Owner, repo.Name
+	}
+
+	return repo, nil
+}
+
+// ListIssues returns every issue in the repository with the given state, which is one
+// of ISSUE_STATE_OPEN, ISSUE_STATE_CLOSED or ISSUE_STATE_ALL. Pull requests are excluded
+// by the type query parameter.
+func (gt *GiteaManager) ListIssues(state string) ([]RemoteIssue, error) {
+	issues := make([]RemoteIssue, 0)
+
+	for page := 1; ; page++ {
+		uri, err := gt.apiURL("repos", gt.userName, gt.repoName, "issues")
+		if err != nil {
+			return nil, err
+		}
+
+		uri = fmt.Sprintf("%s?state=%s&type=issues&limit=%d&page=%d", uri, state, issues_per_page, page)
+		req, err := gt.newAPIRequest("GET", uri, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		data, status, err := gt.do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		if status != http.StatusOK {
+			return nil, fmt.Errorf(err_list_issues, status, errorMessage(data))
+		}
+
+		res := make([]listIssueResponse, 0)
+		if err := json.Unmarshal(data, &res); err != nil {
+			return nil, err
+		}
+
+		for _, is := range res {
+			issues = append(issues, RemoteIssue{
+				Number: is.Number,
+				Title:  is.Title,
+				Body:   is.Body,
+				State:  is.State,
+				URL:    is.HTMLURL,
+			})
+		}
+
+		// instances cap the page size with their MAX_RESPONSE_ITEMS setting, so paging
+		// stops at the first empty page rather than at the first short page
+		if len(res) == 0 {
+			return issues, nil
+		}
+	}
+}
+
+// CloseIssue closes the issue numbered number with a PATCH request to the edit issue
+// endpoint, Gitea refers to the number as the index of the issue. Closing an issue that
+// is already closed succeeds.
+func (gt *GiteaManager) CloseIssue(number int64) error {
+	uri, err := gt.apiURL("repos", gt.userName, gt.repoName, "issues", strconv.FormatInt(number, 10))
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(closeIssueRequest{State: ISSUE_STATE_CLOSED})
+	if err != nil {
+		return err
+	}
+
+	data, status, err := gt.limiter.do(func() (*http.Request, error) {
+		return gt.newAPIRequest("PATCH", uri, bytes.NewReader(payload))
+	})
+	if err != nil {
+		return err
+	}
+
+	// Gitea responds with 201 Created to an edit
+	switch status {
+	case http.StatusCreated, http.StatusOK:
+		return nil
+	case http.StatusNotFound:
+		return fmt.Errorf(err_issue_missing, number, gt.userName, gt.repoName)
+	default:
+		return fmt.Errorf(err_close_issue, number, status, errorMessage(data))
+	}
+}
+
+// ReportedIssues returns the open and closed issues that were created by issue-summoner,
+// keyed by the fingerprint in their marker. Issues without a marker are left out.
+func (gt *GiteaManager) ReportedIssues() (map[string]RemoteIssue, error) {
+	issues, err := gt.ListIssues(ISSUE_STATE_ALL)
+	if err != nil {
+		return nil, err
+	}
+
+	reported := make(map[string]RemoteIssue)
+	for _, is := range issues {
+		if marker, ok := issue.ParseMarker(is.Body); ok {
+			reported[marker.Fingerprint] = is
+		}
+	}
+
+	return reported, nil
+}
+
+// giteaIssue is the payload of the create issue api. Unlike GitHub, Gitea expects the
+// ids of the labels rather than their names.
+type giteaIssue struct {
+	Title     string   `json:"title"`
+	Body      string   `json:"body,omitempty"`
+	Labels    []int64  `json:"labels,omitempty"`
+	Assignees []string `json:"assignees,omitempty"`
+	Milestone int      `json:"milestone,omitempty"`
+}
+
+// Report creates the issues concurrently. The names of the labels are resolved to their
+// ids once, before any issue is created, and every issue fails when a label does not
+// exist in the repository.
+func (gt *GiteaManager) Report(issues []GitIssue) <-chan Reporter {
+	res := make(chan Reporter)
+	labels, labelsErr := gt.labelIDs(issues)
+
+	wg := sync.WaitGroup{}
+	wg.Add(len(issues))
+
+	for _, is := range issues {
+		go func(is GitIssue) {
+			defer wg.Done()
+			if labelsErr != nil {
+				res <- Reporter{QueueIndex: is.QueueIndex, Err: labelsErr}
+				return
+			}
+
+			resp, err := gt.createIssue(is, labels)
+			if err != nil {
+				res <- Reporter{QueueIndex: is.QueueIndex, Err: err}
+				return
+			}
+			res <- Reporter{
+				ID:         resp.ID,
+				Number:     resp.Number,
+				URL:        resp.HTMLURL,
+				QueueIndex: is.QueueIndex,
+			}
+		}(is)
+	}
+
+	go func() {
+		wg.Wait()
+		close(res)
+	}()
+
+	return res
+}
+
+func (gt *GiteaManager) createIssue(is GitIssue, labels map[string]int64) (createIssueResponse, error) {
+	var res createIssueResponse
+
+	is, err := is.Normalize(GITEA_MAX_TITLE_LENGTH)
+	if err != nil {
+		return res, err
+	}
+
+	payload := giteaIssue{
+		Title:     is.Title,
+		Body:      is.Body,
+		Assignees: is.Assignees,
+		Milestone: is.Milestone,
+	}
+
+	for _, name := range is.Labels {
+		payload.Labels = append(payload.Labels, labels[name])
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return res, err
+	}
+
+	uri, err := gt.apiURL("repos", gt.userName, gt.repoName, "issues")
+	if err != nil {
+		return res, err
+	}
+
+	data, status, err := gt.limiter.do(func() (*http.Request, error) {
+		return gt.newAPIRequest("POST", uri, bytes.NewReader(data))
+	})
+	if err != nil {
+		return res, err
+	}
+
+	if status != http.StatusCreated {
+		return res, handleCreateIssueErr(data, status, is.Title)
+	}
+
+	return res, json.Unmarshal(data, &res)
+}
+
+type giteaLabel struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+// labelIDs maps the names of the labels that are used by issues to their ids
+func (gt *GiteaManager) labelIDs(issues []GitIssue) (map[string]int64, error) {
+	ids := make(map[string]int64)
+	needed := false
+	for _, is := range issues {
+		needed = needed || len(is.Labels) > 0
+	}
+
+	if !needed {
+		return ids, nil
+	}
+
+	for page := 1; ; page++ {
+		uri, err := gt.apiURL("repos", gt.userName, gt.repoName, "labels")
+		if err != nil {
+			return nil, err
+		}
+
+		req, err := gt.newAPIRequest("GET", fmt.Sprintf("%s?limit=%d&page=%d", uri, gitea_labels_limit, page), nil)
+		if err != nil {
+			return nil, err
+		}
+
+		data, status, err := gt.do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		if status != http.StatusOK {
+			return nil, fmt.Errorf(err_gitea_list_label, status, errorMessage(data))
+		}
+
+		labels := make([]giteaLabel, 0)
+		if err := json.Unmarshal(data, &labels); err != nil {
+			return nil, err
+		}
+
+		for _, label := range labels {
+			ids[label.Name] = label.ID
+		}
+
+		if len(labels) == 0 {
+			break
+		}
+	}
+
+	for _, is := range issues {
+		for _, name := range is.Labels {
+			if _, ok := ids[name]; !ok {
+				return nil, fmt.Errorf(err_gitea_label, name, gt.userName, gt.repoName)
+			}
+		}
+	}
+
+	return ids, nil
+}
+
+// errorMessage returns the message of an api error response
+func errorMessage(data []byte) string {
+	res := createIssueErrorResponse{}
+	_ = json.Unmarshal(data, &res)
+	return res.Message
+}
@@ -0,0 +1,193 @@
+package scm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newTestGitea starts a server for the rest api of a Gitea instance and returns a manager
+// that sends its requests to the server. The access token is read from a temporary config
+// file.
+func newTestGitea(t *testing.T, handler http.HandlerFunc) *GiteaManager {
+	t.Setenv(CONFIG_DIR_ENV, t.TempDir())
+	require.NoError(t, WriteToken("gitea-token", GITEA, RemoteRepository{}))
+
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	gitManager, err := NewGitManager(GitConfig{
+		Scm:            GITEA,
+		UserName:       "tech",
+		RepositoryName: "debt",
+		BaseURL:        srv.URL,
+	})
+	require.NoError(t, err)
+	return gitManager.(*GiteaManager)
+}
+
+// should read the gitea token from the config file and send it with the token scheme
+func TestGiteaReadToken(t *testing.T) {
+	gt := newTestGitea(t, func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "GET", r.Method)
+		require.Equal(t, "/api/v1/repos/tech/debt", r.URL.Path)
+		require.Equal(t, "token gitea-token", r.Header.Get("Authorization"))
+		w.Write([]byte(`{"name":"debt","owner":{"login":"tech"}}`))
+	})
+
+	config, err := ReadConfig()
+	require.NoError(t, err)
+	require.Empty(t, config.Tokens[GITHUB].AccessToken)
+
+	repo, err := gt.VerifyRepository()
+	require.NoError(t, err)
+	require.Equal(t, RemoteRepository{Owner: "tech", Name: "debt"}, repo)
+}
+
+// should resolve the names of the labels to their ids and create the issue
+func TestGiteaReport(t *testing.T) {
+	gt := newTestGitea(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/repos/tech/debt/labels":
+			if r.URL.Query().Get("page") == "1" {
+				w.Write([]byte(`[{"id":4,"name":"networking"},{"id":9,"name":"tech-debt"}]`))
+				return
+			}
+			w.Write([]byte(`[]`))
+		case "/api/v1/repos/tech/debt/issues":
+			require.Equal(t, "POST", r.Method)
+			require.Equal(t, "token gitea-token", r.Header.Get("Authorization"))
+
+			body := map[string]any{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			require.Equal(t, map[string]any{
+				"title":     "add retries",
+				"body":      "requests fail on flaky networks",
+				"labels":    []any{float64(9), float64(4)},
+				"assignees": []any{"octocat"},
+			}, body)
+
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id":7,"number":3,"html_url":"https://gitea.example.com/tech/debt/issues/3"}`))
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+	})
+
+	results := make([]Reporter, 0)
+	for r := range gt.Report([]GitIssue{{
+		Title:      "add retries",
+		Body:       "requests fail on flaky networks",
+		Labels:     []string{"tech-debt", "networking"},
+		Assignees:  []string{"octocat"},
+		QueueIndex: 1,
+	}}) {
+		results = append(results, r)
+	}
+
+	require.Equal(t, []Reporter{{
+		ID:         7,
+		Number:     3,
+		URL:        "https://gitea.example.com/tech/debt/issues/3",
+		QueueIndex: 1,
+	}}, results)
+}
+
+// should not create any issues when a label does not exist
+func TestGiteaReportUnknownLabel(t *testing.T) {
+	gt := newTestGitea(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/repos/tech/debt/labels" {
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+		w.Write([]byte(`[]`))
+	})
+
+	_, err := gt.labelIDs([]GitIssue{{Title: "add retries", Labels: []string{"tech-debt"}}})
+	require.ErrorContains(t, err, "label tech-debt does not exist in tech/debt")
+
+	for r := range gt.Report([]GitIssue{{Title: "add retries", Labels: []string{"tech-debt"}}}) {
+		require.ErrorContains(t, r.Err, "label tech-debt does not exist in tech/debt")
+		require.Zero(t, r.Number)
+	}
+}
+
+// should close the issue with a PATCH request to the edit issue endpoint
+func TestGiteaCloseIssue(t *testing.T) {
+	gt := newTestGitea(t, func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "PATCH", r.Method)
+		require.Equal(t, "/api/v1/repos/tech/debt/issues/3", r.URL.Path)
+		require.Equal(t, "token gitea-token", r.Header.Get("Authorization"))
+
+		body := map[string]any{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		require.Equal(t, map[string]any{"state": "closed"}, body)
+
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"number":3,"state":"closed"}`))
+	})
+
+	require.NoError(t, gt.CloseIssue(3))
+}
+
+// should include the api error message when the issue can't be closed
+func TestGiteaCloseIssueErrors(t *testing.T) {
+	gt := newTestGitea(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/repos/tech/debt/issues/3":
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"message":"user does not have permission"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"issue does not exist"}`))
+		}
+	})
+
+	err := gt.CloseIssue(3)
+	require.ErrorContains(t, err, "failed to close issue #3 with status code: 403")
+	require.ErrorContains(t, err, "user does not have permission")
+
+	err = gt.CloseIssue(4)
+	require.ErrorContains(t, err, "issue #4 does not exist in tech/debt")
+}
+
+// should verify the token that was entered and write it to the config file
+func TestGiteaAuthorize(t *testing.T) {
+	gt := newTestGitea(t, func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/api/v1/user", r.URL.Path)
+		require.Equal(t, "token new-token", r.Header.Get("Authorization"))
+		w.Write([]byte(`{"login":"tech"}`))
+	})
+
+	tokenURL := ""
+	err := gt.Authorize(context.Background(), DeviceFlowOptions{
+		ReadToken: func(url string) (string, error) {
+			tokenURL = url
+			return " new-token\n", nil
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, gt.baseURL+"/user/settings/applications", tokenURL)
+
+	token, err := ReadAccessToken(GITEA, RemoteRepository{Owner: "tech", Name: "debt"})
+	require.NoError(t, err)
+	require.Equal(t, "new-token", token)
+
+	require.ErrorIs(t, gt.Authorize(context.Background(), DeviceFlowOptions{}), ErrTokenPromptRequired)
+}
+
+// should default to gitea.com and detect gitea remotes
+func TestGiteaPlatform(t *testing.T) {
+	gitManager, err := NewGitManager(GitConfig{Scm: GITEA})
+	require.NoError(t, err)
+	require.Equal(t, GITEA_BASE_URL, gitManager.(*GiteaManager).baseURL)
+
+	platform, err := PlatformFromHost("gitea.com")
+	require.NoError(t, err)
+	require.Equal(t, GITEA, platform)
+
+	require.Equal(t, "https://gitea.example.com", Config{GiteaURL: "https://gitea.example.com"}.PlatformURL(GITEA))
+}
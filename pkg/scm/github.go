@@ -2,15 +2,20 @@ package scm
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/AntoninoAdornetto/issue-summoner/pkg/clock"
+	"github.com/AntoninoAdornetto/issue-summoner/pkg/issue"
 	"github.com/AntoninoAdornetto/issue-summoner/pkg/utils"
 )
 
@@ -26,16 +31,44 @@ const (
 	GITHUB_API_VERSION = "2022-11-28"
 	err_create_issue   = "failed to create issue <%s> with status code: %d\terror: %s"
 	err_not_found      = "failed to create issue <%s> with status code: %d\terror: unable to find repo. please check your remote url via <git remote -v>"
+	err_repo_not_found = "repository %s/%s does not exist or your access token does not have access to it"
+	err_verify_repo    = "failed to verify repository %s/%s with status code: %d\terror: %s"
+	err_list_issues    = "failed to list issues with status code: %d\terror: %s"
+	err_close_issue    = "failed to close issue #%d with status code: %d\terror: %s"
+	err_issue_missing  = "issue #%d does not exist in %s/%s or your access token does not have access to it"
 )
 
+const (
+	default_poll_interval = 5   // seconds
+	default_expires_in    = 900 // seconds, used when the server leaves out expires_in
+	slow_down_increment   = 5 * time.Second
+)
+
+var ErrDeviceCodeExpired = errors.New(
+	"the user code has expired, please run 'issue-summoner authorize' again",
+)
+
+// GitHubManager reports issues to a GitHub repository. token is read from the config
+// file, on the first request, when it's not provided by the GitConfig. limiter paces the
+// requests that create issues.
 type GitHubManager struct {
-	repoName string
-	userName string
+	repoName  string
+	userName  string
+	baseURL   string
+	token     string
+	tokenOnce sync.Once
+	tokenErr  error
+	limiter   *limiter
 }
 
+// Reporter is sent by Report for every issue, once it's created or has failed. QueueIndex
+// is the QueueIndex of the GitIssue and Err is the error of an issue that wasn't created.
 type Reporter struct {
 	ID         int64
+	Number     int
+	URL        string
 	QueueIndex int
+	Err        error
 }
 
 func (gh *GitHubManager) Report(issues []GitIssue) <-chan Reporter {
@@ -48,10 +81,15 @@ func (gh *GitHubManager) Report(issues []GitIssue) <-chan Reporter {
 			defer wg.Done()
 			resp, err := gh.createIssue(is)
 			if err != nil {
-				fmt.Println(err.Error())
+				res <- Reporter{QueueIndex: is.QueueIndex, Err: err}
 				return
 			}
-			rc := Reporter{ID: resp.ID, QueueIndex: is.QueueIndex}
+			rc := Reporter{
+				ID:         resp.ID,
+				Number:     resp.Number,
+				URL:        resp.HTMLURL,
+				QueueIndex: is.QueueIndex,
+			}
 			res <- rc
 		}(issue)
 	}
@@ -80,31 +118,25 @@ type createIssueResponse struct {
 func (gh *GitHubManager) createIssue(issue GitIssue) (createIssueResponse, error) {
 	var res createIssueResponse
 
-	payload, err := json.Marshal(issue)
-	if err != nil {
-		return res, err
-	}
-
-	body := bytes.NewBuffer(payload)
-	req, err := gh.newIssueRequest(body)
+	issue, err := issue.Normalize(MAX_TITLE_LENGTH)
 	if err != nil {
 		return res, err
 	}
 
-	client := http.Client{}
-	resp, err := client.Do(req)
+	payload, err := json.Marshal(issue)
 	if err != nil {
 		return res, err
 	}
-	defer resp.Body.Close()
 
-	data, err := io.ReadAll(resp.Body)
+	data, status, err := gh.limiter.do(func() (*http.Request, error) {
+		return gh.newIssueRequest(bytes.NewReader(payload))
+	})
 	if err != nil {
 		return res, err
 	}
 
-	if resp.StatusCode != 201 {
-		return res, handleCreateIssueErr(data, resp.StatusCode, issue.Title)
+	if status != http.StatusCreated {
+		return res, handleCreateIssueErr(data, status, issue.Title)
 	}
 
 	err = json.Unmarshal(data, &res)
@@ -133,32 +165,228 @@ func handleCreateIssueErr(data []byte, statusCode int, title string) error {
 	return fmt.Errorf(err_create_issue, title, statusCode, res.Message)
 }
 
-var accessToken = ""
-
 func (gh *GitHubManager) newIssueRequest(body io.Reader) (*http.Request, error) {
-	if accessToken == "" {
-		token, err := ReadAccessToken(GITHUB)
+	uri, err := url.JoinPath(gh.baseURL, "repos", gh.userName, gh.repoName, "issues")
+	if err != nil {
+		return nil, err
+	}
+
+	return gh.newAPIRequest("POST", uri, body)
+}
+
+// newAPIRequest creates a request, for GitHubs rest api, that is authorized with the
+// access token of the repository
+func (gh *GitHubManager) newAPIRequest(method, uri string, body io.Reader) (*http.Request, error) {
+	gh.tokenOnce.Do(func() {
+		if gh.token == "" {
+			repo := RemoteRepository{Owner: gh.userName, Name: gh.repoName}
+			gh.token, gh.tokenErr = ReadAccessToken(GITHUB, repo)
+		}
+	})
+
+	if gh.tokenErr != nil {
+		return nil, gh.tokenErr
+	}
+
+	req, err := http.NewRequest(method, uri, body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("Accept", ACCEPT_VDN)
+	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", gh.token))
+	req.Header.Add("X-GitHub-Api-Version", GITHUB_API_VERSION)
+
+	return req, nil
+}
+
+type repositoryResponse struct {
+	Name  string `json:"name"`
+	Owner struct {
+		Login string `json:"login"`
+	} `json:"owner"`
+}
+
+// VerifyRepository makes a GET request to the repos api to make sure that the repository
+// exists, and that the access token can see it, before any issues are created. GitHub
+// responds with a 301 redirect when a repository has been renamed or transferred. The
+// redirect is followed and the manager is updated to use the new owner and name, which
+// are returned so the caller can let the user know.
+func (gh *GitHubManager) VerifyRepository() (RemoteRepository, error) {
+	repo := RemoteRepository{Owner: gh.userName, Name: gh.repoName}
+	uri, err := url.JoinPath(gh.baseURL, "repos", gh.userName, gh.repoName)
+	if err != nil {
+		return repo, err
+	}
+
+	req, err := gh.newAPIRequest("GET", uri, nil)
+	if err != nil {
+		return repo, err
+	}
+
+	client := http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return repo, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return repo, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return repo, fmt.Errorf(err_repo_not_found, gh.userName, gh.repoName)
+	default:
+		errRes := createIssueErrorResponse{}
+		_ = json.Unmarshal(data, &errRes)
+		return repo, fmt.Errorf(
+			err_verify_repo,
+			gh.userName,
+			gh.repoName,
+			resp.StatusCode,
+			errRes.Message,
+		)
+	}
+
+	res := repositoryResponse{}
+	if err := json.Unmarshal(data, &res); err != nil {
+		return repo, err
+	}
+
+	if res.Owner.Login != "" && res.Name != "" {
+		repo = RemoteRepository{Owner: res.Owner.Login, Name: res.Name}
+		gh.userName, gh.repoName = repo.Owner, repo.Name
+	}
+
+	return repo, nil
+}
+
+const issues_per_page = 100
+
+type listIssueResponse struct {
+	Number      int             `json:"number"`
+	Title       string          `json:"title"`
+	Body        string          `json:"body"`
+	State       string          `json:"state"`
+	HTMLURL     string          `json:"html_url"`
+	PullRequest json.RawMessage `json:"pull_request"`
+}
+
+// ListIssues returns every issue in the repository with the given state, which is one
+// of ISSUE_STATE_OPEN, ISSUE_STATE_CLOSED or ISSUE_STATE_ALL. The list endpoint is
+// paginated and also returns pull requests, which are excluded.
+func (gh *GitHubManager) ListIssues(state string) ([]RemoteIssue, error) {
+	issues := make([]RemoteIssue, 0)
+	client := http.Client{}
+
+	for page := 1; ; page++ {
+		uri, err := url.JoinPath(gh.baseURL, "repos", gh.userName, gh.repoName, "issues")
 		if err != nil {
 			return nil, err
 		}
-		accessToken = token
+
+		uri = fmt.Sprintf("%s?state=%s&per_page=%d&page=%d", uri, state, issues_per_page, page)
+		req, err := gh.newAPIRequest("GET", uri, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			errRes := createIssueErrorResponse{}
+			_ = json.Unmarshal(data, &errRes)
+			return nil, fmt.Errorf(err_list_issues, resp.StatusCode, errRes.Message)
+		}
+
+		res := make([]listIssueResponse, 0)
+		if err := json.Unmarshal(data, &res); err != nil {
+			return nil, err
+		}
+
+		for _, is := range res {
+			if len(is.PullRequest) == 0 {
+				issues = append(issues, RemoteIssue{
+					Number: is.Number,
+					Title:  is.Title,
+					Body:   is.Body,
+					State:  is.State,
+					URL:    is.HTMLURL,
+				})
+			}
+		}
+
+		if len(res) < issues_per_page {
+			return issues, nil
+		}
 	}
+}
 
-	uri, err := url.JoinPath(GITHUB_BASE_URL, "repos", gh.userName, gh.repoName, "issues")
+// closeIssueRequest is the payload of the update issue endpoint that closes an issue
+type closeIssueRequest struct {
+	State string `json:"state"`
+}
+
+// CloseIssue closes the issue numbered number with a PATCH request to the update issue
+// endpoint. The request is paced by the limiter, like the requests that create issues.
+// Closing an issue that is already closed succeeds.
+func (gh *GitHubManager) CloseIssue(number int64) error {
+	uri, err := url.JoinPath(gh.baseURL, "repos", gh.userName, gh.repoName, "issues", strconv.FormatInt(number, 10))
 	if err != nil {
-		return nil, err
+		return err
+	}
+
+	payload, err := json.Marshal(closeIssueRequest{State: ISSUE_STATE_CLOSED})
+	if err != nil {
+		return err
 	}
 
-	req, err := http.NewRequest("POST", uri, body)
+	data, status, err := gh.limiter.do(func() (*http.Request, error) {
+		return gh.newAPIRequest("PATCH", uri, bytes.NewReader(payload))
+	})
+	if err != nil {
+		return err
+	}
+
+	switch status {
+	case http.StatusOK:
+		return nil
+	case http.StatusNotFound, http.StatusGone:
+		return fmt.Errorf(err_issue_missing, number, gh.userName, gh.repoName)
+	default:
+		return fmt.Errorf(err_close_issue, number, status, errorMessage(data))
+	}
+}
+
+// ReportedIssues returns the open and closed issues that were created by issue-summoner,
+// keyed by the fingerprint in their marker. Issues without a marker are left out.
+func (gh *GitHubManager) ReportedIssues() (map[string]RemoteIssue, error) {
+	issues, err := gh.ListIssues(ISSUE_STATE_ALL)
 	if err != nil {
 		return nil, err
 	}
 
-	req.Header.Add("Accept", ACCEPT_VDN)
-	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", accessToken))
-	req.Header.Add("X-GitHub-Api-Version", GITHUB_API_VERSION)
+	reported := make(map[string]RemoteIssue)
+	for _, is := range issues {
+		if marker, ok := issue.ParseMarker(is.Body); ok {
+			reported[marker.Fingerprint] = is
+		}
+	}
 
-	return req, nil
+	return reported, nil
 }
 
 // Authorize satisfies the GitManager interface. Each source code management
@@ -168,97 +396,134 @@ func (gh *GitHubManager) newIssueRequest(body io.Reader) (*http.Request, error)
 // First, a user code is created and a browser opens to GitHubs verification url.
 // While the program is waiting for the user to enter the code, we poll an endpoint
 // and check if the user has authorized the app. Once they have done so, an access token
-// is returned from the service and is then written to ~/.config/issue-summoner/config.json
-func (gh *GitHubManager) Authorize() error {
-	var once sync.Once
-	deviceChan := make(chan requestDeviceVerificationResponse)
-	tokenChan := make(chan createTokenResponse)
-	errChan := make(chan error)
-
-	go initDeviceFlow(deviceChan, errChan)
-	device := <-deviceChan
-	go pollTokenService(tokenChan, device, errChan, &once)
-
-	select {
-	case token := <-tokenChan:
-		return WriteToken(token.AccessToken, GITHUB)
-	case err := <-errChan:
+// is returned from the service and is then written to the issue-summoner config file.
+// The token is stored for the repository of the manager when its owner and name are set.
+// Polling stops as soon as ctx is cancelled.
+func (gh *GitHubManager) Authorize(ctx context.Context, opts DeviceFlowOptions) error {
+	webURL := githubWebURL(gh.baseURL)
+	device, err := requestDeviceVerification(webURL)
+	if err != nil {
 		return err
 	}
-}
 
-/*
-@TODO user code is not printed when opening browser in same workspace as issue summoner process
-When executing <issue-summoner authorize>, without my default browser being open already, the
-user code is never printed to the terminal for me to copy and paste into githubs device authorization
-page. However, if I have my default browser open in a workspace where the issue summoner process is not running,
-I can see the user code is printed to the terminal just fine. I have a feeling this may be a simple fix where
-I just need to adjust the way I am utilizing go routines. Will investigate further.
-
-Environment Notes:
-This needs to be tested on other environments and most importantly, different tiling window managers.
-I am reporting this issue from Arch, btw, while using hyprland as my window manager.
-
-Expected Behavior: executing <issue-summoner authorize> should open the default browser and print the usercode
-to the terminal regardless if the browser is open already or if the browser opens in the same workspace as the
-issue-summoner process.
-
-Actual Behavior: executing <issue-summoner authorize> opens the default browser but fails to print the usercode
-to the terminal.
-*/
-func initDeviceFlow(vd chan requestDeviceVerificationResponse, ec chan error) {
-	resp, err := requestDeviceVerification()
+	presentUserCode(device, opts)
+	token, err := pollTokenService(ctx, device, opts, func(deviceCode string) (createTokenResponse, error) {
+		return createToken(webURL, deviceCode)
+	})
 	if err != nil {
-		ec <- err
-		return
+		return err
 	}
 
-	fmt.Printf(
-		"User Code: %s - Please visit %s if you have any isues",
-		resp.UserCode,
-		resp.VerificationUri,
+	return WriteToken(
+		token.AccessToken,
+		GITHUB,
+		RemoteRepository{Owner: gh.userName, Name: gh.repoName},
 	)
+}
 
-	err = utils.OpenBrowser(resp.VerificationUri)
-	if err != nil {
-		fmt.Printf(
-			"failed to open default browser. Please visit %s and enter your User Code",
-			resp.VerificationUri,
-		)
+// DeviceFlowOptions controls how the user code is presented and overrides the polling
+// values that are returned by the authorization server. The zero value prints the user
+// code, does not open a browser, and honors the interval and expires_in fields of the
+// server response.
+type DeviceFlowOptions struct {
+	// Interval is the initial wait between access token requests
+	Interval time.Duration
+	// Timeout is how long we poll before giving up on the user code
+	Timeout time.Duration
+	// Clock defaults to the wall clock when nil
+	Clock clock.Clock
+	// OpenBrowser opens the verification url, see utils.OpenBrowser
+	OpenBrowser func(url string) error
+	// ShowUserCode displays the user code. opened reports if the verification url was
+	// opened in a browser. The code is printed to stdout when nil.
+	ShowUserCode func(userCode, verificationURI string, opened bool)
+	// ReadToken prompts for an access token that the user created at tokenURL. It's used
+	// by platforms that don't support the device flow, such as Gitea.
+	ReadToken func(tokenURL string) (string, error)
+}
+
+// presentUserCode opens the verification url, when a browser opener is provided, and
+// then displays the user code. The code is always displayed since the user has to
+// enter it on the verification page.
+func presentUserCode(device requestDeviceVerificationResponse, opts DeviceFlowOptions) {
+	opened := false
+	if opts.OpenBrowser != nil {
+		opened = opts.OpenBrowser(device.VerificationUri) == nil
 	}
-	vd <- resp
+
+	if opts.ShowUserCode != nil {
+		opts.ShowUserCode(device.UserCode, device.VerificationUri, opened)
+		return
+	}
+
+	fmt.Printf(
+		"User Code: %s\nPlease visit %s and enter your User Code\n",
+		device.UserCode,
+		device.VerificationUri,
+	)
 }
 
 // pollTokenService will make an http POST request to check if the user has successfully
 // authorized the app by entering the user_code into the browser. The function will not
 // poll the endpoint at a higher frequency than the frequency indicated by **interval**
 // in the **requestDeviceVerificationResponse** struct. GitHub will respond with a 200 status code and
-// an error response
+// an error response while authorization is pending. When the error is slow_down, the interval
+// is increased by 5 seconds, as required by the device flow spec.
 func pollTokenService(
-	tc chan createTokenResponse,
+	ctx context.Context,
 	device requestDeviceVerificationResponse,
-	ec chan error,
-	once *sync.Once,
-) {
-	expireTime := time.Now().Add(time.Duration(device.ExpiresIn) * time.Second)
-	ticker := time.NewTicker(time.Duration(device.Interval+1) * time.Second)
+	opts DeviceFlowOptions,
+	fetchToken func(deviceCode string) (createTokenResponse, error),
+) (createTokenResponse, error) {
+	interval := opts.Interval
+	if interval <= 0 {
+		interval = time.Duration(max(device.Interval, default_poll_interval)) * time.Second
+	}
 
-	defer ticker.Stop()
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		expiresIn := device.ExpiresIn
+		if expiresIn <= 0 {
+			expiresIn = default_expires_in
+		}
+		timeout = time.Duration(expiresIn) * time.Second
+	}
+
+	clk := clock.OrReal(opts.Clock)
+	expired := clk.NewTimer(timeout)
+	defer expired.Stop()
+
+	timer := clk.NewTimer(interval)
+	defer timer.Stop()
 
 	for {
-		if time.Now().After(expireTime) {
-			once.Do(func() {
-				ec <- errors.New("User Code has expired, please re-run the 'issue-summoner report' command to generate a new user code")
-				close(ec)
-			})
-			break
+		select {
+		case <-ctx.Done():
+			return createTokenResponse{}, ctx.Err()
+		case <-expired.C():
+			return createTokenResponse{}, ErrDeviceCodeExpired
+		case <-timer.C():
 		}
 
-		<-ticker.C
-		resp, err := createToken(device.DeviceCode)
+		resp, err := fetchToken(device.DeviceCode)
 		if err == nil {
-			tc <- resp
+			return resp, nil
 		}
+
+		tokenErr := &createTokenError{}
+		if errors.As(err, &tokenErr) {
+			switch tokenErr.Code {
+			case "authorization_pending":
+			case "slow_down":
+				interval += slow_down_increment
+			case "expired_token":
+				return createTokenResponse{}, ErrDeviceCodeExpired
+			default:
+				return createTokenResponse{}, err
+			}
+		}
+
+		timer.Reset(interval)
 	}
 }
 
@@ -268,7 +533,7 @@ type createTokenResponse struct {
 	Scope       string `json:"scope"`      // "repo, gist, ..."
 }
 
-func createToken(deviceCode string) (createTokenResponse, error) {
+func createToken(webURL, deviceCode string) (createTokenResponse, error) {
 	var res createTokenResponse
 	paths := []string{"login", "oauth", "access_token"}
 	params := map[string]string{
@@ -277,7 +542,7 @@ func createToken(deviceCode string) (createTokenResponse, error) {
 		"grant_type":  GRANT_TYPE,
 	}
 
-	url, err := utils.BuildURL(BASE_URL, paths, params)
+	url, err := utils.BuildURL(webURL, paths, params)
 	if err != nil {
 		return res, err
 	}
@@ -291,8 +556,8 @@ func createToken(deviceCode string) (createTokenResponse, error) {
 	}
 
 	tokenErr := handleCreateTokenErr(resp)
-	if tokenErr.Error != "" {
-		return res, errors.New(tokenErr.ErrorDesc)
+	if tokenErr.Code != "" {
+		return res, tokenErr
 	}
 
 	err = json.Unmarshal(resp, &res)
@@ -303,19 +568,36 @@ func createToken(deviceCode string) (createTokenResponse, error) {
 	return res, nil
 }
 
+// githubWebURL returns the url that the device flow is served from. GitHub Enterprise
+// Server serves its api from <host>/api/v3, the device flow is served from <host>.
+func githubWebURL(baseURL string) string {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	if baseURL == "" || baseURL == GITHUB_BASE_URL {
+		return BASE_URL
+	}
+	return strings.TrimSuffix(baseURL, "/api/v3")
+}
+
 type createTokenError struct {
-	Error     string `json:"error"`
+	Code      string `json:"error"`
 	ErrorDesc string `json:"error_description"`
 }
 
-func handleCreateTokenErr(data []byte) createTokenError {
+func (e *createTokenError) Error() string {
+	if e.ErrorDesc == "" {
+		return e.Code
+	}
+	return e.ErrorDesc
+}
+
+func handleCreateTokenErr(data []byte) *createTokenError {
 	var tokenErr createTokenError
 	err := json.Unmarshal(data, &tokenErr)
 	if err != nil {
-		tokenErr.Error = err.Error()
-		return tokenErr
+		tokenErr.Code = err.Error()
+		return &tokenErr
 	}
-	return tokenErr
+	return &tokenErr
 }
 
 type requestDeviceVerificationResponse struct {
@@ -330,12 +612,12 @@ type requestDeviceVerificationResponse struct {
 // code service. It returns a struct containing information that is needed
 // to create an access token. This is step 1 of the device flow.
 // See -> https://docs.github.com/en/apps/oauth-apps/building-oauth-apps/authorizing-oauth-apps#device-flow
-func requestDeviceVerification() (requestDeviceVerificationResponse, error) {
+func requestDeviceVerification(webURL string) (requestDeviceVerificationResponse, error) {
 	var res requestDeviceVerificationResponse
 	paths := []string{"login", "device", "code"}
 	params := map[string]string{"client_id": CLIENT_ID, "scope": SCOPES}
 
-	url, err := utils.BuildURL(BASE_URL, paths, params)
+	url, err := utils.BuildURL(webURL, paths, params)
 	if err != nil {
 		return res, err
 	}
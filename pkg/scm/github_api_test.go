@@ -0,0 +1,314 @@
+package scm
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newTestGitHub starts a server for the rest api and returns a manager that sends its
+// requests to the server. The access token is read from a temporary config file.
+func newTestGitHub(t *testing.T, handler http.HandlerFunc) *GitHubManager {
+	t.Setenv(CONFIG_DIR_ENV, t.TempDir())
+	require.NoError(t, WriteToken("test-token", GITHUB, RemoteRepository{}))
+
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return &GitHubManager{userName: "tech", repoName: "debt", baseURL: srv.URL}
+}
+
+// should verify that the repository exists with an authorized GET request
+func TestVerifyRepository(t *testing.T) {
+	gh := newTestGitHub(t, func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "GET", r.Method)
+		require.Equal(t, "/repos/tech/debt", r.URL.Path)
+		require.Equal(t, "Bearer test-token", r.Header.Get("Authorization"))
+		w.Write([]byte(`{"name":"debt","owner":{"login":"tech"}}`))
+	})
+
+	repo, err := gh.VerifyRepository()
+	require.NoError(t, err)
+	require.Equal(t, RemoteRepository{Owner: "tech", Name: "debt"}, repo)
+}
+
+// should follow the redirect of a renamed repository and use the new owner and name
+// for the rest of the run
+func TestVerifyRepositoryRenamed(t *testing.T) {
+	gh := newTestGitHub(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/tech/debt":
+			http.Redirect(w, r, "/repositories/42", http.StatusMovedPermanently)
+		case "/repositories/42":
+			require.Equal(t, "Bearer test-token", r.Header.Get("Authorization"))
+			w.Write([]byte(`{"name":"tech-debt","owner":{"login":"platform"}}`))
+		case "/repos/platform/tech-debt/issues":
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id":1,"number":1}`))
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+	})
+
+	repo, err := gh.VerifyRepository()
+	require.NoError(t, err)
+	require.Equal(t, "platform/tech-debt", repo.String())
+
+	_, err = gh.createIssue(GitIssue{Title: "renamed"})
+	require.NoError(t, err)
+}
+
+// should return a descriptive error when the repository can't be found
+func TestVerifyRepositoryNotFound(t *testing.T) {
+	gh := newTestGitHub(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message":"Not Found"}`))
+	})
+
+	_, err := gh.VerifyRepository()
+	require.ErrorContains(t, err, "repository tech/debt does not exist")
+}
+
+// should include the api error message for other failures
+func TestVerifyRepositoryUnauthorized(t *testing.T) {
+	gh := newTestGitHub(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"message":"Bad credentials"}`))
+	})
+
+	_, err := gh.VerifyRepository()
+	require.ErrorContains(t, err, "status code: 401")
+	require.ErrorContains(t, err, "Bad credentials")
+}
+
+// should page through the issues with the given state and exclude pull requests
+func TestListIssues(t *testing.T) {
+	gh := newTestGitHub(t, func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/repos/tech/debt/issues", r.URL.Path)
+		require.Equal(t, "open", r.URL.Query().Get("state"))
+
+		page := make([]map[string]any, 0)
+		switch r.URL.Query().Get("page") {
+		case "1":
+			for i := 0; i < issues_per_page; i++ {
+				page = append(page, map[string]any{"title": fmt.Sprintf("issue %d", i)})
+			}
+		case "2":
+			page = append(page, map[string]any{
+				"number":   101,
+				"title":    "last issue",
+				"body":     "the body",
+				"state":    "open",
+				"html_url": "https://github.com/tech/debt/issues/101",
+			})
+			page = append(page, map[string]any{"title": "a pull request", "pull_request": map[string]any{}})
+		}
+
+		require.NoError(t, json.NewEncoder(w).Encode(page))
+	})
+
+	issues, err := gh.ListIssues(ISSUE_STATE_OPEN)
+	require.NoError(t, err)
+	require.Len(t, issues, issues_per_page+1)
+	require.Equal(t, "issue 0", issues[0].Title)
+	require.Equal(t, RemoteIssue{
+		Number: 101,
+		Title:  "last issue",
+		Body:   "the body",
+		State:  "open",
+		URL:    "https://github.com/tech/debt/issues/101",
+	}, issues[issues_per_page])
+
+	for _, is := range issues {
+		require.NotEqual(t, "a pull request", is.Title)
+	}
+}
+
+// should send api requests to the base url of a GitHub Enterprise Server installation
+func TestGitHubEnterpriseBaseURL(t *testing.T) {
+	t.Setenv(CONFIG_DIR_ENV, t.TempDir())
+	require.NoError(t, WriteToken("test-token", GITHUB, RemoteRepository{}))
+	var host string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, host, r.Host)
+		require.Equal(t, "/api/v3/repos/tech/debt", r.URL.Path)
+		w.Write([]byte(`{"name":"debt","owner":{"login":"tech"}}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	u, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+	host = u.Host
+
+	gm, err := NewGitManager(GitConfig{
+		Scm:            GITHUB,
+		UserName:       "tech",
+		RepositoryName: "debt",
+		BaseURL:        srv.URL + "/api/v3",
+	})
+	require.NoError(t, err)
+
+	_, err = gm.VerifyRepository()
+	require.NoError(t, err)
+}
+
+// should default to api.github.com when the base url is empty
+func TestGitHubDefaultBaseURL(t *testing.T) {
+	gm, err := NewGitManager(GitConfig{Scm: GITHUB})
+	require.NoError(t, err)
+	require.Equal(t, GITHUB_BASE_URL, gm.(*GitHubManager).baseURL)
+}
+
+// should serve the device flow from the host of the api url
+func TestGitHubWebURL(t *testing.T) {
+	require.Equal(t, BASE_URL, githubWebURL(""))
+	require.Equal(t, BASE_URL, githubWebURL(GITHUB_BASE_URL))
+	require.Equal(t, "https://git.corp.com", githubWebURL("https://git.corp.com/api/v3/"))
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/login/device/code", r.URL.Path)
+		w.Write([]byte(`{"device_code":"abc","user_code":"ABCD-1234"}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	device, err := requestDeviceVerification(githubWebURL(srv.URL + "/api/v3"))
+	require.NoError(t, err)
+	require.Equal(t, "ABCD-1234", device.UserCode)
+}
+
+// should authorize requests with the token of the repository
+func TestGitHubRepositoryToken(t *testing.T) {
+	gh := newTestGitHub(t, func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "Bearer debt-token", r.Header.Get("Authorization"))
+		w.Write([]byte(`{"name":"debt","owner":{"login":"tech"}}`))
+	})
+	repo := RemoteRepository{Owner: "tech", Name: "debt"}
+	require.NoError(t, WriteToken("debt-token", GITHUB, repo))
+
+	_, err := gh.VerifyRepository()
+	require.NoError(t, err)
+}
+
+// should send the labels and assignees of the issue in the request body
+func TestReportLabelsAssignees(t *testing.T) {
+	gh := newTestGitHub(t, func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "POST", r.Method)
+		require.Equal(t, "/repos/tech/debt/issues", r.URL.Path)
+
+		body := map[string]any{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		require.Equal(t, map[string]any{
+			"title":     "add retries",
+			"body":      "requests fail on flaky networks",
+			"labels":    []any{"tech-debt", "networking"},
+			"assignees": []any{"octocat"},
+		}, body)
+
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":7,"number":3,"html_url":"https://github.com/tech/debt/issues/3"}`))
+	})
+
+	reported := gh.Report([]GitIssue{{
+		Title:      "add retries",
+		Body:       "requests fail on flaky networks",
+		Labels:     []string{"tech-debt", "networking"},
+		Assignees:  []string{"octocat"},
+		QueueIndex: 2,
+	}})
+
+	results := make([]Reporter, 0)
+	for r := range reported {
+		results = append(results, r)
+	}
+
+	require.Equal(t, []Reporter{{
+		ID:         7,
+		Number:     3,
+		URL:        "https://github.com/tech/debt/issues/3",
+		QueueIndex: 2,
+	}}, results)
+}
+
+// should return the open and closed issues that contain a marker, keyed by fingerprint
+func TestReportedIssues(t *testing.T) {
+	gh := newTestGitHub(t, func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "all", r.URL.Query().Get("state"))
+		w.Write([]byte(`[
+			{"number":1,"state":"open","body":"body\n\n<!-- issue-summoner fingerprint=aaaa version=dev -->"},
+			{"number":2,"state":"closed","body":"<!-- issue-summoner fingerprint=bbbb version=dev -->"},
+			{"number":3,"state":"open","body":"created by hand"}
+		]`))
+	})
+
+	reported, err := gh.ReportedIssues()
+	require.NoError(t, err)
+	require.Len(t, reported, 2)
+	require.Equal(t, 1, reported["aaaa"].Number)
+	require.Equal(t, "closed", reported["bbbb"].State)
+}
+
+// should post the normalized title and refuse to create an issue with an empty title
+func TestCreateIssueNormalizesTitle(t *testing.T) {
+	requests := 0
+	gh := newTestGitHub(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		body := map[string]any{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		require.Equal(t, "add retries to the client", body["title"])
+
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":1,"number":1}`))
+	})
+
+	_, err := gh.createIssue(GitIssue{Title: "add retries\nto the client "})
+	require.NoError(t, err)
+
+	_, err = gh.createIssue(GitIssue{Title: "\n"})
+	require.ErrorIs(t, err, ErrEmptyTitle)
+	require.Equal(t, 1, requests)
+}
+
+// should close the issue with a PATCH request to the update issue endpoint
+func TestCloseIssue(t *testing.T) {
+	gh := newTestGitHub(t, func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "PATCH", r.Method)
+		require.Equal(t, "/repos/tech/debt/issues/42", r.URL.Path)
+		require.Equal(t, "Bearer test-token", r.Header.Get("Authorization"))
+
+		body := map[string]any{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		require.Equal(t, map[string]any{"state": "closed"}, body)
+		w.Write([]byte(`{"number":42,"state":"closed"}`))
+	})
+
+	require.NoError(t, gh.CloseIssue(42))
+}
+
+// should include the api error message when the issue can't be closed
+func TestCloseIssueForbidden(t *testing.T) {
+	gh := newTestGitHub(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"message":"Resource not accessible by integration"}`))
+	})
+
+	err := gh.CloseIssue(42)
+	require.ErrorContains(t, err, "failed to close issue #42 with status code: 403")
+	require.ErrorContains(t, err, "Resource not accessible by integration")
+}
+
+// should report an issue that doesn't exist, or was deleted, as missing
+func TestCloseIssueMissing(t *testing.T) {
+	for _, status := range []int{http.StatusNotFound, http.StatusGone} {
+		gh := newTestGitHub(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+			w.Write([]byte(`{"message":"Not Found"}`))
+		})
+
+		err := gh.CloseIssue(42)
+		require.ErrorContains(t, err, "issue #42 does not exist in tech/debt", "status %d", status)
+	}
+}
@@ -0,0 +1,56 @@
+package scm
+
+import (
+	"fmt"
+	"strings"
+)
+
+// AnnotationLabels maps an annotation, such as @BUG, to the labels that are added to the
+// issues that are reported for it
+type AnnotationLabels map[string][]string
+
+// ParseAnnotationLabels parses mappings of the form annotation=label, such as @BUG=bug.
+// An annotation that is mapped more than once gets every label it's mapped to.
+func ParseAnnotationLabels(mappings []string) (AnnotationLabels, error) {
+	labels := make(AnnotationLabels)
+	for _, mapping := range mappings {
+		annotation, label, ok := strings.Cut(mapping, "=")
+		annotation, label = strings.TrimSpace(annotation), strings.TrimSpace(label)
+		if !ok || annotation == "" || label == "" {
+			return nil, fmt.Errorf("invalid annotation label %q. expected annotation=label, such as @BUG=bug", mapping)
+		}
+		labels[annotation] = append(labels[annotation], label)
+	}
+	return labels, nil
+}
+
+// Merge returns the labels of both mappings. The labels of other are added after the
+// labels of a for the annotations that are in both.
+func (a AnnotationLabels) Merge(other AnnotationLabels) AnnotationLabels {
+	merged := make(AnnotationLabels, len(a)+len(other))
+	for _, m := range []AnnotationLabels{a, other} {
+		for annotation, labels := range m {
+			merged[annotation] = append(merged[annotation], labels...)
+		}
+	}
+	return merged
+}
+
+// Labels returns labels followed by the labels that annotation is mapped to. Labels that
+// appear more than once are only returned the first time.
+func (a AnnotationLabels) Labels(annotation string, labels []string) []string {
+	mapped := a[annotation]
+	if len(mapped) == 0 {
+		return labels
+	}
+
+	res := make([]string, 0, len(labels)+len(mapped))
+	seen := make(map[string]bool)
+	for _, label := range append(append([]string{}, labels...), mapped...) {
+		if !seen[label] {
+			seen[label] = true
+			res = append(res, label)
+		}
+	}
+	return res
+}
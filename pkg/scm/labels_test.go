@@ -0,0 +1,37 @@
+package scm_test
+
+import (
+	"testing"
+
+	"github.com/AntoninoAdornetto/issue-summoner/pkg/scm"
+	"github.com/stretchr/testify/require"
+)
+
+// should parse annotation=label mappings and collect every label of an annotation
+func TestParseAnnotationLabels(t *testing.T) {
+	labels, err := scm.ParseAnnotationLabels([]string{"@BUG=bug", "@HACK=tech-debt", " @BUG = critical "})
+	require.NoError(t, err)
+	require.Equal(t, scm.AnnotationLabels{
+		"@BUG":  {"bug", "critical"},
+		"@HACK": {"tech-debt"},
+	}, labels)
+
+	for _, mapping := range []string{"@BUG", "=bug", "@BUG=", ""} {
+		_, err := scm.ParseAnnotationLabels([]string{mapping})
+		require.ErrorContains(t, err, "expected annotation=label", mapping)
+	}
+}
+
+// should add the labels of the annotation of each issue to the labels of every issue
+func TestAnnotationLabels(t *testing.T) {
+	config := scm.AnnotationLabels{"@BUG": {"bug"}, "@HACK": {"tech-debt"}}
+	flags := scm.AnnotationLabels{"@BUG": {"critical", "bug"}}
+	labels := config.Merge(flags)
+
+	base := []string{"backend"}
+	require.Equal(t, []string{"backend", "bug", "critical"}, labels.Labels("@BUG", base))
+	require.Equal(t, []string{"backend", "tech-debt"}, labels.Labels("@HACK", base))
+	require.Equal(t, []string{"backend"}, labels.Labels("@TODO", base))
+	require.Equal(t, []string{"backend"}, base)
+	require.Equal(t, scm.AnnotationLabels{"@BUG": {"bug"}, "@HACK": {"tech-debt"}}, config)
+}
@@ -0,0 +1,162 @@
+package scm
+
+import (
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/AntoninoAdornetto/issue-summoner/pkg/clock"
+)
+
+const (
+	DEFAULT_REQUESTS_PER_SECOND = 1.0
+	default_max_retries         = 5
+	default_base_delay          = time.Second
+	default_max_delay           = time.Minute
+)
+
+// RateLimit paces the requests that create issues and retries the requests that are
+// rejected by a rate limit or fail with a server error. The delay of the Retry-After
+// header is honored when it's present, otherwise the delay doubles with every attempt,
+// starting at BaseDelay and capped at MaxDelay, and half of it is random jitter. Zero
+// values are replaced with the defaults. A negative RequestsPerSecond disables pacing and
+// a negative MaxRetries disables retries.
+type RateLimit struct {
+	RequestsPerSecond float64
+	MaxRetries        int
+	BaseDelay         time.Duration
+	MaxDelay          time.Duration
+	// Clock defaults to the wall clock when nil
+	Clock clock.Clock
+	// Rand is the source of the jitter, tests provide a seeded source. A source that is
+	// seeded with the current time is used when nil.
+	Rand *rand.Rand
+}
+
+// limiter enforces a RateLimit. The methods of a nil limiter send each request once,
+// without pacing, so that managers that are created without NewGitManager still work.
+type limiter struct {
+	RateLimit
+	mu   sync.Mutex
+	next time.Time
+}
+
+func newLimiter(rl RateLimit) *limiter {
+	if rl.RequestsPerSecond == 0 {
+		rl.RequestsPerSecond = DEFAULT_REQUESTS_PER_SECOND
+	}
+
+	if rl.MaxRetries == 0 {
+		rl.MaxRetries = default_max_retries
+	}
+
+	if rl.BaseDelay <= 0 {
+		rl.BaseDelay = default_base_delay
+	}
+
+	if rl.MaxDelay <= 0 {
+		rl.MaxDelay = default_max_delay
+	}
+
+	if rl.Rand == nil {
+		rl.Rand = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+
+	rl.Clock = clock.OrReal(rl.Clock)
+	return &limiter{RateLimit: rl}
+}
+
+// wait blocks until the next request is allowed. Each caller reserves the next free slot,
+// so concurrent callers are spaced out by the interval of the rate.
+func (l *limiter) wait() {
+	if l == nil || l.RequestsPerSecond < 0 {
+		return
+	}
+
+	interval := time.Duration(float64(time.Second) / l.RequestsPerSecond)
+	l.mu.Lock()
+	now := l.Clock.Now()
+	at := l.next
+	if at.Before(now) {
+		at = now
+	}
+	l.next = at.Add(interval)
+	l.mu.Unlock()
+
+	if d := at.Sub(now); d > 0 {
+		<-l.Clock.After(d)
+	}
+}
+
+// do sends the request that is created by newRequest and returns the body and status
+// code of the last response. A new request is created for every attempt since the body
+// of a request can only be read once.
+func (l *limiter) do(newRequest func() (*http.Request, error)) ([]byte, int, error) {
+	client := http.Client{}
+	for attempt := 0; ; attempt++ {
+		l.wait()
+		req, err := newRequest()
+		if err != nil {
+			return nil, 0, err
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		data, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, resp.StatusCode, err
+		}
+
+		if l == nil || attempt >= l.MaxRetries || !shouldRetry(resp) {
+			return data, resp.StatusCode, nil
+		}
+
+		<-l.Clock.After(l.delay(resp.Header, attempt))
+	}
+}
+
+// shouldRetry reports whether the response was rejected by a rate limit, 429 or a 403
+// once the rate limit of GitHub is exhausted, or failed with a server error
+func shouldRetry(resp *http.Response) bool {
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests, resp.StatusCode >= 500:
+		return true
+	case resp.StatusCode == http.StatusForbidden:
+		return resp.Header.Get("Retry-After") != "" ||
+			resp.Header.Get("X-RateLimit-Remaining") == "0"
+	default:
+		return false
+	}
+}
+
+// delay returns how long to wait before the next attempt. Retry-After is either a number
+// of seconds or an http date.
+func (l *limiter) delay(header http.Header, attempt int) time.Duration {
+	if retryAfter := header.Get("Retry-After"); retryAfter != "" {
+		if seconds, err := strconv.Atoi(retryAfter); err == nil && seconds >= 0 {
+			return min(time.Duration(seconds)*time.Second, l.MaxDelay)
+		}
+
+		if at, err := http.ParseTime(retryAfter); err == nil {
+			return min(max(at.Sub(l.Clock.Now()), 0), l.MaxDelay)
+		}
+	}
+
+	backoff := l.MaxDelay
+	if attempt < 20 {
+		backoff = min(l.BaseDelay<<attempt, l.MaxDelay)
+	}
+
+	l.mu.Lock()
+	jitter := time.Duration(l.Rand.Int63n(int64(backoff/2) + 1))
+	l.mu.Unlock()
+
+	return backoff/2 + jitter
+}
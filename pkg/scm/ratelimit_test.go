@@ -0,0 +1,153 @@
+package scm
+
+import (
+	"math/rand"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/AntoninoAdornetto/issue-summoner/pkg/clock"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestLimiter returns a limiter with a fake clock and a seeded source of jitter
+func newTestLimiter(rl RateLimit) (*limiter, *clock.Fake) {
+	clk := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	rl.Clock = clk
+	rl.Rand = rand.New(rand.NewSource(1))
+	return newLimiter(rl), clk
+}
+
+// should retry a request that was rate limited once the Retry-After delay has passed
+func TestCreateIssueRetryAfter(t *testing.T) {
+	requests := atomic.Int32{}
+	gh := newTestGitHub(t, func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.Header().Set("Retry-After", "2")
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"message":"API rate limit exceeded"}`))
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":7,"number":3}`))
+	})
+
+	limiter, clk := newTestLimiter(RateLimit{})
+	gh.limiter = limiter
+
+	done := make(chan error)
+	go func() {
+		_, err := gh.createIssue(GitIssue{Title: "add retries"})
+		done <- err
+	}()
+
+	clk.BlockUntil(1)
+	require.Equal(t, int32(1), requests.Load())
+	clk.Advance(time.Second)
+	require.Equal(t, int32(1), requests.Load())
+	clk.Advance(time.Second)
+
+	require.NoError(t, <-done)
+	require.Equal(t, int32(2), requests.Load())
+}
+
+// should back off after server errors and give up once the retries are exhausted
+func TestCreateIssueRetriesExhausted(t *testing.T) {
+	requests := atomic.Int32{}
+	gh := newTestGitHub(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte(`{"message":"Bad Gateway"}`))
+	})
+
+	limiter, clk := newTestLimiter(RateLimit{MaxRetries: 2})
+	gh.limiter = limiter
+
+	done := make(chan error)
+	go func() {
+		_, err := gh.createIssue(GitIssue{Title: "add retries"})
+		done <- err
+	}()
+
+	for i := 0; i < 2; i++ {
+		clk.BlockUntil(1)
+		clk.Advance(default_max_delay)
+	}
+
+	require.ErrorContains(t, <-done, "status code: 502")
+	require.Equal(t, int32(3), requests.Load())
+}
+
+// should not retry requests that were rejected for other reasons
+func TestCreateIssueNoRetry(t *testing.T) {
+	requests := atomic.Int32{}
+	gh := newTestGitHub(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"message":"Resource not accessible by integration"}`))
+	})
+	gh.limiter, _ = newTestLimiter(RateLimit{})
+
+	_, err := gh.createIssue(GitIssue{Title: "add retries"})
+	require.ErrorContains(t, err, "status code: 403")
+	require.Equal(t, int32(1), requests.Load())
+}
+
+// should honor both forms of Retry-After and otherwise double the delay, with jitter,
+// up to the max delay
+func TestLimiterDelay(t *testing.T) {
+	l, clk := newTestLimiter(RateLimit{BaseDelay: time.Second, MaxDelay: 10 * time.Second})
+
+	header := http.Header{}
+	header.Set("Retry-After", "3")
+	require.Equal(t, 3*time.Second, l.delay(header, 0))
+
+	header.Set("Retry-After", clk.Now().Add(5*time.Second).Format(http.TimeFormat))
+	require.Equal(t, 5*time.Second, l.delay(header, 0))
+
+	header.Set("Retry-After", "120")
+	require.Equal(t, 10*time.Second, l.delay(header, 0))
+
+	backoffs := []time.Duration{
+		time.Second,
+		2 * time.Second,
+		4 * time.Second,
+		8 * time.Second,
+		10 * time.Second,
+		10 * time.Second,
+	}
+
+	for attempt, backoff := range backoffs {
+		delay := l.delay(http.Header{}, attempt)
+		require.GreaterOrEqual(t, delay, backoff/2, attempt)
+		require.LessOrEqual(t, delay, backoff, attempt)
+	}
+}
+
+// should space concurrent requests out by the interval of the rate
+func TestLimiterWait(t *testing.T) {
+	l, clk := newTestLimiter(RateLimit{RequestsPerSecond: 2})
+
+	waited := make(chan struct{})
+	l.wait()
+	go func() {
+		l.wait()
+		close(waited)
+	}()
+
+	clk.BlockUntil(1)
+	clk.Advance(499 * time.Millisecond)
+	select {
+	case <-waited:
+		t.Fatal("expected the second request to wait for 500ms")
+	default:
+	}
+
+	clk.Advance(time.Millisecond)
+	<-waited
+
+	unlimited, _ := newTestLimiter(RateLimit{RequestsPerSecond: -1})
+	unlimited.wait()
+	unlimited.wait()
+}
@@ -0,0 +1,45 @@
+package scm
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ReferenceFormat returns the reference to the issue numbered id that is canonical on scm,
+// it's written in place of the annotation once the issue is reported. GitHub, Gitea and
+// Bitbucket refer to the issues of a repository with #id. GitLab qualifies the reference
+// with the path of the project, group/project#id, so that it still resolves when the
+// comment is quoted in another project. The short form is used when owner or repo is not
+// known, and for platforms that are not supported.
+func ReferenceFormat(scm string, id int64, owner, repo string) string {
+	switch {
+	case scm == GITLAB && owner != "" && repo != "":
+		return fmt.Sprintf("%s/%s#%d", owner, repo, id)
+	default:
+		return fmt.Sprintf("#%d", id)
+	}
+}
+
+// ReferenceNumber returns the number of the issue that ref, see ReferenceFormat, refers
+// to. A qualified reference only refers to an issue of the repository owner/repo when
+// its path matches, without regard to case. References without a #, which were written
+// by versions that predate ReferenceFormat, are read as the number. ok is false when ref
+// refers to another repository or is not a reference.
+func ReferenceNumber(ref, owner, repo string) (number int64, ok bool) {
+	path, num := "", ref
+	if i := strings.LastIndex(ref, "#"); i != -1 {
+		path, num = ref[:i], ref[i+1:]
+	}
+
+	number, err := strconv.ParseInt(num, 10, 64)
+	if err != nil || number <= 0 {
+		return 0, false
+	}
+
+	if path != "" && !strings.EqualFold(path, owner+"/"+repo) {
+		return 0, false
+	}
+
+	return number, true
+}
@@ -0,0 +1,48 @@
+package scm_test
+
+import (
+	"testing"
+
+	"github.com/AntoninoAdornetto/issue-summoner/pkg/scm"
+	"github.com/stretchr/testify/require"
+)
+
+// should return the canonical issue reference of each platform
+func TestReferenceFormat(t *testing.T) {
+	for _, tc := range []struct {
+		scm, owner, repo, expected string
+	}{
+		{scm.GITHUB, "octocat", "hello-world", "#42"},
+		{scm.GITEA, "octocat", "hello-world", "#42"},
+		{scm.BITBUCKET, "octocat", "hello-world", "#42"},
+		{scm.GITLAB, "my-group/sub-group", "project", "my-group/sub-group/project#42"},
+		{scm.GITLAB, "", "project", "#42"},
+		{"sourcehut", "octocat", "hello-world", "#42"},
+	} {
+		actual := scm.ReferenceFormat(tc.scm, 42, tc.owner, tc.repo)
+		require.Equal(t, tc.expected, actual, "%s %s/%s", tc.scm, tc.owner, tc.repo)
+	}
+}
+
+// should return the number of the issues that a reference refers to in the repository
+func TestReferenceNumber(t *testing.T) {
+	for _, tc := range []struct {
+		ref      string
+		expected int64
+		ok       bool
+	}{
+		{"#42", 42, true},
+		{"42", 42, true},
+		{"my-group/project#42", 42, true},
+		{"My-Group/Project#42", 42, true},
+		{"other-group/project#42", 0, false},
+		{"#", 0, false},
+		{"#0", 0, false},
+		{"#abc", 0, false},
+		{"", 0, false},
+	} {
+		actual, ok := scm.ReferenceNumber(tc.ref, "my-group", "project")
+		require.Equal(t, tc.ok, ok, tc.ref)
+		require.Equal(t, tc.expected, actual, tc.ref)
+	}
+}
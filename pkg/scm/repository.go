@@ -2,8 +2,10 @@ package scm
 
 import (
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 type Repository struct {
@@ -18,17 +20,86 @@ func NewRepository(path string) *Repository {
 	}
 }
 
+// ErrNotRepository is returned when a directory is not inside of a git repository
+var ErrNotRepository = errors.New("expected to find a local git repo but found none")
+
+// FindRepository returns the repository that contains wd, searching wd and its parents
+// for a .git entry. A relative wd is resolved against the working directory, the error
+// wraps ErrNotRepository once the root of the file system is reached.
 func FindRepository(wd string) (*Repository, error) {
-	if wd == "/" {
-		return nil, errors.New("expected to find a local git repo but found none.")
+	dir, err := filepath.Abs(wd)
+	if err != nil {
+		return nil, err
 	}
 
-	if _, err := os.Stat(filepath.Join(wd, ".git")); err != nil {
-		if os.IsNotExist(err) {
-			return FindRepository(filepath.Join(wd, "../"))
+	for {
+		_, err := os.Stat(filepath.Join(dir, ".git"))
+		if err == nil {
+			return NewRepository(dir), nil
+		}
+
+		if !os.IsNotExist(err) {
+			return nil, err
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, fmt.Errorf("%w. %s is not inside of a git repository", ErrNotRepository, wd)
 		}
+		dir = parent
+	}
+}
+
+// FindRepositories returns the repositories located beneath dir, searching at most
+// maxDepth directories deep. The directories of a repository are not searched, so that
+// submodules and repositories nested inside of another repository are not reported
+// twice. Hidden directories are skipped, as are the directories beneath dir that can't be
+// read, so that a single directory doesn't prevent the rest of the repositories from
+// being found.
+func FindRepositories(dir string, maxDepth int) ([]*Repository, error) {
+	repos := make([]*Repository, 0)
+	if err := findRepositories(filepath.Clean(dir), 0, maxDepth, &repos); err != nil {
 		return nil, err
 	}
+	return repos, nil
+}
+
+func findRepositories(dir string, depth, maxDepth int, repos *[]*Repository) error {
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+		*repos = append(*repos, NewRepository(dir))
+		return nil
+	} else if !os.IsNotExist(err) {
+		return skipUnreadable(err, depth)
+	}
 
-	return NewRepository(wd), nil
+	if depth >= maxDepth {
+		return nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return skipUnreadable(err, depth)
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		err := findRepositories(filepath.Join(dir, entry.Name()), depth+1, maxDepth, repos)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// skipUnreadable drops the error of a directory beneath the root of the search, only the
+// error of the root itself is returned
+func skipUnreadable(err error, depth int) error {
+	if depth == 0 {
+		return err
+	}
+	return nil
 }
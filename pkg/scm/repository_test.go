@@ -1,6 +1,7 @@
 package scm_test
 
 import (
+	"os"
 	"path/filepath"
 	"testing"
 
@@ -36,3 +37,88 @@ func TestFindRepoRootError(t *testing.T) {
 	require.Error(t, err)
 	require.Nil(t, repo)
 }
+
+// should return ErrNotRepository when no parent of the directory is a repository, and
+// resolve relative directories rather than searching their parents forever
+func TestFindRepoNotRepository(t *testing.T) {
+	repo, err := scm.FindRepository(t.TempDir())
+	require.ErrorIs(t, err, scm.ErrNotRepository)
+	require.Nil(t, repo)
+
+	dir := gitInit(t, "")
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	t.Cleanup(func() { require.NoError(t, os.Chdir(wd)) })
+
+	repo, err = scm.FindRepository(".")
+	require.NoError(t, err)
+	require.Equal(t, dir, repo.WorkTree)
+}
+
+// should find every repository beneath the directory without descending into a repository
+// or past the max depth
+func TestFindRepositories(t *testing.T) {
+	dir := t.TempDir()
+	for _, path := range []string{
+		"api/.git",
+		"api/vendor/lib/.git",
+		"web/.git",
+		"libs/cli/.git",
+		"libs/deep/nested/repo/.git",
+		".cache/hidden/.git",
+		"notes",
+	} {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, path), 0755))
+	}
+
+	// submodules use a .git file rather than a directory
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "libs", "sub"), 0755))
+	require.NoError(
+		t,
+		os.WriteFile(filepath.Join(dir, "libs", "sub", ".git"), []byte("gitdir: ../.git"), 0644),
+	)
+
+	repos, err := scm.FindRepositories(dir, 2)
+	require.NoError(t, err)
+
+	workTrees := make([]string, len(repos))
+	for i, repo := range repos {
+		workTrees[i] = repo.WorkTree
+	}
+
+	require.Equal(t, []string{
+		filepath.Join(dir, "api"),
+		filepath.Join(dir, "libs", "cli"),
+		filepath.Join(dir, "libs", "sub"),
+		filepath.Join(dir, "web"),
+	}, workTrees)
+}
+
+// should skip the directories that can't be read and keep searching the rest
+func TestFindRepositoriesUnreadable(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("the permissions of a directory don't apply to root")
+	}
+
+	dir := t.TempDir()
+	for _, path := range []string{"api/.git", "locked/repo/.git", "web/.git"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, path), 0755))
+	}
+
+	locked := filepath.Join(dir, "locked")
+	require.NoError(t, os.Chmod(locked, 0000))
+	t.Cleanup(func() { os.Chmod(locked, 0755) })
+
+	repos, err := scm.FindRepositories(dir, 2)
+	require.NoError(t, err)
+	require.Len(t, repos, 2)
+	require.Equal(t, filepath.Join(dir, "api"), repos[0].WorkTree)
+	require.Equal(t, filepath.Join(dir, "web"), repos[1].WorkTree)
+}
+
+// should return the error of a root that can't be read
+func TestFindRepositoriesRootUnreadable(t *testing.T) {
+	_, err := scm.FindRepositories(filepath.Join(t.TempDir(), "missing"), 2)
+	require.Error(t, err)
+}
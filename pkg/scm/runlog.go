@@ -0,0 +1,119 @@
+package scm
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const run_log_dir_name = "runs"
+
+// LoggedIssue is an issue that a report run created
+type LoggedIssue struct {
+	ID     int64  `json:"id"`
+	Number int    `json:"number"`
+	URL    string `json:"url,omitempty"`
+}
+
+// RunLog records the issues that report runs created for a repository, keyed by the
+// fingerprint of their annotation, until the reference to the issue has been written to
+// the annotation. A run that fails after an issue was created, but before its annotation
+// was updated, leaves the issue in the log so that the next run doesn't create it again.
+// Every change is written to the file right away, so the log survives a run that crashes.
+type RunLog struct {
+	path     string
+	Repo     string                 `json:"repo"`
+	Reported map[string]LoggedIssue `json:"reported"`
+}
+
+// RunLogPath returns the path of the run log of repo. Each repository has its own log
+// file in the runs directory of the config dir.
+func RunLogPath(repo RemoteRepository) (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256([]byte(strings.ToLower(repo.String())))
+	name := fmt.Sprintf("run-%s.json", hex.EncodeToString(sum[:8]))
+	return filepath.Join(dir, run_log_dir_name, name), nil
+}
+
+// OpenRunLog reads the run log of repo. The log is empty when no run has left any issues
+// in it.
+func OpenRunLog(repo RemoteRepository) (*RunLog, error) {
+	path, err := RunLogPath(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	log := &RunLog{path: path, Repo: repo.String(), Reported: make(map[string]LoggedIssue)}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return log, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, log); err != nil {
+		return nil, fmt.Errorf("invalid run log %s: %w", path, err)
+	}
+
+	if log.Reported == nil {
+		log.Reported = make(map[string]LoggedIssue)
+	}
+	return log, nil
+}
+
+// Find returns the issue that a previous run created for the annotation with fingerprint
+func (rl *RunLog) Find(fingerprint string) (LoggedIssue, bool) {
+	is, ok := rl.Reported[fingerprint]
+	return is, ok
+}
+
+// Record adds the issue that was created for the annotation with fingerprint to the log
+func (rl *RunLog) Record(fingerprint string, is LoggedIssue) error {
+	rl.Reported[fingerprint] = is
+	return rl.save()
+}
+
+// Forget removes the annotation with fingerprint from the log, once the reference to its
+// issue has been written to the annotation
+func (rl *RunLog) Forget(fingerprint string) error {
+	if _, ok := rl.Reported[fingerprint]; !ok {
+		return nil
+	}
+
+	delete(rl.Reported, fingerprint)
+	return rl.save()
+}
+
+// Reset removes every issue from the log
+func (rl *RunLog) Reset() error {
+	rl.Reported = make(map[string]LoggedIssue)
+	return rl.save()
+}
+
+// save writes the log, the file is removed once the log is empty
+func (rl *RunLog) save() error {
+	if len(rl.Reported) == 0 {
+		if err := os.Remove(rl.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(rl.path), config_dir_mode); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(rl)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(rl.path, data, 0666)
+}
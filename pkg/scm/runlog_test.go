@@ -0,0 +1,164 @@
+package scm
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// should start with an empty log and keep the recorded issues across runs
+func TestRunLogPersist(t *testing.T) {
+	t.Setenv(CONFIG_DIR_ENV, t.TempDir())
+	repo := RemoteRepository{Owner: "tech", Name: "debt"}
+
+	log, err := OpenRunLog(repo)
+	require.NoError(t, err)
+	require.Empty(t, log.Reported)
+
+	_, ok := log.Find("abc")
+	require.False(t, ok)
+
+	logged := LoggedIssue{ID: 7, Number: 3, URL: "https://github.com/tech/debt/issues/3"}
+	require.NoError(t, log.Record("abc", logged))
+
+	reopened, err := OpenRunLog(repo)
+	require.NoError(t, err)
+	actual, ok := reopened.Find("abc")
+	require.True(t, ok)
+	require.Equal(t, logged, actual)
+
+	require.NoError(t, reopened.Forget("abc"))
+	path, err := RunLogPath(repo)
+	require.NoError(t, err)
+	_, err = os.Stat(path)
+	require.True(t, os.IsNotExist(err), "the file of an empty log is removed")
+}
+
+// should share the log of a repository regardless of case and keep the logs of different
+// repositories apart
+func TestRunLogPath(t *testing.T) {
+	t.Setenv(CONFIG_DIR_ENV, t.TempDir())
+
+	upper, err := RunLogPath(RemoteRepository{Owner: "Tech", Name: "Debt"})
+	require.NoError(t, err)
+	lower, err := RunLogPath(RemoteRepository{Owner: "tech", Name: "debt"})
+	require.NoError(t, err)
+	require.Equal(t, upper, lower)
+
+	other, err := RunLogPath(RemoteRepository{Owner: "tech", Name: "credit"})
+	require.NoError(t, err)
+	require.NotEqual(t, lower, other)
+}
+
+// should remove every issue from the log
+func TestRunLogReset(t *testing.T) {
+	t.Setenv(CONFIG_DIR_ENV, t.TempDir())
+	repo := RemoteRepository{Owner: "tech", Name: "debt"}
+
+	log, err := OpenRunLog(repo)
+	require.NoError(t, err)
+	require.NoError(t, log.Record("abc", LoggedIssue{Number: 3}))
+	require.NoError(t, log.Reset())
+
+	reopened, err := OpenRunLog(repo)
+	require.NoError(t, err)
+	require.Empty(t, reopened.Reported)
+}
+
+// should return an error when the log can't be decoded
+func TestRunLogInvalid(t *testing.T) {
+	t.Setenv(CONFIG_DIR_ENV, t.TempDir())
+	repo := RemoteRepository{Owner: "tech", Name: "debt"}
+
+	log, err := OpenRunLog(repo)
+	require.NoError(t, err)
+	require.NoError(t, log.Record("abc", LoggedIssue{Number: 3}))
+	require.NoError(t, os.WriteFile(log.path, []byte("{"), 0666))
+
+	_, err = OpenRunLog(repo)
+	require.ErrorContains(t, err, "invalid run log")
+}
+
+// should only report the issues that were not created when a partial run is resumed
+func TestRunLogResume(t *testing.T) {
+	t.Setenv(CONFIG_DIR_ENV, t.TempDir())
+	repo := RemoteRepository{Owner: "tech", Name: "debt"}
+
+	mu := sync.Mutex{}
+	requested := make([]string, 0)
+	failing := "cache the results"
+	gh := newTestGitHub(t, func(w http.ResponseWriter, r *http.Request) {
+		body := map[string]any{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+
+		mu.Lock()
+		defer mu.Unlock()
+		requested = append(requested, body["title"].(string))
+		if body["title"] == failing {
+			w.WriteHeader(http.StatusBadGateway)
+			w.Write([]byte(`{"message":"Bad Gateway"}`))
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":7,"number":3,"html_url":"https://github.com/tech/debt/issues/3"}`))
+	})
+
+	fingerprints := map[string]string{
+		"add retries":         "f1",
+		"cache the results":   "f2",
+		"drop the legacy api": "f3",
+	}
+	annotations := []GitIssue{
+		{Title: "add retries"},
+		{Title: "cache the results"},
+		{Title: "drop the legacy api"},
+	}
+
+	// the first run creates the issues that don't fail and is interrupted before their
+	// references are written to the annotations
+	log, err := OpenRunLog(repo)
+	require.NoError(t, err)
+	results, err := ReportBatch(gh, annotations)
+	require.Error(t, err)
+	for _, result := range results {
+		if result.Err != nil {
+			continue
+		}
+		logged := LoggedIssue{ID: result.ID, Number: result.Number, URL: result.URL}
+		require.NoError(t, log.Record(fingerprints[result.Issue.Title], logged))
+	}
+	require.ElementsMatch(t, annotations, toIssues(requested))
+
+	// the second run skips the issues in the log
+	requested, failing = requested[:0], ""
+	resumed, err := OpenRunLog(repo)
+	require.NoError(t, err)
+
+	queue := make([]GitIssue, 0)
+	for _, is := range annotations {
+		if _, ok := resumed.Find(fingerprints[is.Title]); ok {
+			require.NoError(t, resumed.Forget(fingerprints[is.Title]))
+			continue
+		}
+		queue = append(queue, is)
+	}
+
+	results, err = ReportBatch(gh, queue)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	require.Equal(t, []string{"cache the results"}, requested)
+	require.Empty(t, resumed.Reported)
+}
+
+func toIssues(titles []string) []GitIssue {
+	issues := make([]GitIssue, 0, len(titles))
+	for _, title := range titles {
+		issues = append(issues, GitIssue{Title: title})
+	}
+	return issues
+}
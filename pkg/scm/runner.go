@@ -0,0 +1,321 @@
+package scm
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+var ErrUnsupportedGitCommand = errors.New("git command is not supported without the git binary")
+
+// GitRunner executes a git command in dir and returns its standard output
+type GitRunner interface {
+	Run(dir string, args ...string) ([]byte, error)
+}
+
+// Git is the runner used to read the remotes and the user of a repository. The git binary
+// is used when it's found in PATH, otherwise the config files are read directly.
+var Git GitRunner = defaultGitRunner()
+
+func defaultGitRunner() GitRunner {
+	if _, err := exec.LookPath("git"); err == nil {
+		return ExecRunner{}
+	}
+	return ConfigFileRunner{}
+}
+
+// ExecRunner runs commands with the git binary
+type ExecRunner struct{}
+
+func (ExecRunner) Run(dir string, args ...string) ([]byte, error) {
+	stderr := bytes.Buffer{}
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil && stderr.Len() > 0 {
+		return out, fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return out, err
+}
+
+// ConfigFileRunner is a pure Go fallback for machines without the git binary. It only
+// supports reading the remotes of a repository, <git remote -v>, the name of the user,
+// <git config [--global] user.name>, and the global excludes file,
+// <git config --get core.excludesFile>, by parsing the git config files.
+type ConfigFileRunner struct{}
+
+func (ConfigFileRunner) Run(dir string, args ...string) ([]byte, error) {
+	switch strings.Join(args, " ") {
+	case "remote -v":
+		return readRemotes(dir)
+	case "config user.name":
+		return readConfigValue(dir, false, "user", "name")
+	case "config --global user.name":
+		return readConfigValue(dir, true, "user", "name")
+	case "config --get core.excludesFile":
+		return readConfigValue(dir, false, "core", "excludesfile")
+	default:
+		return nil, fmt.Errorf("%w: git %s", ErrUnsupportedGitCommand, strings.Join(args, " "))
+	}
+}
+
+// FakeGitRunner returns canned output for tests. Outputs is keyed by the arguments of
+// the command joined by a space, commands without an output return an error. Every
+// call is recorded in Calls.
+type FakeGitRunner struct {
+	Outputs map[string]string
+	Calls   []FakeGitCall
+}
+
+type FakeGitCall struct {
+	Dir  string
+	Args []string
+}
+
+func (f *FakeGitRunner) Run(dir string, args ...string) ([]byte, error) {
+	f.Calls = append(f.Calls, FakeGitCall{Dir: dir, Args: args})
+	out, ok := f.Outputs[strings.Join(args, " ")]
+	if !ok {
+		return nil, fmt.Errorf("exit status 1: git %s", strings.Join(args, " "))
+	}
+	return []byte(out), nil
+}
+
+// RepoName returns the owner and name of the repository located at path, using the url
+// of its first remote
+func RepoName(runner GitRunner, path string) (string, string, error) {
+	out, err := runner.Run(path, "remote", "-v")
+	if err != nil {
+		return "", "", err
+	}
+	return ExtractUserRepoName(out)
+}
+
+// GlobalUserName returns the user.name value of the global git config
+func GlobalUserName(runner GitRunner) (string, error) {
+	out, err := runner.Run("", "config", "--global", "user.name")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// GlobalExcludesFile returns the path of the excludes file that git applies to every
+// repository, core.excludesFile, as seen from dir. git falls back to
+// $XDG_CONFIG_HOME/git/ignore, or ~/.config/git/ignore, when it's not set. The file is
+// not required to exist.
+func GlobalExcludesFile(runner GitRunner, dir string) string {
+	home, _ := os.UserHomeDir()
+	out, err := runner.Run(dir, "config", "--get", "core.excludesFile")
+	if path := strings.TrimSpace(string(out)); err == nil && path != "" {
+		if rest, ok := strings.CutPrefix(path, "~/"); ok && home != "" {
+			return filepath.Join(home, rest)
+		}
+		return path
+	}
+
+	if xdg := os.Getenv(XDG_CONFIG_ENV); xdg != "" {
+		return filepath.Join(xdg, "git", "ignore")
+	}
+
+	if home == "" {
+		return ""
+	}
+
+	return filepath.Join(home, ".config", "git", "ignore")
+}
+
+// DefaultBranch returns the default branch of the origin remote of the repository located
+// at dir, falling back to the branch that is checked out
+func DefaultBranch(runner GitRunner, dir string) (string, error) {
+	out, err := runner.Run(dir, "symbolic-ref", "--short", "refs/remotes/origin/HEAD")
+	if err == nil {
+		return strings.TrimPrefix(strings.TrimSpace(string(out)), "origin/"), nil
+	}
+
+	out, err = runner.Run(dir, "rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return "", err
+	}
+
+	branch := strings.TrimSpace(string(out))
+	if branch == "HEAD" {
+		return "", errors.New("unable to determine the default branch, HEAD is detached")
+	}
+
+	return branch, nil
+}
+
+// gitConfigEntry is a key of a git config file. subsection is the quoted part of a
+// section header, such as origin for [remote "origin"].
+type gitConfigEntry struct {
+	section    string
+	subsection string
+	key        string
+	value      string
+}
+
+// parseGitConfig reads the entries of a git config file. Includes, multi line values and
+// escape sequences other than quotes are not supported.
+func parseGitConfig(path string) ([]gitConfigEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	entries := make([]gitConfigEntry, 0)
+	section, subsection := "", ""
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+
+		if line[0] == '[' {
+			header := strings.TrimSuffix(strings.TrimPrefix(line, "["), "]")
+			name, sub, _ := strings.Cut(header, " ")
+			section = strings.ToLower(name)
+			subsection = strings.Trim(strings.TrimSpace(sub), `"`)
+			continue
+		}
+
+		key, value, _ := strings.Cut(line, "=")
+		entries = append(entries, gitConfigEntry{
+			section:    section,
+			subsection: subsection,
+			key:        strings.ToLower(strings.TrimSpace(key)),
+			value:      strings.Trim(strings.TrimSpace(value), `"`),
+		})
+	}
+
+	return entries, scanner.Err()
+}
+
+// gitDir resolves the git directory of the repository located at dir. Submodules and
+// linked worktrees use a .git file that points to the git directory, and the config of
+// a linked worktree is stored in the common directory of the main repository.
+func gitDir(dir string) (string, error) {
+	repo, err := FindRepository(dir)
+	if err != nil {
+		return "", err
+	}
+
+	info, err := os.Stat(repo.Dir)
+	if err != nil {
+		return "", err
+	}
+
+	if info.IsDir() {
+		return repo.Dir, nil
+	}
+
+	data, err := os.ReadFile(repo.Dir)
+	if err != nil {
+		return "", err
+	}
+
+	path, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir:")
+	if !ok {
+		return "", fmt.Errorf("expected %s to contain a gitdir", repo.Dir)
+	}
+
+	path = strings.TrimSpace(path)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(repo.WorkTree, path)
+	}
+
+	if common, err := os.ReadFile(filepath.Join(path, "commondir")); err == nil {
+		commonDir := strings.TrimSpace(string(common))
+		if !filepath.IsAbs(commonDir) {
+			commonDir = filepath.Join(path, commonDir)
+		}
+		return commonDir, nil
+	}
+
+	return path, nil
+}
+
+// readRemotes formats the remotes of the repository like <git remote -v>
+func readRemotes(dir string) ([]byte, error) {
+	path, err := gitDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := parseGitConfig(filepath.Join(path, "config"))
+	if err != nil {
+		return nil, err
+	}
+
+	remotes := make([]gitConfigEntry, 0)
+	for _, entry := range entries {
+		if entry.section == "remote" && entry.key == "url" {
+			remotes = append(remotes, entry)
+		}
+	}
+
+	// git lists the remotes sorted by name
+	sort.SliceStable(remotes, func(i, j int) bool {
+		return remotes[i].subsection < remotes[j].subsection
+	})
+
+	out := bytes.Buffer{}
+	for _, remote := range remotes {
+		fmt.Fprintf(&out, "%s\t%s (fetch)\n", remote.subsection, remote.value)
+		fmt.Fprintf(&out, "%s\t%s (push)\n", remote.subsection, remote.value)
+	}
+
+	return out.Bytes(), nil
+}
+
+// readConfigValue returns the last value of section.key, the repository config takes
+// precedence over the global config files unless global is set. key is lower case.
+func readConfigValue(dir string, global bool, section, key string) ([]byte, error) {
+	paths := make([]string, 0, 3)
+	if xdg := os.Getenv(XDG_CONFIG_ENV); xdg != "" {
+		paths = append(paths, filepath.Join(xdg, "git", "config"))
+	}
+
+	if home, err := os.UserHomeDir(); err == nil {
+		paths = append(paths, filepath.Join(home, ".gitconfig"))
+	}
+
+	if !global {
+		if path, err := gitDir(dir); err == nil {
+			paths = append(paths, filepath.Join(path, "config"))
+		}
+	}
+
+	value := ""
+	for _, path := range paths {
+		entries, err := parseGitConfig(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+
+		for _, entry := range entries {
+			if entry.section == section && entry.key == key {
+				value = entry.value
+			}
+		}
+	}
+
+	if value == "" {
+		return nil, fmt.Errorf("%s.%s is not set in the git config", section, key)
+	}
+
+	return []byte(value + "\n"), nil
+}
@@ -0,0 +1,159 @@
+package scm_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/AntoninoAdornetto/issue-summoner/pkg/scm"
+	"github.com/stretchr/testify/require"
+)
+
+// should run the remote command in the provided path rather than the working directory
+func TestRepoNameFake(t *testing.T) {
+	runner := &scm.FakeGitRunner{Outputs: map[string]string{"remote -v": ssh_remote_output}}
+	userName, repoName, err := scm.RepoName(runner, "/home/user/project")
+	require.NoError(t, err)
+	require.Equal(t, "AntoninoAdornetto", userName)
+	require.Equal(t, "issue-summoner", repoName)
+	require.Equal(t, []scm.FakeGitCall{
+		{Dir: "/home/user/project", Args: []string{"remote", "-v"}},
+	}, runner.Calls)
+}
+
+// should return the error of the runner
+func TestGlobalUserNameFake(t *testing.T) {
+	runner := &scm.FakeGitRunner{}
+	_, err := scm.GlobalUserName(runner)
+	require.Error(t, err)
+
+	runner.Outputs = map[string]string{"config --global user.name": "Antonino Adornetto\n"}
+	name, err := scm.GlobalUserName(runner)
+	require.NoError(t, err)
+	require.Equal(t, "Antonino Adornetto", name)
+}
+
+// should read the same remotes, user name and excludes file as the git binary
+func TestConfigFileRunner(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv(scm.XDG_CONFIG_ENV, "")
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	require.NoError(t, os.WriteFile(
+		filepath.Join(home, ".gitconfig"),
+		[]byte("[user]\n\tname = Global User\n[core]\n\texcludesFile = ~/.gitignore_global\n"),
+		0644,
+	))
+
+	dir := gitInit(t, "git@github.com:AntoninoAdornetto/issue-summoner.git")
+	require.NoError(t, exec.Command("git", "-C", dir, "remote", "add", "fork", "https://github.com/fork/issue-summoner.git").Run())
+
+	nested := filepath.Join(dir, "pkg", "scm")
+	require.NoError(t, os.MkdirAll(nested, 0755))
+
+	fallback := scm.ConfigFileRunner{}
+	for _, args := range [][]string{
+		{"remote", "-v"},
+		{"config", "user.name"},
+		{"config", "--global", "user.name"},
+		{"config", "--get", "core.excludesFile"},
+	} {
+		expected, err := scm.ExecRunner{}.Run(nested, args...)
+		require.NoError(t, err)
+
+		actual, err := fallback.Run(nested, args...)
+		require.NoError(t, err)
+		require.Equal(t, string(expected), string(actual), args)
+	}
+
+	require.NoError(t, exec.Command("git", "-C", dir, "config", "user.name", "Local User").Run())
+	name, err := fallback.Run(dir, "config", "user.name")
+	require.NoError(t, err)
+	require.Equal(t, "Local User\n", string(name))
+
+	_, err = fallback.Run(dir, "status")
+	require.ErrorIs(t, err, scm.ErrUnsupportedGitCommand)
+}
+
+// should expand the home directory of core.excludesFile and fall back to the default
+// location of git when it's not set
+func TestGlobalExcludesFile(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv(scm.XDG_CONFIG_ENV, "")
+
+	runner := &scm.FakeGitRunner{Outputs: map[string]string{
+		"config --get core.excludesFile": "~/.gitignore_global\n",
+	}}
+	require.Equal(t, filepath.Join(home, ".gitignore_global"), scm.GlobalExcludesFile(runner, "/repo"))
+	require.Equal(t, "/repo", runner.Calls[0].Dir)
+
+	runner.Outputs["config --get core.excludesFile"] = "/etc/gitignore\n"
+	require.Equal(t, "/etc/gitignore", scm.GlobalExcludesFile(runner, "/repo"))
+
+	delete(runner.Outputs, "config --get core.excludesFile")
+	require.Equal(t, filepath.Join(home, ".config", "git", "ignore"), scm.GlobalExcludesFile(runner, "/repo"))
+
+	xdg := t.TempDir()
+	t.Setenv(scm.XDG_CONFIG_ENV, xdg)
+	require.Equal(t, filepath.Join(xdg, "git", "ignore"), scm.GlobalExcludesFile(runner, "/repo"))
+}
+
+// should prefer the default branch of origin and fall back to the current branch
+func TestDefaultBranch(t *testing.T) {
+	runner := &scm.FakeGitRunner{Outputs: map[string]string{
+		"symbolic-ref --short refs/remotes/origin/HEAD": "origin/trunk\n",
+		"rev-parse --abbrev-ref HEAD":                   "feature\n",
+	}}
+	branch, err := scm.DefaultBranch(runner, "/repo")
+	require.NoError(t, err)
+	require.Equal(t, "trunk", branch)
+
+	delete(runner.Outputs, "symbolic-ref --short refs/remotes/origin/HEAD")
+	branch, err = scm.DefaultBranch(runner, "/repo")
+	require.NoError(t, err)
+	require.Equal(t, "feature", branch)
+
+	runner.Outputs["rev-parse --abbrev-ref HEAD"] = "HEAD\n"
+	_, err = scm.DefaultBranch(runner, "/repo")
+	require.Error(t, err)
+}
+
+// should read the author of the line from the porcelain output of git blame
+func TestBlame(t *testing.T) {
+	runner := &scm.FakeGitRunner{Outputs: map[string]string{
+		"blame --porcelain -L 12,12 -- main.go": "6b1c7a9e2f0d4c3b8a5e1f7d9c2b4a6e8f0d1c3b 12 12 1\n" +
+			"author Jane Doe\n" +
+			"author-mail <jane@example.com>\n" +
+			"author-time 1700000000\n" +
+			"author-tz +0000\n" +
+			"summary add greeting\n" +
+			"filename main.go\n" +
+			"\t// @TODO customize the greeting\n",
+	}}
+
+	author, err := scm.Blame(runner, "/repo", "main.go", 12)
+	require.NoError(t, err)
+	require.Equal(t, scm.BlameAuthor{Name: "Jane Doe", Email: "jane@example.com", Committed: true}, author)
+	require.Equal(t, "Jane Doe <jane@example.com>", author.String())
+	require.Equal(t, []scm.FakeGitCall{
+		{Dir: "/repo", Args: []string{"blame", "--porcelain", "-L", "12,12", "--", "main.go"}},
+	}, runner.Calls)
+}
+
+// should not attribute uncommitted lines to an author
+func TestBlameNotCommitted(t *testing.T) {
+	runner := &scm.FakeGitRunner{Outputs: map[string]string{
+		"blame --porcelain -L 3,3 -- main.go": "0000000000000000000000000000000000000000 3 3 1\n" +
+			"author Not Committed Yet\n" +
+			"author-mail <not.committed.yet>\n" +
+			"filename main.go\n" +
+			"\t// @TODO new\n",
+	}}
+
+	author, err := scm.Blame(runner, "/repo", "main.go", 3)
+	require.NoError(t, err)
+	require.False(t, author.Committed)
+	require.Empty(t, author.String())
+}
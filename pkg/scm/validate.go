@@ -0,0 +1,355 @@
+package scm
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ConfigError describes a malformed value of the config file. Field is the dotted path of
+// the value, such as tokens.github.AccessToken, it's empty when the document is not valid
+// json. Line and Column locate the value in the file, they are 0 when it can't be located.
+type ConfigError struct {
+	Field  string
+	Line   int
+	Column int
+	Msg    string
+}
+
+func (e *ConfigError) Error() string {
+	var b strings.Builder
+	if e.Line > 0 {
+		fmt.Fprintf(&b, "line %d, column %d: ", e.Line, e.Column)
+	}
+	if e.Field != "" {
+		fmt.Fprintf(&b, "%s: ", e.Field)
+	}
+	b.WriteString(e.Msg)
+	return b.String()
+}
+
+// platforms are the source code management platforms that tokens can be stored for
+var platforms = []string{GITHUB, GITLAB, BITBUCKET, GITEA}
+
+// ValidateConfig checks the config document data and returns a ConfigError for every
+// problem that is found, joined with errors.Join. The document must be valid json and its
+// values must have the right type. The keys that are not recognized, tokens of platforms
+// that are not supported and tokens without an AccessToken are reported as well, even
+// though they don't prevent the document from being read. nil is returned when the
+// document is valid.
+func ValidateConfig(data []byte) error {
+	config := Config{}
+	if err := json.Unmarshal(data, &config); err != nil {
+		if errors.Is(err, ErrNewerConfigVersion) {
+			return err
+		}
+		return decodeError(data, err)
+	}
+
+	doc := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return decodeError(data, err)
+	}
+
+	offsets := keyOffsets(data)
+	problem := func(field, msg string) error {
+		line, column := position(data, offsets[field])
+		return &ConfigError{Field: field, Line: line, Column: column, Msg: msg}
+	}
+
+	errs := make([]error, 0)
+	tokens := doc
+	if _, ok := doc[config_key_version]; ok {
+		known := jsonFields(reflect.TypeOf(Config{}))
+		for _, key := range sortedKeys(doc) {
+			if !known[key] {
+				errs = append(errs, problem(key, "unknown field"))
+			}
+		}
+
+		tokens = make(map[string]json.RawMessage)
+		_ = json.Unmarshal(doc["tokens"], &tokens)
+	}
+
+	for _, scm := range sortedKeys(tokens) {
+		if _, ok := doc[config_key_version]; !ok && scm == config_key_hooks {
+			continue
+		}
+
+		field := scm
+		if _, ok := doc[config_key_version]; ok {
+			field = "tokens." + scm
+		}
+
+		if !isPlatform(scm) {
+			msg := fmt.Sprintf("unknown source code management platform, expected one of %s", strings.Join(platforms, ", "))
+			errs = append(errs, problem(field, msg))
+			continue
+		}
+
+		errs = append(errs, validateToken(tokens[scm], field, problem)...)
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateToken reports the unknown keys of the token config located at field, and a
+// missing AccessToken when it doesn't hold a token for the platform or any repository
+func validateToken(data json.RawMessage, field string, problem func(field, msg string) error) []error {
+	errs := make([]error, 0)
+	doc := make(map[string]json.RawMessage)
+	_ = json.Unmarshal(data, &doc)
+
+	known := jsonFields(reflect.TypeOf(ScmTokenConfig{}))
+	for _, key := range sortedKeys(doc) {
+		if !known[key] {
+			errs = append(errs, problem(field+"."+key, "unknown field"))
+		}
+	}
+
+	token := ScmTokenConfig{}
+	_ = json.Unmarshal(data, &token)
+	if token.AccessToken == "" && token.Encrypted == nil && len(token.Repositories) == 0 {
+		errs = append(errs, problem(field, "missing AccessToken"))
+	}
+
+	repos := make(map[string]json.RawMessage)
+	_ = json.Unmarshal(doc["repositories"], &repos)
+	for _, repo := range sortedKeys(repos) {
+		errs = append(errs, validateToken(repos[repo], field+".repositories."+repo, problem)...)
+	}
+
+	return errs
+}
+
+// RepairConfig makes a best effort to repair the config file. The keys that are not
+// recognized, see ValidateConfig, and the values that have the wrong type are dropped and
+// the rest of the document is written back. The dotted paths of the dropped keys are
+// returned. A document that is not valid json can't be repaired.
+func RepairConfig() ([]string, error) {
+	path, err := findConfigFilePath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := make(map[string]any)
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, decodeError(data, err)
+	}
+
+	// every value of the wrong type is reported one at a time by the decoder
+	dropped := make([]string, 0)
+	for {
+		data, err = json.Marshal(doc)
+		if err != nil {
+			return dropped, err
+		}
+
+		err = json.Unmarshal(data, &Config{})
+		typeErr := &json.UnmarshalTypeError{}
+		if err == nil {
+			break
+		}
+
+		if !errors.As(err, &typeErr) || typeErr.Field == "" || !deleteField(doc, typeErr.Field) {
+			return dropped, decodeError(data, err)
+		}
+		dropped = append(dropped, typeErr.Field)
+	}
+
+	for _, field := range unknownFields(ValidateConfig(data)) {
+		if deleteField(doc, field) {
+			dropped = append(dropped, field)
+		}
+	}
+
+	data, err = json.Marshal(doc)
+	if err != nil {
+		return dropped, err
+	}
+
+	config := Config{}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return dropped, decodeError(data, err)
+	}
+	return dropped, WriteConfig(config)
+}
+
+// unknownFields returns the fields of the problems that are dropped by RepairConfig
+func unknownFields(err error) []string {
+	fields := make([]string, 0)
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		return fields
+	}
+
+	for _, e := range joined.Unwrap() {
+		var configErr *ConfigError
+		if errors.As(e, &configErr) && strings.HasPrefix(configErr.Msg, "unknown") {
+			fields = append(fields, configErr.Field)
+		}
+	}
+	return fields
+}
+
+// decodeError converts an error of the json decoder into a ConfigError that locates the
+// malformed value
+func decodeError(data []byte, err error) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		line, column := position(data, syntaxErr.Offset)
+		return &ConfigError{Line: line, Column: column, Msg: syntaxErr.Error()}
+	case errors.As(err, &typeErr):
+		// the offset of a type error is the end of the value
+		line, column := position(data, typeErr.Offset)
+		msg := fmt.Sprintf("expected %s but got %s", jsonKind(typeErr.Type), typeErr.Value)
+		return &ConfigError{Field: typeErr.Field, Line: line, Column: column, Msg: msg}
+	default:
+		return &ConfigError{Msg: err.Error()}
+	}
+}
+
+// keyOffsets returns the offset of every key of the objects in data, keyed by its dotted
+// path
+func keyOffsets(data []byte) map[string]int64 {
+	offsets := make(map[string]int64)
+	decoder := json.NewDecoder(bytes.NewReader(data))
+
+	var walk func(path string) error
+	walk = func(path string) error {
+		tok, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+
+		switch tok {
+		case json.Delim('{'):
+			for decoder.More() {
+				offset := decoder.InputOffset()
+				key, err := decoder.Token()
+				if err != nil {
+					return err
+				}
+
+				field := key.(string)
+				if path != "" {
+					field = path + "." + field
+				}
+
+				offsets[field] = offset + int64(len(data[offset:])-len(bytes.TrimLeft(data[offset:], " \t\r\n,")))
+				if err := walk(field); err != nil {
+					return err
+				}
+			}
+			_, err = decoder.Token()
+		case json.Delim('['):
+			for decoder.More() {
+				if err := walk(path); err != nil {
+					return err
+				}
+			}
+			_, err = decoder.Token()
+		}
+		return err
+	}
+
+	_ = walk("")
+	return offsets
+}
+
+// position returns the line and column, both starting at 1, of the byte at offset. The
+// line is 0 when offset is not in data.
+func position(data []byte, offset int64) (line, column int) {
+	if offset <= 0 || offset > int64(len(data)) {
+		return 0, 0
+	}
+
+	before := data[:offset]
+	line = bytes.Count(before, []byte("\n")) + 1
+	column = len(before) - bytes.LastIndexByte(before, '\n')
+	return line, column
+}
+
+// deleteField deletes the value located at the dotted path field from doc and reports
+// whether it existed
+func deleteField(doc map[string]any, field string) bool {
+	parts := strings.Split(field, ".")
+	for i, part := range parts {
+		// the decoder escapes map keys like a json pointer, so owner/repo is owner~1repo
+		parts[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(part)
+	}
+
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := doc[part].(map[string]any)
+		if !ok {
+			return false
+		}
+		doc = next
+	}
+
+	last := parts[len(parts)-1]
+	if _, ok := doc[last]; !ok {
+		return false
+	}
+	delete(doc, last)
+	return true
+}
+
+// jsonFields returns the names of the json fields of the struct t
+func jsonFields(t reflect.Type) map[string]bool {
+	fields := make(map[string]bool)
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name == "" {
+			name = t.Field(i).Name
+		}
+		fields[name] = true
+	}
+	return fields
+}
+
+// jsonKind describes the json value that a value of t is decoded from
+func jsonKind(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Map, reflect.Struct, reflect.Pointer:
+		return "an object"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	default:
+		return "a number"
+	}
+}
+
+func isPlatform(scm string) bool {
+	for _, platform := range platforms {
+		if scm == platform {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys(m map[string]json.RawMessage) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,131 @@
+package scm_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/AntoninoAdornetto/issue-summoner/pkg/scm"
+	"github.com/stretchr/testify/require"
+)
+
+// readFixture returns the malformed config file in the testdata directory
+func readFixture(t *testing.T, name string) []byte {
+	data, err := os.ReadFile(filepath.Join("testdata", "config", name))
+	require.NoError(t, err)
+	return data
+}
+
+// should locate every problem of the malformed config files
+func TestValidateConfig(t *testing.T) {
+	for _, tc := range []struct {
+		fixture  string
+		problems []string
+	}{
+		{
+			fixture:  "syntax.json",
+			problems: []string{"line 4, column 42: invalid character '}' looking for beginning of object key string"},
+		},
+		{
+			fixture:  "wrong_type.json",
+			problems: []string{"line 5, column 24: tokens.github.AccessToken: expected a string but got number"},
+		},
+		{
+			fixture: "unknown_keys.json",
+			problems: []string{
+				"line 7, column 3: hook: unknown field",
+				"line 4, column 5: tokens.githb: unknown source code management platform",
+				"line 5, column 42: tokens.github.Scopes: unknown field",
+			},
+		},
+		{
+			fixture: "missing_token.json",
+			problems: []string{
+				"line 5, column 32: tokens.gitea.repositories.tech/debt: missing AccessToken",
+				"line 4, column 5: tokens.github: missing AccessToken",
+			},
+		},
+		{
+			fixture:  "legacy_unknown.json",
+			problems: []string{"line 4, column 3: bitbuckt: unknown source code management platform"},
+		},
+	} {
+		err := scm.ValidateConfig(readFixture(t, tc.fixture))
+		require.Error(t, err, tc.fixture)
+
+		var configErr *scm.ConfigError
+		require.True(t, errors.As(err, &configErr), tc.fixture)
+		for _, problem := range tc.problems {
+			require.ErrorContains(t, err, problem, tc.fixture)
+		}
+	}
+}
+
+// should not report any problems for a valid config file
+func TestValidateConfigValid(t *testing.T) {
+	data := []byte(`{"version":1,"tokens":{"github":{"AccessToken":"gho_abc","repositories":{"tech/debt":{"AccessToken":"gho_def"}}}},"hooks":{},"owner":"tech"}`)
+	require.NoError(t, scm.ValidateConfig(data))
+}
+
+// should return an error that locates the malformed value when the config file is read
+func TestReadConfigMalformed(t *testing.T) {
+	writeRawConfig(t, string(readFixture(t, "wrong_type.json")))
+
+	_, err := scm.ReadConfig()
+	var configErr *scm.ConfigError
+	require.True(t, errors.As(err, &configErr))
+	require.Equal(t, "tokens.github.AccessToken", configErr.Field)
+	require.Equal(t, 5, configErr.Line)
+	require.ErrorContains(t, err, "invalid config file")
+}
+
+// should explain why the access token is missing when the token config is malformed
+func TestReadAccessTokenMalformed(t *testing.T) {
+	writeRawConfig(t, string(readFixture(t, "missing_token.json")))
+	t.Setenv("ISSUE_SUMMONER_GITHUB_TOKEN", "")
+	t.Setenv("GITHUB_TOKEN", "")
+
+	_, err := scm.ReadAccessToken(scm.GITHUB, scm.RemoteRepository{})
+	require.ErrorIs(t, err, scm.ErrTokenMissing)
+	require.ErrorContains(t, err, "tokens.github: missing AccessToken")
+}
+
+// should drop the unknown keys and the values of the wrong type and keep the rest
+func TestRepairConfig(t *testing.T) {
+	writeRawConfig(t, `{
+  "version": 1,
+  "tokens": {
+    "githb": {"AccessToken": "gho_typo"},
+    "github": {"AccessToken": "gho_abc", "Scopes": "repo"},
+    "gitea": {"AccessToken": 42}
+  },
+  "hook": {"issue_created": "echo created"},
+  "owner": ["tech"],
+  "repo": "debt"
+}`)
+
+	dropped, err := scm.RepairConfig()
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{
+		"hook",
+		"tokens.githb",
+		"tokens.github.Scopes",
+		"tokens.gitea.AccessToken",
+		"owner",
+	}, dropped)
+
+	config, err := scm.ReadConfig()
+	require.NoError(t, err)
+	require.Equal(t, "gho_abc", config.Tokens[scm.GITHUB].AccessToken)
+	require.Equal(t, "debt", config.Repo)
+	require.Empty(t, config.Owner)
+}
+
+// should not repair a config file that is not valid json
+func TestRepairConfigSyntax(t *testing.T) {
+	writeRawConfig(t, string(readFixture(t, "syntax.json")))
+
+	_, err := scm.RepairConfig()
+	require.ErrorContains(t, err, "line 4")
+}
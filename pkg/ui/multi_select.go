@@ -26,35 +26,60 @@ func (s *Selection) OnSelect(option string, value bool) {
 }
 
 type model struct {
-	cursor   int
-	options  []Item
-	selected map[int]struct{}
-	choices  *Selection
-	header   string
-	exit     *bool
+	cursor    int
+	offset    int
+	height    int
+	maxHeight int // the height of the caller, 0 or less when it's not capped
+	options   []Item
+	selected  map[int]struct{}
+	choices   *Selection
+	header    string
+	exit      *bool
 }
 
+// each option renders a title line, a description line, and a blank line
+const linesPerOption = 3
+
 func (m model) Init() tea.Cmd {
 	return nil
 }
 
+// InitialModelMultiSelect creates a multi select model. height is the number of options
+// that are rendered at once, the list will scroll as the cursor moves past the visible
+// bounds. A height of 0 or less will render every option. The height is lowered to fit
+// the terminal once bubbletea reports the window size, but never raised past height.
 func InitialModelMultiSelect(
 	options []Item,
 	selection *Selection,
 	header string,
 	program *bool,
+	height int,
 ) model {
 	return model{
-		options:  options,
-		selected: make(map[int]struct{}),
-		choices:  selection,
-		header:   AccentTextStyle.Render(header),
-		exit:     program,
+		height:    height,
+		maxHeight: height,
+		options:   options,
+		selected:  make(map[int]struct{}),
+		choices:   selection,
+		header:    AccentTextStyle.Render(header),
+		exit:      program,
 	}
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		// leave room for the header and confirmation footer
+		if h := (msg.Height - 4) / linesPerOption; h > 0 {
+			m.height = h
+			if m.maxHeight > 0 {
+				m.height = min(m.maxHeight, h)
+			}
+
+			// a taller window would leave blank lines below the last option
+			m.offset = min(m.offset, max(0, len(m.options)-m.height))
+			m.scroll()
+		}
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "ctrl+c", "q":
@@ -63,10 +88,12 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "up", "k":
 			if m.cursor > 0 {
 				m.cursor--
+				m.scroll()
 			}
 		case "down", "j":
 			if m.cursor < len(m.options)-1 {
 				m.cursor++
+				m.scroll()
 			}
 		case "enter", " ":
 			_, ok := m.selected[m.cursor]
@@ -86,10 +113,41 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// scroll shifts the visible window so that the cursor is always in view
+func (m *model) scroll() {
+	if m.height <= 0 {
+		return
+	}
+
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+
+	if m.cursor >= m.offset+m.height {
+		m.offset = m.cursor - m.height + 1
+	}
+}
+
+// visible returns the start and end indices of the options that fit in the window
+func (m model) visible() (int, int) {
+	if m.height <= 0 || len(m.options) <= m.height {
+		return 0, len(m.options)
+	}
+
+	end := m.offset + m.height
+	if end > len(m.options) {
+		end = len(m.options)
+	}
+
+	return m.offset, end
+}
+
 func (m model) View() string {
 	s := m.header + "\n\n"
+	start, end := m.visible()
 
-	for i, option := range m.options {
+	for i := start; i < end; i++ {
+		option := m.options[i]
 		cursor := " "
 		if m.cursor == i {
 			cursor = SuccessTextStyle.Render(">")
@@ -108,6 +166,11 @@ func (m model) View() string {
 		s += fmt.Sprintf("%s [%s] %s\n%s\n\n", cursor, checked, title, description)
 	}
 
+	if start > 0 || end < len(m.options) {
+		s += DimTextStyle.Render(fmt.Sprintf("(%d-%d of %d)", start+1, end, len(m.options)))
+		s += "\n"
+	}
+
 	s += fmt.Sprintf("Press %s to confirm choice.\n", AccentTextStyle.Render("y"))
 	return s
 }
@@ -0,0 +1,103 @@
+package ui
+
+import (
+	"fmt"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestModel(n, height int) model {
+	options := make([]Item, n)
+	for i := range options {
+		options[i] = Item{ID: fmt.Sprintf("%d", i), Title: fmt.Sprintf("title-%d", i)}
+	}
+	quit := false
+	return InitialModelMultiSelect(options, &Selection{Options: map[string]bool{}}, "", &quit, height)
+}
+
+func press(m model, key string) model {
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)})
+	return updated.(model)
+}
+
+// should shift the visible slice of options once the cursor moves past
+// the bottom edge of the window and shift it back when moving up again
+func TestMultiSelectScroll(t *testing.T) {
+	m := newTestModel(10, 3)
+	start, end := m.visible()
+	require.Equal(t, 0, start)
+	require.Equal(t, 3, end)
+
+	for i := 0; i < 3; i++ {
+		m = press(m, "j")
+	}
+
+	require.Equal(t, 3, m.cursor)
+	start, end = m.visible()
+	require.Equal(t, 1, start)
+	require.Equal(t, 4, end)
+	require.Contains(t, m.View(), "title-3")
+	require.NotContains(t, m.View(), "title-0")
+
+	for i := 0; i < 3; i++ {
+		m = press(m, "k")
+	}
+
+	require.Equal(t, 0, m.cursor)
+	start, end = m.visible()
+	require.Equal(t, 0, start)
+	require.Equal(t, 3, end)
+}
+
+// should render every option when the height is not set
+func TestMultiSelectNoHeight(t *testing.T) {
+	m := newTestModel(5, 0)
+	start, end := m.visible()
+	require.Equal(t, 0, start)
+	require.Equal(t, 5, end)
+}
+
+func resize(m model, height int) model {
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 80, Height: height})
+	return updated.(model)
+}
+
+// should shrink the window to fit the terminal height, but not grow it past the height
+// of the caller
+func TestMultiSelectWindowSize(t *testing.T) {
+	m := resize(newTestModel(20, 10), 13)
+	require.Equal(t, 3, m.height)
+
+	m = resize(m, 34)
+	require.Equal(t, 10, m.height)
+
+	m = resize(m, 100)
+	require.Equal(t, 10, m.height)
+}
+
+// should fit the terminal height when the caller renders every option
+func TestMultiSelectWindowSizeNoHeight(t *testing.T) {
+	m := resize(newTestModel(20, 0), 34)
+	require.Equal(t, 10, m.height)
+}
+
+// should clamp the offset when the window grows, so no blank lines are left below the
+// last option
+func TestMultiSelectWindowGrows(t *testing.T) {
+	m := resize(newTestModel(10, 10), 13)
+	for i := 0; i < 9; i++ {
+		m = press(m, "j")
+	}
+	start, end := m.visible()
+	require.Equal(t, 7, start)
+	require.Equal(t, 10, end)
+
+	m = resize(m, 22)
+	require.Equal(t, 6, m.height)
+	start, end = m.visible()
+	require.Equal(t, 4, start)
+	require.Equal(t, 10, end)
+	require.Equal(t, 9, m.cursor)
+}
@@ -1,19 +1,42 @@
 package utils
 
 import (
+	"errors"
+	"os"
 	"os/exec"
 	"runtime"
 )
 
+var ErrNoDisplay = errors.New("no display is available to open a browser")
+
+// OpenBrowser opens url with the default browser of the operating system. On linux and
+// the BSDs an error is returned, without running a command, when there isn't a display.
 func OpenBrowser(url string) error {
-	var cmd *exec.Cmd
-	switch runtime.GOOS {
+	if !hasDisplay(runtime.GOOS) {
+		return ErrNoDisplay
+	}
+
+	name, args := BrowserCommand(runtime.GOOS, url)
+	return exec.Command(name, args...).Run()
+}
+
+// BrowserCommand returns the program, and the arguments, that open url on goos
+func BrowserCommand(goos, url string) (string, []string) {
+	switch goos {
 	case "windows":
-		cmd = exec.Command("cmd", "/c", "start", url)
+		return "rundll32", []string{"url.dll,FileProtocolHandler", url}
 	case "darwin":
-		cmd = exec.Command("open", url)
+		return "open", []string{url}
+	default:
+		return "xdg-open", []string{url}
+	}
+}
+
+func hasDisplay(goos string) bool {
+	switch goos {
+	case "windows", "darwin":
+		return true
 	default:
-		cmd = exec.Command("xdg-open", url)
+		return os.Getenv("DISPLAY") != "" || os.Getenv("WAYLAND_DISPLAY") != ""
 	}
-	return cmd.Run()
 }
@@ -0,0 +1,24 @@
+package utils_test
+
+import (
+	"testing"
+
+	"github.com/AntoninoAdornetto/issue-summoner/pkg/utils"
+	"github.com/stretchr/testify/require"
+)
+
+// should use the default browser launcher of each operating system
+func TestBrowserCommand(t *testing.T) {
+	url := "https://github.com/login/device"
+	cases := map[string][]string{
+		"windows": {"rundll32", "url.dll,FileProtocolHandler", url},
+		"darwin":  {"open", url},
+		"linux":   {"xdg-open", url},
+		"freebsd": {"xdg-open", url},
+	}
+
+	for goos, expected := range cases {
+		name, args := utils.BrowserCommand(goos, url)
+		require.Equal(t, expected, append([]string{name}, args...), goos)
+	}
+}
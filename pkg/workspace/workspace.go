@@ -0,0 +1,102 @@
+/*
+Package workspace scans every git repository beneath a directory in one invocation.
+Each repository is walked with its own ignore files and issue manager, and a failure
+in one repository is recorded in its result rather than stopping the others.
+*/
+package workspace
+
+import (
+	"errors"
+
+	"github.com/AntoninoAdornetto/issue-summoner/pkg/issue"
+	"github.com/AntoninoAdornetto/issue-summoner/pkg/scm"
+)
+
+// DEFAULT_MAX_DEPTH is how many directories beneath the workspace are searched for
+// repositories when a depth is not provided
+const DEFAULT_MAX_DEPTH = 3
+
+// Result is the outcome of scanning a single repository. Manager is nil when Err was
+// returned before the repository could be walked. FileErrors are the files that could not
+// be scanned, the issues of the other files are still in Issues.
+type Result struct {
+	Repository *scm.Repository
+	Manager    issue.IssueManager
+	Issues     []issue.Issue
+	FileErrors issue.FileErrors
+	Err        error
+}
+
+// Options configures how each repository is scanned. Params returns the walk params for
+// the root of a repository, which allows a scan cache or ignore file to be set per
+// repository. When it is nil, the root is walked with the default params.
+type Options struct {
+	Mode       string
+	Annotation string
+	MaxDepth   int
+	Params     func(root string) issue.WalkParams
+}
+
+// Scan discovers the repositories beneath dir and scans each of them
+func Scan(dir string, opts Options) ([]Result, error) {
+	if opts.MaxDepth <= 0 {
+		opts.MaxDepth = DEFAULT_MAX_DEPTH
+	}
+
+	repos, err := scm.FindRepositories(dir, opts.MaxDepth)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]Result, len(repos))
+	for i, repo := range repos {
+		results[i] = scanRepository(repo, opts)
+	}
+
+	return results, nil
+}
+
+func scanRepository(repo *scm.Repository, opts Options) Result {
+	result := Result{Repository: repo}
+	im, err := issue.NewIssueManager(opts.Mode, opts.Annotation)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	params := issue.WalkParams{Root: repo.WorkTree}
+	if opts.Params != nil {
+		params = opts.Params(repo.WorkTree)
+	}
+
+	result.Manager = im
+	if _, err := im.Walk(params); err != nil {
+		if !errors.As(err, &result.FileErrors) {
+			result.Err = err
+			return result
+		}
+	}
+
+	result.Issues = im.GetIssues()
+	return result
+}
+
+// Total returns the number of issues found across every repository
+func Total(results []Result) int {
+	n := 0
+	for _, result := range results {
+		n += len(result.Issues)
+	}
+	return n
+}
+
+// Failed returns the results of the repositories that could not be scanned
+func Failed(results []Result) []Result {
+	failed := make([]Result, 0)
+	for _, result := range results {
+		if result.Err != nil {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
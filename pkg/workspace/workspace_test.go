@@ -0,0 +1,114 @@
+package workspace_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/AntoninoAdornetto/issue-summoner/pkg/issue"
+	"github.com/AntoninoAdornetto/issue-summoner/pkg/workspace"
+	"github.com/stretchr/testify/require"
+)
+
+const annotation = "@TEST_TODO"
+
+// writeRepo creates a repository fixture at dir/name with the provided files
+func writeRepo(t *testing.T, dir, name string, files map[string]string) string {
+	root := filepath.Join(dir, name)
+	require.NoError(t, os.MkdirAll(filepath.Join(root, ".git"), 0755))
+	for path, content := range files {
+		path = filepath.Join(root, path)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	return root
+}
+
+func newWorkspace(t *testing.T) string {
+	dir := t.TempDir()
+	writeRepo(t, dir, "api", map[string]string{
+		".gitignore":              "build/\n",
+		"main.go":                 "package main\n\n// @TEST_TODO add graceful shutdown\nfunc main() {}\n",
+		"build/gen.go":            "// @TEST_TODO ignored by the gitignore of api\n",
+		"third_party/lib/main.go": "// @TEST_TODO a nested repository\n",
+	})
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "api", "third_party", "lib", ".git"), 0755))
+
+	writeRepo(t, dir, "services/web", map[string]string{
+		".issueignore": "*.min.js\n",
+		"app.js":       "// @TEST_TODO handle offline mode\n/* @TEST_TODO cache assets */\n",
+		"app.min.js":   "// @TEST_TODO ignored by the issueignore of web\n",
+	})
+
+	// an ignore file that is a directory can't be read, which fails the walk of the repository
+	writeRepo(t, dir, "broken", map[string]string{
+		".issueignore/README": "not an ignore file\n",
+		"main.c":              "// @TEST_TODO never scanned\n",
+	})
+
+	return dir
+}
+
+// should scan every repository with its own ignore files and isolate failures
+func TestScan(t *testing.T) {
+	dir := newWorkspace(t)
+	results, err := workspace.Scan(dir, workspace.Options{
+		Mode:       issue.PENDING_ISSUE,
+		Annotation: annotation,
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+
+	api, broken, web := results[0], results[1], results[2]
+	require.Equal(t, filepath.Join(dir, "api"), api.Repository.WorkTree)
+	require.NoError(t, api.Err)
+	require.Len(t, api.Issues, 2)
+	require.Equal(t, "add graceful shutdown", api.Issues[0].Title)
+	require.Equal(t, "a nested repository", api.Issues[1].Title)
+
+	require.Equal(t, filepath.Join(dir, "broken"), broken.Repository.WorkTree)
+	require.Error(t, broken.Err)
+	require.Empty(t, broken.Issues)
+
+	require.Equal(t, filepath.Join(dir, "services", "web"), web.Repository.WorkTree)
+	require.NoError(t, web.Err)
+	require.Len(t, web.Issues, 2)
+	require.Equal(t, "handle offline mode", web.Issues[0].Title)
+	require.Equal(t, "cache assets", web.Issues[1].Title)
+
+	require.Equal(t, 4, workspace.Total(results))
+	require.Equal(t, []workspace.Result{broken}, workspace.Failed(results))
+}
+
+// should only discover the repositories within the max depth
+func TestScanMaxDepth(t *testing.T) {
+	dir := newWorkspace(t)
+	results, err := workspace.Scan(dir, workspace.Options{
+		Mode:       issue.PENDING_ISSUE,
+		Annotation: annotation,
+		MaxDepth:   1,
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	require.Equal(t, filepath.Join(dir, "api"), results[0].Repository.WorkTree)
+	require.Equal(t, filepath.Join(dir, "broken"), results[1].Repository.WorkTree)
+}
+
+// should use the walk params that are returned for each repository
+func TestScanParams(t *testing.T) {
+	dir := newWorkspace(t)
+	roots := make([]string, 0)
+	results, err := workspace.Scan(dir, workspace.Options{
+		Mode:       issue.PENDING_ISSUE,
+		Annotation: annotation,
+		Params: func(root string) issue.WalkParams {
+			roots = append(roots, root)
+			return issue.WalkParams{Root: root, IssueIgnorePath: filepath.Join(dir, "none")}
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, roots, 3)
+
+	// every repository fails, since the ignore file does not exist
+	require.Len(t, workspace.Failed(results), 3)
+}
@@ -0,0 +1,59 @@
+package templates_test
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/AntoninoAdornetto/issue-summoner/pkg/issue"
+	"github.com/AntoninoAdornetto/issue-summoner/templates"
+	"github.com/stretchr/testify/require"
+)
+
+var is = issue.Issue{
+	Title:       "add a flag for the greeting",
+	Description: "so that it can be customized",
+	FileName:    "main.go",
+	LineNumber:  5,
+}
+
+// should render the author, the permalink and a fenced code block of the snippet
+func TestIssueTemplateSource(t *testing.T) {
+	tmpl, err := templates.LoadIssueTemplate()
+	require.NoError(t, err)
+
+	md, err := is.ExecuteIssueTemplate(tmpl, issue.Source{
+		Snippet:   "// @TODO add a flag for the greeting\nfunc main() {}",
+		Language:  "go",
+		Fence:     "```",
+		StartLine: 5,
+		EndLine:   6,
+		Permalink: "https://github.com/tech/debt/blob/main/main.go#L5",
+		Author:    "Jane Doe",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "### Description\n\n"+
+		"so that it can be customized\n\n"+
+		"### Location\n\n"+
+		"***File name:*** `main.go` ***Line number:*** `5`\n\n"+
+		"***Reported by:*** Jane Doe\n\n"+
+		"https://github.com/tech/debt/blob/main/main.go#L5\n\n"+
+		"```go\n"+
+		"// @TODO add a flag for the greeting\n"+
+		"func main() {}\n"+
+		"```\n\n"+
+		"### Environment\n\n"+
+		runtime.GOOS+"\n\n"+
+		"### Generated with :heart:\n\n"+
+		"created by [issue-summoner](https://github.com/AntoninoAdornetto/issue-summoner)\n\n", string(md))
+}
+
+// should leave out the author, snippet and permalink when they are empty
+func TestIssueTemplateNoSource(t *testing.T) {
+	tmpl, err := templates.LoadIssueTemplate()
+	require.NoError(t, err)
+
+	md, err := is.ExecuteIssueTemplate(tmpl, issue.Source{})
+	require.NoError(t, err)
+	require.Contains(t, string(md), "***Line number:*** `5`\n\n### Environment")
+	require.NotContains(t, string(md), "```")
+}